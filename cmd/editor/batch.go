@@ -0,0 +1,161 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/editor/batch.go
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+// The extension of a level's metadata file, kept alongside its .hsb map.
+const SIDECAR_EXT = ".meta"
+
+// Converts each of the .hsb files to a .json puzzle definition without any
+// prompts, writing a line for each and a summary to the output.  Returns the
+// number of inputs that could not be converted.
+//
+// A level's properties come from the headers following its map in the .hsb
+// file, then from the headers in its sidecar file (e.g. 001.meta for 001.hsb,
+// see SIDECAR_EXT), either of which is in the collection header format:
+//
+//	Title: Treasure Room
+//	Author: Anonymous
+//	Difficulty: 1
+//
+// Properties found in neither are taken from the defaults (from the flags, e.g.
+// the author for a whole pack).  A missing title or author is an error.  The
+// definition is written next to the input or into outDir if it is not empty,
+// and the ID defaults to the name of that directory (its collection) and the
+// file's name, as in "DWS/001".  Existing definitions are updated in
+// place, as when editing them interactively.
+func runBatch(output io.Writer, paths []string, defaults hexoban.Puzzle, outDir string) int {
+	created, updated, unchanged, failed := 0, 0, 0, 0
+	for _, path := range paths {
+		outpath, status, err := convertLevel(path, defaults, outDir)
+		if err != nil {
+			fmt.Fprintf(output, "%s: %s\n", path, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(output, "%s -> %s (%s)\n", path, outpath, status)
+		switch status {
+		case "created":
+			created++
+		case "updated":
+			updated++
+		default:
+			unchanged++
+		}
+	}
+	fmt.Fprintf(output, "%d converted (%d created, %d updated, %d unchanged), %d failed\n",
+		created+updated+unchanged, created, updated, unchanged, failed)
+	return failed
+}
+
+// Converts one .hsb file, returning the path written and whether the file was
+// "created", "updated" or "unchanged".
+func convertLevel(path string, defaults hexoban.Puzzle, outDir string) (string, string, error) {
+	if !strings.HasSuffix(path, ".hsb") {
+		return "", "", fmt.Errorf("not an .hsb file")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	collection, err := textmap.DecodeCollection(file)
+	file.Close()
+	if err != nil {
+		return "", "", err
+	}
+	if len(collection.Levels) != 1 {
+		return "", "", fmt.Errorf("has %d maps, split it with `hexoban collection -x` first",
+			len(collection.Levels))
+	}
+	puzzle := collection.Levels[0].Puzzle
+
+	base := strings.TrimSuffix(path, ".hsb")
+	sidecar := hexoban.Puzzle{}
+	if file, err := os.Open(base + SIDECAR_EXT); err == nil {
+		sidecar, err = textmap.DecodeHeaders(file)
+		file.Close()
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", base+SIDECAR_EXT, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", "", err
+	}
+
+	// The first non-empty value of each property is used.
+	first := func(values ...string) string {
+		for _, value := range values {
+			if value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	puzzle.Title = first(puzzle.Title, sidecar.Title, collection.Title, defaults.Title)
+	puzzle.Author = first(puzzle.Author, sidecar.Author, collection.Author, defaults.Author)
+	puzzle.Source = first(puzzle.Source, sidecar.Source, collection.Source, defaults.Source)
+	outpath := base + ".json"
+	if outDir != "" {
+		outpath = filepath.Join(outDir, filepath.Base(outpath))
+	}
+	collectionDir, err := filepath.Abs(filepath.Dir(outpath))
+	if err != nil {
+		return "", "", err
+	}
+	puzzle.Identity = first(puzzle.Identity, sidecar.Identity,
+		filepath.Base(collectionDir)+"/"+filepath.Base(base))
+	for _, difficulty := range []int{sidecar.Difficulty, defaults.Difficulty} {
+		if puzzle.Difficulty == 0 {
+			puzzle.Difficulty = difficulty
+		}
+	}
+	if puzzle.Title == "" {
+		return "", "", fmt.Errorf("missing title (a Title: header, %s or -title)", SIDECAR_EXT)
+	}
+	if puzzle.Author == "" {
+		return "", "", fmt.Errorf("missing author (an Author: header, %s or -author)", SIDECAR_EXT)
+	}
+	if _, err := hexoban.NewState(puzzle); err != nil {
+		return "", "", err
+	}
+
+	filedata, err := os.ReadFile(outpath)
+	status := "updated"
+	if os.IsNotExist(err) {
+		filedata, status = []byte(EMPTY_PUZZLE_JSON), "created"
+	} else if err != nil {
+		return "", "", err
+	}
+	written, err := updatePuzzleJSON(filedata, puzzle)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", outpath, err)
+	}
+	if bytes.Equal(written, filedata) {
+		return outpath, "unchanged", nil
+	}
+	return outpath, status, os.WriteFile(outpath, written, 0644)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/editor/batch_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+const batchMap = "  # # #\n #     #\n# @ $ . #\n # # # #\n"
+
+func TestRunBatch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"one.hsb":   batchMap + "Title: First\nDifficulty: 2\n",
+		"two.hsb":   batchMap,
+		"two.meta":  "Title: Second\nID: pack/2\nAuthor: Someone Else\n",
+		"three.hsb": batchMap + "Author: Nobody\n",
+		"four.hsb":  batchMap + "Title: Fourth\n\n" + batchMap + "Title: Fifth\n",
+		"five.txt":  batchMap,
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := []string{"one.hsb", "two.hsb", "three.hsb", "four.hsb", "five.txt"}
+	for index, name := range paths {
+		paths[index] = filepath.Join(dir, name)
+	}
+	outDir := filepath.Join(dir, "Pack")
+	if err := os.Mkdir(outDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defaults := hexoban.Puzzle{Author: "Pack Author", Source: "example.com"}
+
+	var output strings.Builder
+	if failed := runBatch(&output, paths, defaults, outDir); failed != 3 {
+		t.Errorf("runBatch() failed = %d, want 3\n%s", failed, output.String())
+	}
+	for _, want := range []string{
+		"three.hsb: missing title",
+		"four.hsb: has 2 maps",
+		"five.txt: not an .hsb file",
+		"2 converted (2 created, 0 updated, 0 unchanged), 3 failed",
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, output.String())
+		}
+	}
+
+	tests := []struct {
+		name string
+		want hexoban.Puzzle
+	}{
+		{"one.json", hexoban.Puzzle{Identity: "Pack/one", Title: "First",
+			Author: "Pack Author", Source: "example.com", Difficulty: 2}},
+		{"two.json", hexoban.Puzzle{Identity: "pack/2", Title: "Second",
+			Author: "Someone Else", Source: "example.com"}},
+	}
+	for _, tt := range tests {
+		filedata, err := os.ReadFile(filepath.Join(outDir, tt.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var puzzle hexoban.Puzzle
+		if err := json.Unmarshal(filedata, &puzzle); err != nil {
+			t.Fatal(err)
+		}
+		if puzzle.Identity != tt.want.Identity || puzzle.Title != tt.want.Title ||
+			puzzle.Author != tt.want.Author || puzzle.Source != tt.want.Source ||
+			puzzle.Difficulty != tt.want.Difficulty || len(puzzle.Terrain) != 5 {
+			t.Errorf("%s = %+v, want %+v", tt.name, puzzle, tt.want)
+		}
+	}
+
+	// Converting again leaves the definitions as they are.
+	output.Reset()
+	runBatch(&output, paths[:2], defaults, outDir)
+	if !strings.Contains(output.String(), "2 converted (0 created, 0 updated, 2 unchanged), 0 failed") {
+		t.Errorf("second run:\n%s", output.String())
+	}
+}
@@ -24,13 +24,19 @@ import (
 	"strings"
 
 	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/textmap"
 )
 
 type Builder interface {
 	// Builds a puzzle definition from flags and user input.
 	ParseMeta(*bufio.Reader) error
-	// Builds the Terrain and Init properties from a grid representation.
+	// Builds the Terrain and Init properties from a grid representation,
+	// replacing any map that was previously parsed or preset.
 	ParsePuzzleMap(*bufio.Reader) error
+	// Starts from an existing puzzle definition, its metadata is used for any
+	// input which has not already been given a value (e.g. by its flag) and its
+	// map, if it has one, replaces the current map.
+	Preset(hexoban.Puzzle)
 
 	// Retrieve the puzzle definition
 	// after BuildMeta() and/or ParsePuzzleMap()
@@ -56,6 +62,7 @@ type Builder interface {
 	// List of field names (assumed to be string valued)
 	// which may also be written to the json representation.
 	// User will be prompted for additional fields, which must be from this list.
+	// Each is also a flag, unless a flag of the same name is already defined.
 	OptionalFields(names ...string)
 }
 
@@ -71,7 +78,59 @@ type builderState struct {
 }
 
 func (state *builderState) GetPuzzle() hexoban.Puzzle {
-	return *state.puzzle
+	puzzle := *state.puzzle
+	for _, input := range state.inputs {
+		switch value := input.Value().(type) {
+		case string:
+			if value == "" {
+				continue
+			}
+			switch input.Name() {
+			case "id":
+				puzzle.Identity = value
+			case "title":
+				puzzle.Title = value
+			case "author":
+				puzzle.Author = value
+			case "source":
+				puzzle.Source = value
+			case "difficulty":
+				if difficulty, err := strconv.Atoi(value); err == nil && difficulty > 0 {
+					puzzle.Difficulty = difficulty
+				}
+			}
+		case int:
+			if input.Name() == "difficulty" && value > 0 {
+				puzzle.Difficulty = value
+			}
+		}
+	}
+	return puzzle
+}
+
+func (state *builderState) Preset(puzzle hexoban.Puzzle) {
+	metadata := map[string]string{
+		"id":     puzzle.Identity,
+		"title":  puzzle.Title,
+		"author": puzzle.Author,
+		"source": puzzle.Source,
+	}
+	for _, input := range state.inputs {
+		switch in := input.(type) {
+		case *StringInput:
+			if in.value == "" {
+				in.value = metadata[in.Name()]
+			}
+		case *IntegerInput:
+			if in.Name() == "difficulty" && in.value == 0 {
+				in.value = puzzle.Difficulty
+			}
+		}
+	}
+	if len(puzzle.Terrain) > 0 {
+		state.puzzle.Terrain = puzzle.Terrain
+		state.puzzle.Init = puzzle.Init
+	}
 }
 
 func (state *builderState) ParseMeta(io *bufio.Reader) error {
@@ -81,7 +140,7 @@ func (state *builderState) ParseMeta(io *bufio.Reader) error {
 
 	for _, input := range state.inputs {
 		// prompt for each input that is required and undefined,
-		if input.Required() {
+		if input.Required() && isEmpty(input) {
 			if err := readInput(io, input); err != nil {
 				return err
 			}
@@ -100,6 +159,7 @@ func (state *builderState) ParseMeta(io *bufio.Reader) error {
 				if err := readInput(io, input); err != nil {
 					return err
 				}
+				break
 			}
 		}
 	}
@@ -107,25 +167,59 @@ func (state *builderState) ParseMeta(io *bufio.Reader) error {
 	return nil
 }
 
+func isEmpty(input IInput) bool {
+	switch value := input.Value().(type) {
+	case string:
+		return value == ""
+	case int:
+		return value == 0
+	}
+	return true
+}
+
+// Prompts for the input's value.  If it already has a value, that value is
+// shown with the prompt and an empty response keeps it.
 func readInput(io *bufio.Reader, input IInput) error {
-	str, ok := input.Value().(string)
-	if ok && str == "" {
-		input.(*StringInput).value = readNonemptyLine(io, input.Prompt()+"? ")
-		return nil
+	prompt := input.Prompt()
+	if prompt == "" {
+		prompt = input.Name()
 	}
 
-	integer, ok := input.Value().(int)
-	if ok && integer == 0 {
-		// All integer attributes here are optional, gracefully handle errors.
-		input.(*IntegerInput).value = readInteger(io, input.Prompt()+"? ", 0)
+	switch in := input.(type) {
+	case *StringInput:
+		if in.value == "" {
+			in.value = readNonemptyLine(io, prompt+"? ")
+			return nil
+		}
+		reply := strings.TrimSpace(readLine(io, fmt.Sprintf("%s [%s]? ", prompt, in.value)))
+		if reply != "" {
+			in.value = reply
+		}
+		return nil
+	case *IntegerInput:
+		if in.value == 0 {
+			// All integer attributes here are optional, gracefully handle errors.
+			in.value = readInteger(io, prompt+"? ", 0)
+			return nil
+		}
+		reply := strings.TrimSpace(readLine(io, fmt.Sprintf("%s [%d]? ", prompt, in.value)))
+		if reply != "" && in.Validate(reply) {
+			in.value, _ = strconv.Atoi(reply)
+		}
 		return nil
 	}
 
-	return fmt.Errorf("unrecognized type for required param %s: %t", input.Name(), input)
+	return fmt.Errorf("unrecognized type for param %s: %T", input.Name(), input)
 }
 
 func (state *builderState) ParsePuzzleMap(io *bufio.Reader) error {
-	return ParsePuzzleDefinition(io, state.puzzle)
+	parsed := hexoban.Puzzle{}
+	if err := textmap.ParsePuzzleDefinition(io, &parsed); err != nil {
+		return err
+	}
+	state.puzzle.Terrain = parsed.Terrain
+	state.puzzle.Init = parsed.Init
+	return nil
 }
 
 type IInput interface {
@@ -258,16 +352,19 @@ func (state *builderState) IntegerInput(
 // The names should be lowercase and match the JSON field names for Puzzle.
 func (state *builderState) OptionalFields(names ...string) {
 	for _, name := range names {
-		state.inputs = append(state.inputs,
-			&StringInput{
-				input{
-					name,
-					"",
-					false,
-				},
+		strInput := StringInput{
+			input{
+				name,
 				"",
-				func(input string) bool { return len(input) > 0 },
-			})
+				false,
+			},
+			"",
+			func(input string) bool { return len(input) > 0 },
+		}
+		if flag.Lookup(name) == nil {
+			flag.StringVar(&strInput.value, name, "", "The puzzle's "+name)
+		}
+		state.inputs = append(state.inputs, &strInput)
 	}
 }
 
@@ -307,18 +404,10 @@ func yesnoPrompt(reader *bufio.Reader, prompt string, otherwise bool) bool {
 		prompt += " y/[N] "
 	}
 
-	switch strings.ToLower(readLine(reader, prompt)) {
-	case "yes":
-	case "y":
-	case "yeah":
-	case "t":
-	case "true":
+	switch strings.ToLower(strings.TrimSpace(readLine(reader, prompt))) {
+	case "yes", "y", "yeah", "t", "true":
 		return true
-	case "no":
-	case "n":
-	case "nah":
-	case "f":
-	case "false":
+	case "no", "n", "nah", "f", "false":
 		return false
 	}
 
@@ -18,12 +18,18 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render/ansi"
+	"github.com/SymbolNotFound/hexoban/textmap"
 )
 
 func main() {
@@ -34,67 +40,164 @@ func main() {
 	builder.RequiredString("title", "", "The puzzle's title", nil, []string{"t", "name", "n"})
 	builder.IntegerInput("difficulty", 0, "the puzzle's difficulty (zero for unknown)",
 		func(in int) bool { return in >= 0 }, []string{"d"})
-	builder.OptionalFields("difficulty", "source", "id", "map")
+	builder.RequiredString("id", "", "The puzzle's ID, and file name",
+		func(s string) bool { return true }, []string{})
+	builder.OptionalFields("source")
+	batch := flag.Bool("batch", false,
+		"convert each .hsb file argument to JSON, taking properties from flags and headers instead of prompts")
+	outDir := flag.String("o", "", "with -batch, the directory to write JSON files into (default: beside each input)")
+	tui := flag.Bool("tui", false, "edit the level (a .json or .hsb file) in a full-screen editor")
 
 	// check for filename input
 	flag.Parse()
+	if *batch {
+		if flag.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "usage: editor -batch [-o dir] [-author a] [-source s] file.hsb...")
+			os.Exit(2)
+		}
+		if runBatch(os.Stdout, flag.Args(), builder.GetPuzzle(), *outDir) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if *tui {
+		path := flag.Arg(0)
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "usage: editor -tui [-author a] [-title t] level.json|level.hsb")
+			os.Exit(2)
+		}
+		if !strings.HasSuffix(path, ".hsb") && !strings.HasSuffix(path, ".json") {
+			path += ".json"
+		}
+		if err := runTUI(path, builder.GetPuzzle()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 	stdin := bufio.NewReader(os.Stdin)
 	outpath := filepath.Clean(flag.Arg(0))
 
 	// if input file (first argument) exists
 	// and its extension indicates it is HSB,
 	// attempt to parse its contents as a puzzle.
+	// If it is an existing .json file, the puzzle is being updated.
+	var existing *hexoban.Puzzle
+	filedata := []byte(EMPTY_PUZZLE_JSON)
 	if strings.HasSuffix(outpath, ".hsb") {
 		f, err := os.Open(outpath)
 		if err == nil {
 			reader := bufio.NewReader(f)
 			err = builder.ParsePuzzleMap(reader)
 			if err == nil {
-				fmt.Println(MapString(builder.GetPuzzle()))
+				printMap(builder.GetPuzzle())
 			} else {
-				fmt.Println(err)
+				printParseError(err)
 			}
 		} else {
 			fmt.Println(err)
 		}
-	} else if !strings.HasSuffix(outpath, ".json") && outpath != "" {
+	} else if strings.HasSuffix(outpath, ".json") {
+		if data, err := os.ReadFile(outpath); err == nil {
+			puzzle := hexoban.Puzzle{}
+			if err := json.Unmarshal(data, &puzzle); err != nil {
+				fmt.Printf("%s: %s\n", outpath, err)
+				os.Exit(1)
+			}
+			existing, filedata = &puzzle, data
+			fmt.Printf("Updating %s\n", outpath)
+			fmt.Println(Info(puzzle))
+			printMap(puzzle)
+			builder.Preset(puzzle)
+		}
+	} else if outpath != "" {
 		// Append the extension .json if it isn't found.
-		// TODO perhaps if we find existing .json file we are updating a puzzle.
 		// TODO this complicates the `id` representation later, prefer extensionless (here, and for .hsb above).
 		outpath += ".json"
 	}
-	builder.RequiredString("id", outpath, "The puzzle's ID, and file name",
-		func(s string) bool { return true }, []string{})
+	if existing == nil {
+		builder.Preset(hexoban.Puzzle{Identity: outpath})
+	}
 
 	builder.ParseMeta(stdin)
+	if existing != nil && yesnoPrompt(stdin, "Re-enter the map?", false) {
+		readPuzzleMap(builder, stdin)
+	}
 	for len(builder.GetPuzzle().Terrain) == 0 {
-		// read puzzle contents from stdin.
-		fmt.Println("Enter puzzle as a doubled-height offset grid here:")
-		err := builder.ParsePuzzleMap(stdin)
-		if err == nil {
-			fmt.Println(MapString(builder.GetPuzzle()))
-		} else {
-			fmt.Println(err)
-			fmt.Println("\nerror parsing puzzle, try again:")
-		}
+		readPuzzleMap(builder, stdin)
 	}
 
 	puzzle := builder.GetPuzzle()
+	if existing != nil {
+		puzzle = alignMap(puzzle, *existing)
+	}
 	fmt.Println("Successfully parsed metadata & puzzle definition.")
 	fmt.Println(Info(puzzle))
 
-	if yesnoPrompt(stdin, "Write to JSON file?", true) {
-		// Write the puzzle to JSON-serialized format.
-		bytes, err := json.Marshal(puzzle)
+	updated, err := updatePuzzleJSON(filedata, puzzle)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	prompt := "Write to JSON file?"
+	if existing != nil {
+		if bytes.Equal(updated, filedata) {
+			fmt.Println("No changes.")
+			return
+		}
+		writeDiff(os.Stdout, filedata, updated)
+		prompt = fmt.Sprintf("Write changes to %s?", outpath)
+	}
+	if yesnoPrompt(stdin, prompt, true) {
+		err = os.WriteFile(outpath, updated, 0644)
 		if err == nil {
-			err = os.WriteFile(outpath, bytes, 0644)
-			if err == nil {
-				fmt.Printf("Wrote %d bytes to %s\n", len(bytes), outpath)
-			} else {
-				fmt.Println(err)
-			}
+			fmt.Printf("Wrote %d bytes to %s\n", len(updated), outpath)
 		} else {
 			fmt.Println(err)
 		}
 	}
 }
+
+// Prints the puzzle's map, in color when stdout is a terminal.
+func printMap(puzzle hexoban.Puzzle) {
+	mode := ansi.ForOutput(ansi.MODE_AUTO, os.Stdout)
+	if mapString, err := ansi.Render(puzzle, ansi.Options{Mode: mode}); err == nil {
+		fmt.Println(mapString)
+	} else {
+		fmt.Println(err)
+	}
+}
+
+// Reads the puzzle's map from stdin, repeating until it parses successfully.
+func readPuzzleMap(builder Builder, stdin *bufio.Reader) {
+	for {
+		fmt.Println("Enter puzzle as a doubled-height offset grid here:")
+		err := builder.ParsePuzzleMap(stdin)
+		if err == nil {
+			printMap(builder.GetPuzzle())
+			return
+		}
+		printParseError(err)
+		if _, err := stdin.Peek(1); err != nil {
+			// There is nothing more to read, trying again would not end.
+			os.Exit(1)
+		}
+		fmt.Println("\nerror parsing puzzle, try again:")
+	}
+}
+
+// Returns a string with basic information about the puzzle.
+func Info(puzzle hexoban.Puzzle) string {
+	// TODO: what to put here?  sizeof Terrain?  #crates|goals?  Complexity?
+	return fmt.Sprintf("%s\nby %s\n\n", puzzle.Title, puzzle.Author)
+}
+
+// Prints the parser's errors with the lines they were found on, if available.
+func printParseError(err error) {
+	var list textmap.ErrorList
+	if errors.As(err, &list) {
+		fmt.Println(list.Pretty())
+	} else {
+		fmt.Println(err)
+	}
+}
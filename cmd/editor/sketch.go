@@ -0,0 +1,413 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/editor/sketch.go
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+// The level being drawn in the full-screen editor, as a glyph for each of its
+// hexes (using the glyphs of the text map format, see textmap.TokenType).  Hexes
+// that are not in the sketch are outside of the level.  Walls are kept as they
+// are painted while editing, but only the floors are saved (as the terrain),
+// the walls are drawn around them when the level is displayed again.
+type sketch struct {
+	cells    map[hexoban.HexCoord]textmap.TokenType
+	cursor   hexoban.HexCoord
+	symmetry symmetry
+	center   hexoban.HexCoord // of the symmetry, where the cursor was when chosen.
+
+	undos, redos []map[hexoban.HexCoord]textmap.TokenType
+}
+
+// The symmetry of painting, each paint is repeated at the other positions of
+// the same orbit around the symmetry's center.
+type symmetry int
+
+const (
+	SYMMETRY_NONE symmetry = iota
+	SYMMETRY_MIRROR
+	SYMMETRY_TWOFOLD
+	SYMMETRY_THREEFOLD
+	SYMMETRY_SIXFOLD
+)
+
+func (sym symmetry) String() string {
+	switch sym {
+	case SYMMETRY_MIRROR:
+		return "mirror"
+	case SYMMETRY_TWOFOLD:
+		return "2-fold"
+	case SYMMETRY_THREEFOLD:
+		return "3-fold"
+	case SYMMETRY_SIXFOLD:
+		return "6-fold"
+	}
+	return "off"
+}
+
+// Starts a sketch from the puzzle's map, with walls around its terrain.  The
+// cursor begins at the ichiban (or the origin, for an empty puzzle).
+func newSketch(puzzle hexoban.Puzzle) *sketch {
+	drawing := &sketch{
+		cells:  make(map[hexoban.HexCoord]textmap.TokenType),
+		cursor: puzzle.Init.Ichiban,
+	}
+	for _, coord := range puzzle.Terrain {
+		drawing.cells[coord] = textmap.TOKEN_FLOOR
+	}
+	for _, coord := range puzzle.Terrain {
+		for _, dir := range hexoban.Directions {
+			if _, exists := drawing.cells[coord.Neighbor(dir)]; !exists {
+				drawing.cells[coord.Neighbor(dir)] = textmap.TOKEN_WALL
+			}
+		}
+	}
+	for _, goal := range puzzle.Init.Goals {
+		drawing.cells[goal] = textmap.TOKEN_GOAL
+	}
+	for _, crate := range puzzle.Init.Crates {
+		drawing.cells[crate] = withCrate(drawing.cells[crate])
+	}
+	if len(puzzle.Terrain) > 0 {
+		drawing.cells[puzzle.Init.Ichiban] = withIchiban(drawing.cells[puzzle.Init.Ichiban])
+	}
+	return drawing
+}
+
+func (drawing *sketch) move(dir hexoban.Direction) {
+	drawing.cursor = drawing.cursor.Neighbor(dir)
+}
+
+// Cycles through the symmetries, centered on the cursor's position.
+func (drawing *sketch) cycleSymmetry() {
+	drawing.symmetry = (drawing.symmetry + 1) % (SYMMETRY_SIXFOLD + 1)
+	drawing.center = drawing.cursor
+}
+
+// Paints the glyph at the cursor (and its symmetric positions).  Floors and
+// walls replace whatever was there, goals and crates are toggled on the floor
+// and the ichiban is moved to the cursor, it is not repeated symmetrically.
+// Painting EMPTY (backspace or delete) erases the hex, leaving it outside of the
+// level, while a space paints it as floor.
+func (drawing *sketch) paint(glyph textmap.TokenType) {
+	drawing.checkpoint()
+	if glyph == textmap.TOKEN_AT || glyph == textmap.TOKEN_AT_GOAL {
+		for coord, cell := range drawing.cells {
+			if hasIchiban(cell) {
+				drawing.cells[coord] = withoutIchiban(cell)
+			}
+		}
+		cell := drawing.cells[drawing.cursor]
+		if glyph == textmap.TOKEN_AT_GOAL {
+			cell = textmap.TOKEN_GOAL
+		}
+		drawing.cells[drawing.cursor] = withIchiban(withoutCrate(cell))
+		return
+	}
+
+	for _, coord := range drawing.orbit(drawing.cursor) {
+		cell, exists := drawing.cells[coord]
+		switch glyph {
+		case EMPTY:
+			delete(drawing.cells, coord)
+		case textmap.TOKEN_FLOOR, textmap.TOKEN_WALL, textmap.TOKEN_CRATE_GOAL:
+			drawing.cells[coord] = glyph
+		case textmap.TOKEN_GOAL:
+			if !exists || cell == textmap.TOKEN_WALL {
+				cell = textmap.TOKEN_FLOOR
+			}
+			if hasGoal(cell) {
+				drawing.cells[coord] = withoutGoal(cell)
+			} else {
+				drawing.cells[coord] = withGoal(cell)
+			}
+		case textmap.TOKEN_CRATE:
+			if !exists || cell == textmap.TOKEN_WALL {
+				cell = textmap.TOKEN_FLOOR
+			}
+			if hasCrate(cell) {
+				drawing.cells[coord] = withoutCrate(cell)
+			} else {
+				drawing.cells[coord] = withCrate(withoutIchiban(cell))
+			}
+		}
+	}
+}
+
+// The glyph for painting a hex as outside of the level.
+const EMPTY textmap.TokenType = 0
+
+// How the hexes outside of the level are drawn.
+const OUTSIDE = '·'
+
+// Returns the distinct positions symmetric to the coordinate.
+func (drawing *sketch) orbit(coord hexoban.HexCoord) []hexoban.HexCoord {
+	di, dj := coord.I()-drawing.center.I(), coord.J()-drawing.center.J()
+	offsets := [][2]int{{di, dj}}
+	switch drawing.symmetry {
+	case SYMMETRY_MIRROR:
+		// Across the vertical line through the center, left <-> right.
+		offsets = append(offsets, [2]int{di, di - dj})
+	case SYMMETRY_TWOFOLD:
+		offsets = append(offsets, [2]int{-di, -dj})
+	case SYMMETRY_THREEFOLD, SYMMETRY_SIXFOLD:
+		step := 2
+		if drawing.symmetry == SYMMETRY_SIXFOLD {
+			step = 1
+		}
+		for turns := step; turns < 6; turns += step {
+			i, j := di, dj
+			for turn := 0; turn < turns; turn++ {
+				i, j = i-j, i // rotates by 60 degrees counter-clockwise.
+			}
+			offsets = append(offsets, [2]int{i, j})
+		}
+	}
+
+	coords := make([]hexoban.HexCoord, 0, len(offsets))
+	for _, offset := range offsets {
+		symmetric := hexoban.NewHexCoord(drawing.center.I()+offset[0], drawing.center.J()+offset[1])
+		if !slices.Contains(coords, symmetric) {
+			coords = append(coords, symmetric)
+		}
+	}
+	return coords
+}
+
+// Remembers the cells before they are changed, for undo.
+func (drawing *sketch) checkpoint() {
+	drawing.undos = append(drawing.undos, maps.Clone(drawing.cells))
+	drawing.redos = nil
+}
+
+func (drawing *sketch) undo() bool {
+	if len(drawing.undos) == 0 {
+		return false
+	}
+	drawing.redos = append(drawing.redos, drawing.cells)
+	drawing.cells = drawing.undos[len(drawing.undos)-1]
+	drawing.undos = drawing.undos[:len(drawing.undos)-1]
+	return true
+}
+
+func (drawing *sketch) redo() bool {
+	if len(drawing.redos) == 0 {
+		return false
+	}
+	drawing.undos = append(drawing.undos, drawing.cells)
+	drawing.cells = drawing.redos[len(drawing.redos)-1]
+	drawing.redos = drawing.redos[:len(drawing.redos)-1]
+	return true
+}
+
+// Returns the puzzle's map as drawn, its metadata is copied from the given
+// puzzle.  The coordinates of each list are in (i, j) order.
+func (drawing *sketch) puzzle(metadata hexoban.Puzzle) hexoban.Puzzle {
+	puzzle := metadata
+	puzzle.Terrain, puzzle.Init = nil, hexoban.Init{}
+	for coord, cell := range drawing.cells {
+		if cell == textmap.TOKEN_WALL {
+			continue
+		}
+		puzzle.Terrain = append(puzzle.Terrain, coord)
+		if hasGoal(cell) {
+			puzzle.Init.Goals = append(puzzle.Init.Goals, coord)
+		}
+		if hasCrate(cell) {
+			puzzle.Init.Crates = append(puzzle.Init.Crates, coord)
+		}
+		if hasIchiban(cell) {
+			puzzle.Init.Ichiban = coord
+		}
+	}
+	for _, coords := range [][]hexoban.HexCoord{puzzle.Terrain, puzzle.Init.Goals, puzzle.Init.Crates} {
+		slices.SortFunc(coords, func(a, b hexoban.HexCoord) int {
+			if a.I() != b.I() {
+				return a.I() - b.I()
+			}
+			return a.J() - b.J()
+		})
+	}
+	return puzzle
+}
+
+// Returns the reasons the level is not yet playable, if there are any.
+func (drawing *sketch) problems() []string {
+	problems := make([]string, 0)
+	floors, goals, crates, ichibans := 0, 0, 0, 0
+	for coord, cell := range drawing.cells {
+		if cell == textmap.TOKEN_WALL {
+			continue
+		}
+		floors++
+		if hasGoal(cell) {
+			goals++
+		}
+		if hasCrate(cell) {
+			crates++
+		}
+		if hasIchiban(cell) {
+			ichibans++
+		}
+		for _, dir := range hexoban.Directions {
+			if _, exists := drawing.cells[coord.Neighbor(dir)]; !exists {
+				problems = append(problems, fmt.Sprintf("floor at %s is not walled in", formatCoord(coord)))
+				break
+			}
+		}
+	}
+	slices.Sort(problems)
+	switch {
+	case floors == 0:
+		return []string{"no floor has been painted"}
+	case ichibans == 0:
+		problems = append(problems, "the ichiban (@) has not been placed")
+	}
+	if goals == 0 {
+		problems = append(problems, "there are no goals")
+	} else if goals != crates {
+		problems = append(problems, fmt.Sprintf("%d goals but %d crates", goals, crates))
+	} else if drawing.solved() {
+		problems = append(problems, "every crate is already on a goal")
+	}
+	return problems
+}
+
+func (drawing *sketch) solved() bool {
+	for _, cell := range drawing.cells {
+		if hasGoal(cell) != hasCrate(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// Draws the sketch using the layout of text maps, with a margin of one hex
+// around it.  The cursor is shown within brackets and the center of symmetry
+// within parentheses, and positions outside of the level are shown as dots.
+func (drawing *sketch) lines() []string {
+	coords := []hexoban.HexCoord{drawing.cursor}
+	for coord := range drawing.cells {
+		coords = append(coords, coord)
+	}
+	if drawing.symmetry != SYMMETRY_NONE {
+		coords = append(coords, drawing.center)
+	}
+	minCol, minRow, maxCol, maxRow := textmap.RectBounds(coords)
+	minCol, minRow, maxCol, maxRow = minCol-2, minRow-1, maxCol+2, maxRow+1
+
+	// Each line has a space before and after the map for the brackets.
+	grid := make([][]rune, maxRow-minRow+1)
+	for row := minRow; row <= maxRow; row++ {
+		line := []rune(strings.Repeat(" ", maxCol-minCol+3))
+		for col := minCol; col <= maxCol; col++ {
+			if (col-row)&1 == 0 {
+				line[col-minCol+1] = OUTSIDE
+			}
+		}
+		grid[row-minRow] = line
+	}
+	place := func(coord hexoban.HexCoord) (line []rune, x int) {
+		at := textmap.HexToRect(coord, 1-minCol, -minRow)
+		return grid[at.Row()], int(at.Col())
+	}
+	for coord, glyph := range drawing.cells {
+		line, x := place(coord)
+		line[x] = rune(glyph)
+	}
+	if drawing.symmetry != SYMMETRY_NONE {
+		line, x := place(drawing.center)
+		line[x-1], line[x+1] = '(', ')'
+	}
+	line, x := place(drawing.cursor) // (may overlap the center's parentheses)
+	line[x-1], line[x+1] = '[', ']'
+
+	lines := make([]string, 0, len(grid))
+	for _, line := range grid {
+		lines = append(lines, strings.TrimRight(string(line), " "))
+	}
+	return lines
+}
+
+// Helpers for combining the goal, crate and ichiban glyphs on a floor.
+
+func hasGoal(cell textmap.TokenType) bool {
+	return cell == textmap.TOKEN_GOAL || cell == textmap.TOKEN_CRATE_GOAL || cell == textmap.TOKEN_AT_GOAL
+}
+
+func hasCrate(cell textmap.TokenType) bool {
+	return cell == textmap.TOKEN_CRATE || cell == textmap.TOKEN_CRATE_GOAL
+}
+
+func hasIchiban(cell textmap.TokenType) bool {
+	return cell == textmap.TOKEN_AT || cell == textmap.TOKEN_AT_GOAL
+}
+
+func withGoal(cell textmap.TokenType) textmap.TokenType {
+	switch {
+	case hasCrate(cell):
+		return textmap.TOKEN_CRATE_GOAL
+	case hasIchiban(cell):
+		return textmap.TOKEN_AT_GOAL
+	}
+	return textmap.TOKEN_GOAL
+}
+
+func withoutGoal(cell textmap.TokenType) textmap.TokenType {
+	switch {
+	case hasCrate(cell):
+		return textmap.TOKEN_CRATE
+	case hasIchiban(cell):
+		return textmap.TOKEN_AT
+	}
+	return textmap.TOKEN_FLOOR
+}
+
+func withCrate(cell textmap.TokenType) textmap.TokenType {
+	if hasGoal(cell) {
+		return textmap.TOKEN_CRATE_GOAL
+	}
+	return textmap.TOKEN_CRATE
+}
+
+func withoutCrate(cell textmap.TokenType) textmap.TokenType {
+	if hasGoal(cell) {
+		return textmap.TOKEN_GOAL
+	}
+	return textmap.TOKEN_FLOOR
+}
+
+func withIchiban(cell textmap.TokenType) textmap.TokenType {
+	if hasGoal(cell) {
+		return textmap.TOKEN_AT_GOAL
+	}
+	return textmap.TOKEN_AT
+}
+
+func withoutIchiban(cell textmap.TokenType) textmap.TokenType {
+	if hasGoal(cell) {
+		return textmap.TOKEN_GOAL
+	}
+	return textmap.TOKEN_FLOOR
+}
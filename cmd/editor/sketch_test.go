@@ -0,0 +1,192 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/editor/sketch_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+func TestSketch_RoundTrip(t *testing.T) {
+	for _, path := range levelFiles(t) {
+		filedata, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var puzzle hexoban.Puzzle
+		if err := json.Unmarshal(filedata, &puzzle); err != nil {
+			t.Fatal(err)
+		}
+		drawn := newSketch(puzzle).puzzle(puzzle)
+		if !equalCoords(drawn.Terrain, puzzle.Terrain) ||
+			!equalCoords(drawn.Init.Goals, puzzle.Init.Goals) ||
+			!equalCoords(drawn.Init.Crates, puzzle.Init.Crates) ||
+			drawn.Init.Ichiban != puzzle.Init.Ichiban {
+			t.Errorf("%s: sketch changed the puzzle's map", path)
+		}
+	}
+}
+
+func TestSketch_Paint(t *testing.T) {
+	drawing := newSketch(hexoban.Puzzle{})
+	for _, key := range []textmap.TokenType{' ', '.', '$'} {
+		drawing.paint(key)
+	}
+	if got := drawing.cells[drawing.cursor]; got != textmap.TOKEN_CRATE_GOAL {
+		t.Errorf("floor, goal and crate = %q, want '*'", got)
+	}
+	drawing.paint('.')
+	drawing.paint('@')
+	if got := drawing.cells[drawing.cursor]; got != textmap.TOKEN_AT {
+		t.Errorf("removing the goal and placing the ichiban = %q, want '@'", got)
+	}
+
+	// There is only ever one ichiban.
+	drawing.move(hexoban.DIR_RIGHT)
+	drawing.paint('+')
+	ichibans := 0
+	for _, cell := range drawing.cells {
+		if hasIchiban(cell) {
+			ichibans++
+		}
+	}
+	if ichibans != 1 || drawing.cells[drawing.cursor] != textmap.TOKEN_AT_GOAL {
+		t.Errorf("%d ichibans, at the cursor %q", ichibans, drawing.cells[drawing.cursor])
+	}
+
+	drawing.paint(EMPTY)
+	if _, exists := drawing.cells[drawing.cursor]; exists {
+		t.Errorf("erased hex is still in the sketch")
+	}
+}
+
+func TestSketch_Orbit(t *testing.T) {
+	center := hexoban.NewHexCoord(2, 3)
+	at := hexoban.NewHexCoord(2, 4) // to the right of the center.
+	tests := []struct {
+		symmetry symmetry
+		want     []hexoban.HexCoord
+	}{
+		{SYMMETRY_NONE, []hexoban.HexCoord{at}},
+		{SYMMETRY_MIRROR, []hexoban.HexCoord{at, hexoban.NewHexCoord(2, 2)}},
+		{SYMMETRY_TWOFOLD, []hexoban.HexCoord{at, hexoban.NewHexCoord(2, 2)}},
+		{SYMMETRY_THREEFOLD, []hexoban.HexCoord{at,
+			hexoban.NewHexCoord(1, 2), hexoban.NewHexCoord(3, 3)}},
+		{SYMMETRY_SIXFOLD, []hexoban.HexCoord{at,
+			hexoban.NewHexCoord(1, 3), hexoban.NewHexCoord(1, 2), hexoban.NewHexCoord(2, 2),
+			hexoban.NewHexCoord(3, 3), hexoban.NewHexCoord(3, 4)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symmetry.String(), func(t *testing.T) {
+			drawing := &sketch{symmetry: tt.symmetry, center: center}
+			if got := drawing.orbit(at); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orbit(%v) = %v, want %v", at, got, tt.want)
+			}
+			// The center is its own orbit.
+			if got := drawing.orbit(center); len(got) != 1 {
+				t.Errorf("orbit(center) = %v", got)
+			}
+		})
+	}
+
+	// Mirroring up and backward, which are mirrored across the vertical.
+	drawing := &sketch{symmetry: SYMMETRY_MIRROR, center: center}
+	up := center.Neighbor(hexoban.DIR_UP)
+	if got := drawing.orbit(up); got[1] != center.Neighbor(hexoban.DIR_BACKWARD) {
+		t.Errorf("mirror of up = %v, want backward", got[1])
+	}
+}
+
+func TestSketch_UndoRedo(t *testing.T) {
+	drawing := newSketch(hexoban.Puzzle{})
+	drawing.paint('#')
+	drawing.move(hexoban.DIR_RIGHT)
+	drawing.paint('#')
+	if !drawing.undo() || len(drawing.cells) != 1 {
+		t.Fatalf("after undo, %d cells", len(drawing.cells))
+	}
+	if !drawing.redo() || len(drawing.cells) != 2 {
+		t.Fatalf("after redo, %d cells", len(drawing.cells))
+	}
+	drawing.undo()
+	drawing.undo()
+	if drawing.undo() || len(drawing.cells) != 0 {
+		t.Errorf("undo past the beginning, %d cells", len(drawing.cells))
+	}
+	drawing.paint(' ')
+	if drawing.redo() {
+		t.Errorf("redo after a new paint")
+	}
+}
+
+func TestSketch_Problems(t *testing.T) {
+	drawing := newSketch(hexoban.Puzzle{})
+	if got := drawing.problems(); len(got) != 1 || got[0] != "no floor has been painted" {
+		t.Errorf("problems() = %v", got)
+	}
+
+	puzzle, err := textmap.Decode(strings.NewReader(" # # # #\n# @ $ . #\n # # # #\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drawing = newSketch(puzzle)
+	if got := drawing.problems(); len(got) != 0 {
+		t.Errorf("problems() = %v, want none", got)
+	}
+	drawing.move(hexoban.DIR_RIGHT)
+	drawing.paint('$')
+	drawing.move(hexoban.DIR_RIGHT)
+	drawing.move(hexoban.DIR_RIGHT)
+	drawing.paint(' ')
+	want := []string{
+		"floor at [2, 5] is not walled in",
+		"1 goals but 0 crates",
+	}
+	if got := drawing.problems(); !reflect.DeepEqual(got, want) {
+		t.Errorf("problems() = %q, want %q", got, want)
+	}
+}
+
+func TestSketch_Lines(t *testing.T) {
+	puzzle, err := textmap.Decode(strings.NewReader(" # # # #\n# @ $ . #\n # # # #\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drawing := newSketch(puzzle)
+	want := []string{
+		" · · · · · · ·",
+		"  · # # # # ·",
+		" · #[@]$ . # ·",
+		"  · # # # # ·",
+		" · · · · · · ·",
+	}
+	if got := drawing.lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	drawing.cycleSymmetry()
+	drawing.move(hexoban.DIR_RIGHT)
+	if got := drawing.lines()[2]; got != " · #(@[$]. # ·" {
+		t.Errorf("with symmetry, line = %q", got)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/editor/tui.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/internal/terminal"
+	"github.com/SymbolNotFound/hexoban/render/ansi"
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+// The full-screen editor draws the level with the same layout as text maps and
+// is controlled with single keys (see the terminal package).
+//
+// The cursor moves in the six directions with terminal.MOVES, the keys around
+// 's' or the arrow keys.  The glyphs of the text map format paint the hex at
+// the cursor, see sketch.paint().
+const TUI_HELP = "move: w e a d z x, arrows   paint: space # . $ * @ +, backspace erases\r\n" +
+	"m: symmetry   u/U: undo/redo   ctrl-s: save   q: quit"
+
+// The state of the full-screen editor, besides the sketch itself.
+type editorScreen struct {
+	drawing    *sketch
+	path       string
+	metadata   hexoban.Puzzle
+	collection *textmap.Collection // if the level was loaded from an .hsb file.
+	message    string
+	modified   bool
+	quitting   bool // after a first 'q' with unsaved changes.
+}
+
+// Edits the level at the path (a .json or .hsb file, which need not exist yet)
+// in the full-screen editor.  The level's properties are those of the file,
+// replaced by any of the defaults which are not empty.
+func runTUI(path string, defaults hexoban.Puzzle) error {
+	screen, err := newEditorScreen(path, defaults)
+	if err != nil {
+		return err
+	}
+	return terminal.Run(screen.draw, screen.handle)
+}
+
+func newEditorScreen(path string, defaults hexoban.Puzzle) (*editorScreen, error) {
+	screen := &editorScreen{path: path}
+	puzzle := hexoban.Puzzle{}
+	filedata, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		screen.message = "new level " + path
+	case err != nil:
+		return nil, err
+	case strings.HasSuffix(path, ".hsb"):
+		collection, err := textmap.DecodeCollection(bytes.NewReader(filedata))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(collection.Levels) != 1 {
+			return nil, fmt.Errorf("%s: has %d maps, only one can be edited", path, len(collection.Levels))
+		}
+		screen.collection = &collection
+		puzzle = collection.Levels[0].Puzzle
+	default:
+		if err := json.Unmarshal(filedata, &puzzle); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	for _, field := range []struct{ value, from *string }{
+		{&puzzle.Identity, &defaults.Identity},
+		{&puzzle.Title, &defaults.Title},
+		{&puzzle.Author, &defaults.Author},
+		{&puzzle.Source, &defaults.Source},
+	} {
+		if *field.from != "" {
+			*field.value = *field.from
+		}
+	}
+	if defaults.Difficulty > 0 {
+		puzzle.Difficulty = defaults.Difficulty
+	}
+	if puzzle.Identity == "" {
+		puzzle.Identity = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	screen.metadata = puzzle
+	screen.drawing = newSketch(puzzle)
+	return screen, nil
+}
+
+// Applies the key to the editor, returns true when the editor should exit.
+func (screen *editorScreen) handle(key string) bool {
+	quitting := screen.quitting
+	screen.quitting = false
+	screen.message = ""
+	drawing := screen.drawing
+
+	if dir, ok := terminal.MOVES[key]; ok {
+		drawing.move(dir)
+		return false
+	}
+	switch key {
+	case " ", "#", ".", "$", "*", "@", "+":
+		drawing.paint(textmap.TokenType(key[0]))
+		screen.modified = true
+	case terminal.KEY_BACKSPACE, terminal.KEY_DELETE:
+		drawing.paint(EMPTY)
+		screen.modified = true
+	case "m":
+		drawing.cycleSymmetry()
+		screen.message = "symmetry " + drawing.symmetry.String()
+	case "u", terminal.KEY_CTRL_Z:
+		if drawing.undo() {
+			screen.modified = true
+		} else {
+			screen.message = "nothing to undo"
+		}
+	case "U", terminal.KEY_CTRL_R, terminal.KEY_CTRL_Y:
+		if drawing.redo() {
+			screen.modified = true
+		} else {
+			screen.message = "nothing to redo"
+		}
+	case terminal.KEY_CTRL_S:
+		if err := screen.save(); err != nil {
+			screen.message = "not saved: " + err.Error()
+		} else {
+			screen.message = "saved " + screen.path
+			screen.modified = false
+		}
+	case "q", terminal.KEY_CTRL_C:
+		if !screen.modified || quitting {
+			return true
+		}
+		screen.quitting = true
+		screen.message = "there are unsaved changes, press q again to quit"
+	}
+	return false
+}
+
+// Writes the level to its file, as an .hsb text map or updating its JSON
+// definition in place (see updatePuzzleJSON).
+func (screen *editorScreen) save() error {
+	puzzle := screen.drawing.puzzle(screen.metadata)
+	if len(puzzle.Terrain) == 0 {
+		return fmt.Errorf("there is no floor")
+	}
+	if !hasIchiban(screen.drawing.cells[puzzle.Init.Ichiban]) {
+		return fmt.Errorf("the ichiban (@) has not been placed")
+	}
+
+	var output []byte
+	if strings.HasSuffix(screen.path, ".hsb") {
+		collection := textmap.Collection{Levels: []textmap.Level{{Puzzle: puzzle}}}
+		if screen.collection != nil {
+			collection = *screen.collection
+			collection.Levels = []textmap.Level{{Puzzle: puzzle, Comments: collection.Levels[0].Comments}}
+		}
+		var buffer bytes.Buffer
+		if err := textmap.EncodeCollection(&buffer, collection); err != nil {
+			return err
+		}
+		output = buffer.Bytes()
+	} else {
+		filedata, err := os.ReadFile(screen.path)
+		if errors.Is(err, os.ErrNotExist) {
+			filedata = []byte(EMPTY_PUZZLE_JSON)
+		} else if err != nil {
+			return err
+		}
+		if output, err = updatePuzzleJSON(filedata, puzzle); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(screen.path, output, 0644)
+}
+
+// Returns the escape sequences and text for drawing the whole screen.
+func (screen *editorScreen) draw() string {
+	var text strings.Builder
+	text.WriteString(terminal.CLEAR)
+	title := screen.metadata.Title
+	if title == "" {
+		title = "(untitled)"
+	}
+	fmt.Fprintf(&text, "%s%s%s  %s", terminal.BOLD, title, terminal.RESET, screen.path)
+	if screen.modified {
+		text.WriteString(" (modified)")
+	}
+	text.WriteString("\r\n\r\n")
+	for _, line := range screen.drawing.lines() {
+		text.WriteString(colorize(line))
+		text.WriteString("\r\n")
+	}
+
+	fmt.Fprintf(&text, "\r\nsymmetry: %s\r\n", screen.drawing.symmetry)
+	if problems := screen.drawing.problems(); len(problems) == 0 {
+		text.WriteString("\x1b[32mplayable\x1b[0m\r\n")
+	} else {
+		const SHOWN = 4
+		for index, problem := range problems {
+			if index == SHOWN {
+				fmt.Fprintf(&text, "\x1b[33m  ... and %d more\x1b[0m\r\n", len(problems)-SHOWN)
+				break
+			}
+			fmt.Fprintf(&text, "\x1b[33m%s\x1b[0m\r\n", problem)
+		}
+	}
+	fmt.Fprintf(&text, "%s\r\n\r\n%s%s%s", screen.message, terminal.DIM, TUI_HELP, terminal.RESET)
+	return text.String()
+}
+
+// Colors each of the line's map glyphs, the cursor and other markings are kept
+// in the terminal's default color.
+func colorize(line string) string {
+	var text strings.Builder
+	for _, char := range line {
+		if char < 0x80 && textmap.TokenType(char).IsTile() {
+			text.WriteString(ansi.Glyph(textmap.TokenType(char), false, ansi.MODE_COLOR))
+		} else {
+			text.WriteRune(char)
+		}
+	}
+	return text.String()
+}
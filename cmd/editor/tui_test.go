@@ -0,0 +1,115 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/editor/tui_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/internal/terminal"
+	"github.com/SymbolNotFound/hexoban/render/ansi"
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+// Presses each of the keys, returning true if the last one exits the editor.
+func press(screen *editorScreen, keys ...string) bool {
+	exit := false
+	for _, key := range keys {
+		exit = screen.handle(key)
+	}
+	return exit
+}
+
+// Keys for drawing the level " # # # #\n# @ $ . #\n # # # #".
+var drawKeys = []string{"@", "d", "$", "d", ".", "d", "#",
+	"a", "a", "a", "a", "#", "e", "#", "d", "#", "d", "#", "d", "#",
+	"z", "x", "#", "a", "#", "a", "#", "a", "#"}
+
+func TestEditorScreen_SaveJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tiny.json")
+	screen, err := newEditorScreen(path, hexoban.Puzzle{Title: "Tiny", Author: "Someone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if press(screen, terminal.KEY_CTRL_S); !strings.HasPrefix(screen.message, "not saved") {
+		t.Errorf("saving an empty level: %q", screen.message)
+	}
+	press(screen, drawKeys...)
+	if problems := screen.drawing.problems(); len(problems) > 0 {
+		t.Fatalf("drawn level has problems: %v", problems)
+	}
+	if press(screen, "q") || !screen.quitting {
+		t.Errorf("quit with unsaved changes")
+	}
+	press(screen, terminal.KEY_CTRL_S)
+	if screen.message != "saved "+path || screen.modified {
+		t.Errorf("after saving: %q", screen.message)
+	}
+	if !press(screen, "q") {
+		t.Errorf("did not quit after saving")
+	}
+
+	// Reopened, the level is the same and the metadata is kept.
+	reopened, err := newEditorScreen(path, hexoban.Puzzle{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.metadata.Title != "Tiny" || reopened.metadata.Identity != "tiny" {
+		t.Errorf("reopened metadata = %q, %q", reopened.metadata.Title, reopened.metadata.Identity)
+	}
+	text, err := textmap.MapString(reopened.drawing.puzzle(reopened.metadata))
+	if err != nil || text != " # # # #\n# @ $ . #\n # # # #" {
+		t.Errorf("reopened map =\n%s (%v)", text, err)
+	}
+}
+
+func TestEditorScreen_SaveHSB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tiny.hsb")
+	original := " # # # #\n# @ $ . #\n # # # #\nTitle: Tiny\n; a comment to keep\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	screen, err := newEditorScreen(path, hexoban.Puzzle{Author: "Someone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Swap the crate and the goal, undoing a mistake on the way.
+	press(screen, "d", ".", "$", "#", "u", "d", "$", ".", terminal.KEY_CTRL_S)
+	if screen.message != "saved "+path {
+		t.Fatalf("save: %q", screen.message)
+	}
+	filedata, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\n # # # #\n# @ . $ #\n # # # #\nTitle: Tiny\nID: tiny\nAuthor: Someone\na comment to keep\n"
+	if string(filedata) != want {
+		t.Errorf("saved =\n%q\nwant\n%q", filedata, want)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	got := colorize("·[@]$ ")
+	want := "·[" + ansi.STYLE_ICHIBAN + "@" + ansi.STYLE_RESET + "]" +
+		ansi.STYLE_CRATE + "$" + ansi.STYLE_RESET + " "
+	if got != want {
+		t.Errorf("colorize() = %q, want %q", got, want)
+	}
+}
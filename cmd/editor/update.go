@@ -0,0 +1,249 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/editor/update.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/internal/jsonedit"
+)
+
+// Updating a puzzle's JSON file rewrites only the values which have changed,
+// so that properties unknown to the editor, the order of properties and the
+// file's layout are all kept as they were.  New properties are added at the end
+// of their object, in the same layout as the files of the levels collection:
+//
+//	{
+//	  "id": "DWS/001",
+//	  "title": "001",
+//	  ...
+//	  "terrain": [
+//	    [2, 3], [2, 4], ...
+//	  ],
+//	  "init": {
+//	    "goals": [
+//	      [4, 5], ...
+//	    ],
+//	    ...
+//	  }
+//	}
+
+// The JSON text to start from when writing a new puzzle file.
+const EMPTY_PUZZLE_JSON = "{\n}"
+
+// Returns the JSON definition with the values of the updated puzzle, changing
+// only the properties whose values differ from the definition's.
+func updatePuzzleJSON(filedata []byte, updated hexoban.Puzzle) ([]byte, error) {
+	var original hexoban.Puzzle
+	if err := json.Unmarshal(filedata, &original); err != nil {
+		return nil, err
+	}
+	object, err := jsonedit.Parse(filedata, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := []struct {
+		key           string
+		before, after string
+	}{
+		{"id", original.Identity, updated.Identity},
+		{"title", original.Title, updated.Title},
+		{"author", original.Author, updated.Author},
+		{"source", original.Source, updated.Source},
+	}
+	for _, property := range properties {
+		if property.before != property.after || (property.after != "" && !object.Has(property.key)) {
+			if err := object.Set(property.key, jsonedit.Quote(property.after)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !equalCoords(original.Terrain, updated.Terrain) || !object.Has("terrain") {
+		if err := object.Set("terrain", formatCoords(updated.Terrain, object.Indent())); err != nil {
+			return nil, err
+		}
+	}
+
+	init, exists := object.Get("init")
+	if !exists {
+		init = []byte("{\n" + object.Indent() + "}")
+	}
+	initObject, err := jsonedit.Parse(init, len(object.Indent()))
+	if err != nil {
+		return nil, err
+	}
+	if !equalCoords(original.Init.Goals, updated.Init.Goals) || !initObject.Has("goals") {
+		if err := initObject.Set("goals", formatCoords(updated.Init.Goals, initObject.Indent())); err != nil {
+			return nil, err
+		}
+	}
+	if !equalCoords(original.Init.Crates, updated.Init.Crates) || !initObject.Has("crates") {
+		if err := initObject.Set("crates", formatCoords(updated.Init.Crates, initObject.Indent())); err != nil {
+			return nil, err
+		}
+	}
+	if original.Init.Ichiban != updated.Init.Ichiban ||
+		(updated.Init.Ichiban != hexoban.NewHexCoord(0, 0) && !initObject.Has("ichiban")) {
+		if err := initObject.Set("ichiban", formatCoord(updated.Init.Ichiban)); err != nil {
+			return nil, err
+		}
+	}
+	if !exists || !bytes.Equal(initObject.Bytes(), init) {
+		if err := object.Set("init", string(initObject.Bytes())); err != nil {
+			return nil, err
+		}
+	}
+
+	// A difficulty of zero is unrated, which is written by leaving it out.
+	if original.Difficulty != updated.Difficulty {
+		if updated.Difficulty == 0 {
+			err = object.Remove("difficulty")
+		} else {
+			err = object.Set("difficulty", fmt.Sprint(updated.Difficulty))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return object.Bytes(), nil
+}
+
+func formatCoord(coord hexoban.HexCoord) string {
+	return fmt.Sprintf("[%d, %d]", coord.I(), coord.J())
+}
+
+// Formats the coordinates on a single line within brackets on their own lines,
+// where the indent is that of the property holding them.
+func formatCoords(coords []hexoban.HexCoord, indent string) string {
+	if len(coords) == 0 {
+		return "[]"
+	}
+	formatted := make([]string, len(coords))
+	for index, coord := range coords {
+		formatted[index] = formatCoord(coord)
+	}
+	return fmt.Sprintf("[\n%s  %s\n%s]", indent, strings.Join(formatted, ", "), indent)
+}
+
+func equalCoords(a, b []hexoban.HexCoord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// Translates the puzzle's coordinates so that its least terrain coordinate
+// (ordered by i, then j) is the same as the original's.  A map that is entered
+// again is then at the same position as before, as far as is possible.
+func alignMap(puzzle, original hexoban.Puzzle) hexoban.Puzzle {
+	if len(puzzle.Terrain) == 0 || len(original.Terrain) == 0 {
+		return puzzle
+	}
+	least := func(coords []hexoban.HexCoord) hexoban.HexCoord {
+		best := coords[0]
+		for _, coord := range coords {
+			if coord.I() < best.I() || (coord.I() == best.I() && coord.J() < best.J()) {
+				best = coord
+			}
+		}
+		return best
+	}
+	from, to := least(puzzle.Terrain), least(original.Terrain)
+	di, dj := to.I()-from.I(), to.J()-from.J()
+	translate := func(coords []hexoban.HexCoord) []hexoban.HexCoord {
+		moved := make([]hexoban.HexCoord, len(coords))
+		for index, coord := range coords {
+			moved[index] = hexoban.NewHexCoord(coord.I()+di, coord.J()+dj)
+		}
+		return moved
+	}
+	puzzle.Terrain = translate(puzzle.Terrain)
+	puzzle.Init.Goals = translate(puzzle.Init.Goals)
+	puzzle.Init.Crates = translate(puzzle.Init.Crates)
+	puzzle.Init.Ichiban = translate([]hexoban.HexCoord{puzzle.Init.Ichiban})[0]
+	return puzzle
+}
+
+// Writes the lines which differ between the two texts, prefixed with '-' for
+// those removed and '+' for those added, with a line of unchanged context
+// around each change.
+func writeDiff(writer io.Writer, before, after []byte) {
+	a := strings.Split(string(before), "\n")
+	b := strings.Split(string(after), "\n")
+
+	// The length of the longest common subsequence of a[i:] and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		mark byte
+		line string
+	}
+	edits := make([]edit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+
+	changed := func(index int) bool {
+		return index >= 0 && index < len(edits) && edits[index].mark != ' '
+	}
+	skipped := false
+	for index, edit := range edits {
+		if edit.mark == ' ' && !changed(index-1) && !changed(index+1) {
+			skipped = true
+			continue
+		}
+		if skipped {
+			fmt.Fprintln(writer, "  ...")
+			skipped = false
+		}
+		fmt.Fprintf(writer, "%c %s\n", edit.mark, edit.line)
+	}
+}
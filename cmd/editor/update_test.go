@@ -0,0 +1,200 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/editor/update_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+func levelFiles(t *testing.T) []string {
+	paths, err := filepath.Glob("../../levels/*/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no levels found: %v", err)
+	}
+	return paths
+}
+
+func TestUpdatePuzzleJSON_Levels(t *testing.T) {
+	for _, path := range levelFiles(t) {
+		filedata, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var puzzle hexoban.Puzzle
+		if err := json.Unmarshal(filedata, &puzzle); err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+
+		// Without any changes, the file is unchanged.
+		unchanged, err := updatePuzzleJSON(filedata, puzzle)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		if string(unchanged) != string(filedata) {
+			t.Errorf("%s: changed without any updates\n%s", path, unchanged)
+		}
+
+		// A new file is written in the same layout as the levels.
+		written, err := updatePuzzleJSON([]byte(EMPTY_PUZZLE_JSON), puzzle)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		// (some of the levels end with a newline, the others do not)
+		if string(written) != strings.TrimSuffix(string(filedata), "\n") {
+			t.Errorf("%s: new file differs from the level's layout\n%s", path, written)
+		}
+	}
+}
+
+func TestUpdatePuzzleJSON(t *testing.T) {
+	original := `{
+  "id": "test/one",
+  "title": "One",
+  "author": "Someone",
+  "source": "",
+  "notes": {"keep": ["these", "as", "they", "are"]},
+  "terrain": [
+    [0, 0], [0, 1]
+  ],
+  "init": {
+    "goals": [
+      [0, 1]
+    ],
+    "crates": [
+      [0, 0]
+    ]
+  }
+}`
+	puzzle := hexoban.Puzzle{}
+	if err := json.Unmarshal([]byte(original), &puzzle); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		update func(*hexoban.Puzzle)
+		want   string
+	}{
+		{"title and source",
+			func(p *hexoban.Puzzle) {
+				p.Title = "One & Only"
+				p.Source = "http://example.com/?a=1&b=2"
+			},
+			strings.NewReplacer(
+				`"title": "One"`, `"title": "One & Only"`,
+				`"source": ""`, `"source": "http://example.com/?a=1&b=2"`,
+			).Replace(original)},
+		{"difficulty",
+			func(p *hexoban.Puzzle) { p.Difficulty = 3 },
+			strings.TrimSuffix(original, "\n}") + ",\n  \"difficulty\": 3\n}"},
+		{"ichiban",
+			func(p *hexoban.Puzzle) { p.Init.Ichiban = hexoban.NewHexCoord(0, 1) },
+			strings.Replace(original, "[0, 0]\n    ]\n  }",
+				"[0, 0]\n    ],\n    \"ichiban\": [0, 1]\n  }", 1)},
+		{"terrain",
+			func(p *hexoban.Puzzle) {
+				p.Terrain = append(p.Terrain, hexoban.NewHexCoord(1, 1))
+			},
+			strings.Replace(original, "[0, 0], [0, 1]\n", "[0, 0], [0, 1], [1, 1]\n", 1)},
+		{"no goals",
+			func(p *hexoban.Puzzle) { p.Init.Goals = nil },
+			strings.Replace(original, "[\n      [0, 1]\n    ]", "[]", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := puzzle
+			updated.Terrain = append([]hexoban.HexCoord{}, puzzle.Terrain...)
+			tt.update(&updated)
+			got, err := updatePuzzleJSON([]byte(original), updated)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("updatePuzzleJSON() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// An unrated level (difficulty 0) is written without the property.
+func TestUpdatePuzzleJSON_Unrated(t *testing.T) {
+	rated := "{\n  \"id\": \"test/one\",\n  \"difficulty\": 4,\n" +
+		"  \"terrain\": [\n    [0, 0]\n  ],\n" +
+		"  \"init\": {\n    \"goals\": [],\n    \"crates\": []\n  }\n}"
+	puzzle := hexoban.Puzzle{}
+	if err := json.Unmarshal([]byte(rated), &puzzle); err != nil {
+		t.Fatal(err)
+	}
+	puzzle.Difficulty = 0
+	got, err := updatePuzzleJSON([]byte(rated), puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(rated, "  \"difficulty\": 4,\n", "", 1); string(got) != want {
+		t.Errorf("updatePuzzleJSON() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAlignMap(t *testing.T) {
+	original := hexoban.Puzzle{Terrain: []hexoban.HexCoord{
+		hexoban.NewHexCoord(-2, -3), hexoban.NewHexCoord(-2, -2)}}
+	entered := hexoban.Puzzle{
+		Terrain: []hexoban.HexCoord{hexoban.NewHexCoord(1, 1), hexoban.NewHexCoord(1, 2)},
+		Init: hexoban.Init{
+			Goals:   []hexoban.HexCoord{hexoban.NewHexCoord(1, 2)},
+			Crates:  []hexoban.HexCoord{hexoban.NewHexCoord(1, 2)},
+			Ichiban: hexoban.NewHexCoord(1, 1),
+		}}
+
+	aligned := alignMap(entered, original)
+	if !equalCoords(aligned.Terrain, original.Terrain) {
+		t.Errorf("terrain %v, want %v", aligned.Terrain, original.Terrain)
+	}
+	if aligned.Init.Ichiban != hexoban.NewHexCoord(-2, -3) ||
+		aligned.Init.Goals[0] != hexoban.NewHexCoord(-2, -2) ||
+		aligned.Init.Crates[0] != hexoban.NewHexCoord(-2, -2) {
+		t.Errorf("init not aligned: %+v", aligned.Init)
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	before := "{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3,\n  \"d\": 4,\n  \"e\": 5\n}"
+	after := "{\n  \"a\": 1,\n  \"b\": 20,\n  \"c\": 3,\n  \"d\": 4,\n  \"e\": 5,\n  \"f\": 6\n}"
+	want := `  ...
+    "a": 1,
+-   "b": 2,
++   "b": 20,
+    "c": 3,
+    "d": 4,
+-   "e": 5
++   "e": 5,
++   "f": 6
+  }
+`
+	var diff strings.Builder
+	writeDiff(&diff, []byte(before), []byte(after))
+	if diff.String() != want {
+		t.Errorf("writeDiff() =\n%s\nwant\n%s", diff.String(), want)
+	}
+}
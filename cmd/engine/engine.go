@@ -0,0 +1,272 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/engine/engine.go
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// The protocol is line oriented, each line read is one command:
+//
+//	load <json>   loads a puzzle, its JSON definition all on one line
+//	move <dirs>   moves the worker, see engine.move()
+//	state         describes the current position
+//	hint          suggests the next push, see hexoban.Hint()
+//	solve <ms>    searches for a solution for up to ms milliseconds
+//	undo          reverts the last step
+//	quit          ends the session (as does the end of the input)
+//
+// Blank lines and lines beginning with '#' are ignored.  Each command is
+// answered with exactly one line beginning with "ok", or "error" if it failed,
+// followed by its results as key=value pairs separated by spaces.  While
+// solving, "info" lines with the search's progress may come before it.
+//
+// Values never contain spaces: strings are quoted (as in Go), coordinates are
+// written as "i,j", lists of them are separated by ';' and steps are written
+// in hex-LURD.
+//
+// e.g.
+//
+//	> load {"id": "DWS/001", "terrain": [[2, 3], ...], "init": {...}}
+//	< ok id="DWS/001" hexes=17 crates=3
+//	> move uR
+//	< error step=2 message="cannot push the crate at 6,6 r, it is blocked"
+type engine struct {
+	out          *bufio.Writer
+	puzzle       hexoban.Puzzle
+	state        *hexoban.State // nil until a puzzle is loaded.
+	infoInterval int            // positions expanded between info lines.
+}
+
+// The longest that a hint may search for.
+const HINT_TIMEOUT = 10 * time.Second
+
+// How many positions the solver expands between its info lines.
+const INFO_INTERVAL = 4096
+
+func newEngine(output io.Writer) *engine {
+	return &engine{out: bufio.NewWriter(output), infoInterval: INFO_INTERVAL}
+}
+
+// Reads and answers commands until "quit" or the end of the input.
+func (engine *engine) run(input io.Reader) error {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		quit := engine.execute(line)
+		if err := engine.out.Flush(); err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Answers the command, returning true if it was "quit".
+func (engine *engine) execute(line string) bool {
+	command, args, _ := strings.Cut(line, " ")
+	args = strings.TrimSpace(args)
+	if command == "quit" {
+		engine.respond("ok")
+		return true
+	}
+	if command != "load" && engine.state == nil {
+		engine.fail(fmt.Errorf("no puzzle is loaded"))
+		return false
+	}
+
+	var err error
+	switch command {
+	case "load":
+		err = engine.load(args)
+	case "move":
+		err = engine.move(args)
+	case "state":
+		engine.respond("ok", engine.position()...)
+	case "hint":
+		err = engine.hint()
+	case "solve":
+		err = engine.solve(args)
+	case "undo":
+		err = engine.undo()
+	default:
+		err = fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		engine.fail(err)
+	}
+	return false
+}
+
+func (engine *engine) load(definition string) error {
+	var puzzle hexoban.Puzzle
+	if err := json.Unmarshal([]byte(definition), &puzzle); err != nil {
+		return fmt.Errorf("invalid puzzle: %w", err)
+	}
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return err
+	}
+	engine.puzzle, engine.state = puzzle, state
+	engine.respond("ok", "id="+strconv.Quote(puzzle.Identity),
+		fmt.Sprintf("hexes=%d", len(puzzle.Terrain)),
+		fmt.Sprintf("crates=%d", len(puzzle.Init.Crates)))
+	return nil
+}
+
+// Moves in each of the directions, given in hex-LURD where the case of each
+// letter is ignored, see hexoban.State.Walk().
+func (engine *engine) move(dirs string) error {
+	directions, err := hexoban.ParseSolution(dirs)
+	if err != nil {
+		return err
+	}
+	if len(directions) == 0 {
+		return fmt.Errorf("no directions to move in")
+	}
+	taken, err := engine.state.Walk(directions)
+	if err != nil {
+		return err
+	}
+	engine.respond("ok", append([]string{"steps=" + taken.String()}, engine.counts()...)...)
+	return nil
+}
+
+func (engine *engine) undo() error {
+	step, undone := engine.state.Undo()
+	if !undone {
+		return fmt.Errorf("there are no steps to undo")
+	}
+	engine.respond("ok", append([]string{"step=" + string(rune(step))}, engine.counts()...)...)
+	return nil
+}
+
+func (engine *engine) hint() error {
+	ctx, cancel := context.WithTimeout(context.Background(), HINT_TIMEOUT)
+	defer cancel()
+	suggestion, err := hexoban.Hint(ctx, engine.state)
+	if err != nil {
+		return err
+	}
+	switch {
+	case suggestion.Solved:
+		engine.respond("ok", "solved=true")
+	case suggestion.Deadlocked:
+		engine.respond("ok", "deadlocked=true", "crates="+coords(suggestion.DeadCrates))
+	default:
+		engine.respond("ok", "steps="+suggestion.Steps.String(),
+			"crate="+coords([]hexoban.HexCoord{suggestion.Crate}),
+			"direction="+string(rune(suggestion.Direction)),
+			fmt.Sprintf("remaining=%d", suggestion.Remaining))
+	}
+	return nil
+}
+
+// Searches from the current position without taking any of the steps found.
+// Responds with the steps of the solution, or with solved=false if there is
+// no solution.  Running out of time is an error.
+func (engine *engine) solve(args string) error {
+	millis, err := strconv.Atoi(args)
+	if err != nil || millis <= 0 {
+		return fmt.Errorf("solve needs a time limit in milliseconds, not %q", args)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(millis)*time.Millisecond)
+	defer cancel()
+	solver := hexoban.Solver{ProgressInterval: engine.infoInterval, Progress: func(progress hexoban.SolverProgress) {
+		if !progress.Done {
+			engine.respond("info", fmt.Sprintf("nodes=%d", progress.Nodes),
+				fmt.Sprintf("generated=%d", progress.Generated),
+				fmt.Sprintf("cells=%d", progress.FeatureCells),
+				fmt.Sprintf("bound=%d", progress.LowerBound),
+				"best="+progress.Best.String())
+			engine.out.Flush()
+		}
+	}}
+	solution, stats, err := solver.Solve(ctx, engine.state)
+	nodes := fmt.Sprintf("nodes=%d", stats.Nodes)
+	if errors.Is(err, hexoban.ErrNoSolution) {
+		engine.respond("ok", "solved=false", nodes)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	engine.respond("ok", "solved=true", "steps="+solution.String(),
+		fmt.Sprintf("moves=%d", solution.Moves()), fmt.Sprintf("pushes=%d", solution.Pushes()), nodes)
+	return nil
+}
+
+// The key=value pairs describing the current position.
+func (engine *engine) position() []string {
+	return append(engine.counts(),
+		"ichiban="+coords([]hexoban.HexCoord{engine.state.Ichiban()}),
+		"crates="+coords(engine.state.Crates()),
+		"history="+engine.state.History().String())
+}
+
+// The moves and pushes made so far and whether the puzzle is solved.
+func (engine *engine) counts() []string {
+	history := engine.state.History()
+	return []string{fmt.Sprintf("moves=%d", history.Moves()),
+		fmt.Sprintf("pushes=%d", history.Pushes()),
+		fmt.Sprintf("solved=%t", engine.state.IsSolved())}
+}
+
+func (engine *engine) respond(status string, pairs ...string) {
+	engine.out.WriteString(status)
+	for _, pair := range pairs {
+		engine.out.WriteByte(' ')
+		engine.out.WriteString(pair)
+	}
+	engine.out.WriteByte('\n')
+}
+
+// Responds with the error's message, and with the step that failed if the
+// error came from taking steps.
+func (engine *engine) fail(err error) {
+	var stepErr *hexoban.StepError
+	if errors.As(err, &stepErr) {
+		engine.respond("error", fmt.Sprintf("step=%d", stepErr.Index),
+			"message="+strconv.Quote(stepErr.Err.Error()))
+		return
+	}
+	engine.respond("error", "message="+strconv.Quote(err.Error()))
+}
+
+// Writes the coordinates as "i,j" separated by ';'.
+func coords(list []hexoban.HexCoord) string {
+	written := make([]string, len(list))
+	for index, coord := range list {
+		written[index] = fmt.Sprintf("%d,%d", coord.I(), coord.J())
+	}
+	return strings.Join(written, ";")
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/engine/engine_test.go
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the transcripts' responses from the engine's")
+
+// Each transcript in testdata/ is a conversation with the engine, the commands
+// sent to it on lines beginning with "> " and the responses expected on lines
+// beginning with "< ".  Other lines are comments.  The solver's info lines are
+// written for every position it expands, so that they are also compared.
+//
+// Run with -update to rewrite the responses after changing the protocol, and
+// review the differences before committing them.
+func TestEngine_Transcripts(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.txt")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no transcripts found: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			filedata, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(filedata), "\n"), "\n")
+			var input strings.Builder
+			var expected []string
+			for _, line := range lines {
+				if command, found := strings.CutPrefix(line, "> "); found {
+					input.WriteString(command + "\n")
+				} else if response, found := strings.CutPrefix(line, "< "); found {
+					expected = append(expected, response)
+				}
+			}
+
+			var output bytes.Buffer
+			engine := newEngine(&output)
+			engine.infoInterval = 1
+			if err := engine.run(strings.NewReader(input.String())); err != nil {
+				t.Fatal(err)
+			}
+			responses := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+			if *update {
+				os.WriteFile(path, []byte(rewrite(lines, responses)), 0644)
+				return
+			}
+			for index := 0; index < max(len(responses), len(expected)); index++ {
+				got, want := "(nothing)", "(nothing)"
+				if index < len(responses) {
+					got = responses[index]
+				}
+				if index < len(expected) {
+					want = expected[index]
+				}
+				if got != want {
+					t.Errorf("response %d:\n got  %s\n want %s", index+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// Replaces the responses of a transcript, keeping its commands and comments.
+// Each command's responses are those written before the next command's.
+func rewrite(lines, responses []string) string {
+	var result strings.Builder
+	commands := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, "< ") {
+			continue
+		}
+		result.WriteString(line + "\n")
+		if strings.HasPrefix(line, "> ") {
+			commands++
+			// Every command but quit has a final "ok" or "error" response.
+			for len(responses) > 0 {
+				response := responses[0]
+				responses = responses[1:]
+				result.WriteString("< " + response + "\n")
+				if !strings.HasPrefix(response, "info ") {
+					break
+				}
+			}
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/engine/main.go
+
+package main
+
+// Main entry point for engine.exe
+//
+// Plays puzzles for another program (a frontend or a test harness) over stdin
+// and stdout, in the manner of a chess engine speaking UCI.  See engine.go for
+// the commands and the form of their responses.
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := newEngine(os.Stdout).run(os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/ggp/kif.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A term of KIF, the syntax of GDL and of the messages between a game manager
+// and its players: either an atom (a constant, variable or number) or a list
+// of terms in parentheses.
+type term struct {
+	atom string
+	list []term // nil for atoms, empty (not nil) for "()".
+}
+
+func atom(text string) term         { return term{atom: text} }
+func list(terms ...term) term       { return term{list: append([]term{}, terms...)} }
+func (t term) isList() bool         { return t.list != nil }
+func (t term) isAtom(a string) bool { return !t.isList() && t.atom == a }
+
+// Returns the list's first element, for matching relations like (cell ?i ?j).
+func (t term) head() string {
+	if len(t.list) == 0 {
+		return ""
+	}
+	return t.list[0].atom
+}
+
+func (t term) String() string {
+	if !t.isList() {
+		return t.atom
+	}
+	parts := make([]string, len(t.list))
+	for index, element := range t.list {
+		parts[index] = element.String()
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// Parses the text as a sequence of terms.  Comments, from ';' to the end of the
+// line, are skipped and atoms are lowercased, as GDL is not case sensitive.
+func parseTerms(text string) ([]term, error) {
+	tokens := tokenize(text)
+	terms := []term{}
+	for len(tokens) > 0 {
+		var parsed term
+		var err error
+		parsed, tokens, err = parseTerm(tokens)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, parsed)
+	}
+	return terms, nil
+}
+
+// Parses the text as exactly one term, as each message is.
+func parseMessage(text string) (term, error) {
+	terms, err := parseTerms(text)
+	if err != nil {
+		return term{}, err
+	}
+	if len(terms) != 1 {
+		return term{}, fmt.Errorf("expected one term, found %d", len(terms))
+	}
+	return terms[0], nil
+}
+
+func parseTerm(tokens []string) (term, []string, error) {
+	switch token := tokens[0]; token {
+	case ")":
+		return term{}, nil, fmt.Errorf("unexpected ')'")
+	case "(":
+		result := list()
+		tokens = tokens[1:]
+		for len(tokens) > 0 && tokens[0] != ")" {
+			var element term
+			var err error
+			element, tokens, err = parseTerm(tokens)
+			if err != nil {
+				return term{}, nil, err
+			}
+			result.list = append(result.list, element)
+		}
+		if len(tokens) == 0 {
+			return term{}, nil, fmt.Errorf("missing ')'")
+		}
+		return result, tokens[1:], nil
+	default:
+		return atom(token), tokens[1:], nil
+	}
+}
+
+func tokenize(text string) []string {
+	tokens := []string{}
+	for _, line := range strings.Split(strings.ToLower(text), "\n") {
+		line, _, _ = strings.Cut(line, ";")
+		line = strings.ReplaceAll(line, "(", " ( ")
+		line = strings.ReplaceAll(line, ")", " ) ")
+		tokens = append(tokens, strings.Fields(line)...)
+	}
+	return tokens
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/ggp/main.go
+
+package main
+
+// Main entry point for ggp.exe
+//
+// Plays hexoban levels as a General Game Playing player, see player.go, or
+// with -match runs a match of a level against a player as a game manager would
+// (see manager.go).
+//
+//	ggp -addr :9147
+//	ggp -match http://localhost:9147 levels/DWS/001.json
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9147", "the address to listen on as a player")
+	name := flag.String("name", "hexoban", "the player's name, for (info)")
+	margin := flag.Duration("margin", time.Second, "the time kept from each clock for responding")
+	matchURL := flag.String("match", "", "run a match of each level against the player at this URL")
+	startClock := flag.Int("start", 10, "the start clock of a match, in seconds")
+	playClock := flag.Int("play", 5, "the play clock of a match, in seconds")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *matchURL != "" {
+		manager := manager{client: http.DefaultClient, url: *matchURL,
+			startClock: *startClock, playClock: *playClock}
+		if err := runMatches(ctx, manager, flag.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	server := &http.Server{Addr: *addr, Handler: newPlayer(*name, *margin),
+		ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	log.Printf("playing as %s on http://%s/", *name, *addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runMatches(ctx context.Context, manager manager, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("usage: ggp -match <player url> level.json...")
+	}
+	for _, path := range paths {
+		filedata, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var puzzle hexoban.Puzzle
+		if err := json.Unmarshal(filedata, &puzzle); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		id := strings.TrimSuffix(filepath.Base(path), ".json")
+		id = fmt.Sprintf("hexoban.%s.%d", strings.ReplaceAll(id, " ", "_"), time.Now().Unix())
+		result, err := manager.run(ctx, id, puzzle)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Printf("%s: goal %d after %d moves, %d pushes\n", path, result.Goal,
+			result.Steps.Moves(), result.Steps.Pushes())
+	}
+	return nil
+}
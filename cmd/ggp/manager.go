@@ -0,0 +1,146 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/ggp/manager.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// Stands in for a game manager, running a match of a level against a player
+// (for testing players without a GGP server).  Unlike a game manager it does
+// not reason over the rules it sends, it plays them with the rules engine,
+// and it ends the match as soon as the player makes an illegal move or fails
+// to answer in time, where a game manager would substitute a random move.
+type manager struct {
+	client     *http.Client
+	url        string // the player's.
+	startClock int    // in seconds.
+	playClock  int
+}
+
+// The outcome of a match.
+type matchResult struct {
+	Steps  hexoban.Solution // the moves played, with pushes in uppercase.
+	Solved bool
+	Goal   int // the player's reward, 100 if solved and 0 otherwise.
+}
+
+// Plays a match of the level, identified by id, until it is solved or its step
+// limit is reached.  The step limit is the same as in the rules sent.
+func (manager manager) run(ctx context.Context, id string, puzzle hexoban.Puzzle) (matchResult, error) {
+	result := matchResult{}
+	rules, err := hexoban.ToGDL(puzzle)
+	if err != nil {
+		return result, err
+	}
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return result, err
+	}
+	stepLimit := 20 * len(puzzle.Terrain)
+
+	info, err := manager.send(ctx, "(info)", 10)
+	if err != nil {
+		return result, err
+	}
+	if status := statusOf(info); status != "available" {
+		return result, fmt.Errorf("player is %s", status)
+	}
+	start := fmt.Sprintf("(start %s ichiban (%s) %d %d)", id, rules, manager.startClock, manager.playClock)
+	if ready, err := manager.send(ctx, start, manager.startClock); err != nil {
+		return result, err
+	} else if !ready.isAtom("ready") {
+		return result, fmt.Errorf("expected ready, player said %s", ready)
+	}
+
+	moves := "nil"
+	for !state.IsSolved() && len(state.History()) < stepLimit {
+		response, err := manager.send(ctx, fmt.Sprintf("(play %s %s)", id, moves), manager.playClock)
+		if err != nil {
+			return result, fmt.Errorf("step %d: %w", len(state.History())+1, err)
+		}
+		if response.head() != "move" || len(response.list) != 2 || len(response.list[1].atom) != 1 {
+			return result, fmt.Errorf("step %d: expected a move, player said %s",
+				len(state.History())+1, response)
+		}
+		dir, err := hexoban.ParseDirection(response.list[1].atom[0])
+		if err == nil {
+			_, err = state.Move(dir)
+		}
+		if err != nil {
+			return result, fmt.Errorf("step %d: illegal move %s: %w", len(state.History())+1, response, err)
+		}
+		moves = "(" + response.String() + ")"
+	}
+
+	result.Steps, result.Solved = state.History(), state.IsSolved()
+	if result.Solved {
+		result.Goal = 100
+	}
+	if done, err := manager.send(ctx, fmt.Sprintf("(stop %s %s)", id, moves), manager.playClock); err != nil {
+		return result, err
+	} else if !done.isAtom("done") {
+		return result, fmt.Errorf("expected done, player said %s", done)
+	}
+	return result, nil
+}
+
+// Posts the message and parses the response, which must arrive within the
+// clock (in seconds).
+func (manager manager) send(ctx context.Context, message string, clock int) (term, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(clock)*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, manager.url, strings.NewReader(message))
+	if err != nil {
+		return term{}, err
+	}
+	request.Header.Set("Content-Type", "text/acl")
+	response, err := manager.client.Do(request)
+	if err != nil {
+		return term{}, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return term{}, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return term{}, fmt.Errorf("player responded %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return parseMessage(string(body))
+}
+
+// Returns the status of an (info) response, which is either a bare status or
+// a list of properties including (status <status>).
+func statusOf(info term) string {
+	if !info.isList() {
+		return info.atom
+	}
+	for _, property := range info.list {
+		if property.head() == "status" && len(property.list) == 2 {
+			return property.list[1].atom
+		}
+	}
+	return ""
+}
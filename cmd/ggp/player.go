@@ -0,0 +1,318 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/ggp/player.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// A General Game Playing player for hexoban's games, speaking the protocol of
+// the Stanford GGP game managers: each message is POSTed as a KIF term and the
+// response is a KIF term, both as text/acl.
+//
+//	(info)                                         ((name <name>) (status available|busy))
+//	(start <id> <role> (<rules>) <start> <play>)   ready
+//	(play <id> <moves>)                            (move <d>)
+//	(stop <id> <moves>)                            done
+//	(abort <id>)                                   done
+//
+// The rules are expected to be a level exported by hexoban.ToGDL(), the level
+// is reconstructed from their facts and solved in the start clock (seconds).
+// The moves are nil for the first play and otherwise the previous turn's joint
+// move, which is checked against the plan in case the manager substituted a
+// move of its own (as it does when a player's time runs out).
+//
+// The player's mutex is only held to read or replace its match, never while
+// searching, so that (info) and (abort) are answered while a match is being
+// planned.  Searches hold the match's own mutex instead.
+type player struct {
+	name   string
+	margin time.Duration // kept from each clock for the network and the response.
+
+	mutex sync.Mutex
+	match *match // the match being played, nil when available.
+}
+
+type match struct {
+	id        string
+	role      string
+	playClock time.Duration
+	ctx       context.Context // canceled when the match is stopped or aborted.
+	cancel    context.CancelFunc
+
+	mutex sync.Mutex // held while the state and plan are used.
+	state *hexoban.State
+	plan  hexoban.Solution // the steps still to take, nil if none was found.
+}
+
+func newPlayer(name string, margin time.Duration) *player {
+	return &player{name: name, margin: margin}
+}
+
+func (player *player) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "messages are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<24))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	message, err := parseMessage(string(body))
+	if err == nil {
+		var response term
+		if response, err = player.handle(message); err == nil {
+			w.Header().Set("Content-Type", "text/acl")
+			io.WriteString(w, response.String())
+			return
+		}
+	}
+	log.Printf("responding 400: %v", err)
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// Answers the message, or returns an error if it is not understood.
+func (player *player) handle(message term) (term, error) {
+	args := message.list
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	switch {
+	case message.head() == "info" && len(args) == 0:
+		return list(list(atom("name"), atom(player.name)), list(atom("status"), atom(player.status()))), nil
+	case message.head() == "start" && len(args) == 5:
+		return player.start(args[0].atom, args[1].atom, args[2], args[3].atom, args[4].atom)
+	case message.head() == "play" && len(args) == 2:
+		match, err := player.current(args[0].atom)
+		if err != nil {
+			return term{}, err
+		}
+		return match.play(args[1], player.margin)
+	case (message.head() == "stop" && len(args) == 2) || (message.head() == "abort" && len(args) == 1):
+		match, err := player.current(args[0].atom)
+		if err != nil {
+			return term{}, err
+		}
+		player.finish(match)
+		return atom("done"), nil
+	}
+	return term{}, fmt.Errorf("unknown message %q with %d arguments", message.head(), len(args))
+}
+
+func (player *player) status() string {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+	if player.match != nil {
+		return "busy"
+	}
+	return "available"
+}
+
+func (player *player) current(id string) (*match, error) {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+	if player.match == nil || player.match.id != id {
+		return nil, fmt.Errorf("not playing match %q", id)
+	}
+	return player.match, nil
+}
+
+// Ends the match, canceling any search it is making.
+func (player *player) finish(match *match) {
+	match.cancel()
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+	if player.match == match {
+		player.match = nil
+	}
+}
+
+// Accepts the match and plans it during the start clock.  The player is busy
+// from the time the match is accepted, a start for the match already being
+// played replaces it.
+func (player *player) start(id, role string, rules term, startClock, playClock string) (term, error) {
+	startSeconds, err := strconv.Atoi(startClock)
+	if err != nil {
+		return term{}, fmt.Errorf("invalid start clock %q", startClock)
+	}
+	playSeconds, err := strconv.Atoi(playClock)
+	if err != nil {
+		return term{}, fmt.Errorf("invalid play clock %q", playClock)
+	}
+	puzzle, gameRole, err := puzzleOf(rules)
+	if err != nil {
+		return term{}, err
+	}
+	if role != gameRole {
+		return term{}, fmt.Errorf("no role %q in this game", role)
+	}
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return term{}, err
+	}
+	match := &match{id: id, role: role, state: state,
+		playClock: time.Duration(playSeconds) * time.Second}
+	match.ctx, match.cancel = context.WithCancel(context.Background())
+
+	player.mutex.Lock()
+	previous := player.match
+	if previous != nil && previous.id != id {
+		player.mutex.Unlock()
+		match.cancel()
+		return term{}, fmt.Errorf("already playing match %q", previous.id)
+	}
+	player.match = match
+	player.mutex.Unlock()
+	if previous != nil {
+		previous.cancel()
+	}
+
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+	match.replan(time.Duration(startSeconds)*time.Second - player.margin)
+	return atom("ready"), nil
+}
+
+// Reconstructs the level from the facts of its rules, see hexoban.ToGDL().
+// Returns the level and the name of its role.
+func puzzleOf(rules term) (hexoban.Puzzle, string, error) {
+	puzzle := hexoban.Puzzle{}
+	role, workers := "", 0
+	coord := func(fact term) (hexoban.HexCoord, error) {
+		if len(fact.list) != 3 {
+			return hexoban.HexCoord{}, fmt.Errorf("expected a coordinate in %s", fact)
+		}
+		i, err := strconv.Atoi(fact.list[1].atom)
+		if err != nil {
+			return hexoban.HexCoord{}, fmt.Errorf("expected a coordinate in %s", fact)
+		}
+		j, err := strconv.Atoi(fact.list[2].atom)
+		if err != nil {
+			return hexoban.HexCoord{}, fmt.Errorf("expected a coordinate in %s", fact)
+		}
+		return hexoban.NewHexCoord(i, j), nil
+	}
+
+	for _, fact := range rules.list {
+		var at hexoban.HexCoord
+		var err error
+		switch fact.head() {
+		case "role":
+			if role != "" || len(fact.list) != 2 {
+				return puzzle, "", fmt.Errorf("expected a game with one role")
+			}
+			role = fact.list[1].atom
+		case "cell":
+			at, err = coord(fact)
+			puzzle.Terrain = append(puzzle.Terrain, at)
+		case "target":
+			at, err = coord(fact)
+			puzzle.Init.Goals = append(puzzle.Init.Goals, at)
+		case "init":
+			if len(fact.list) != 2 {
+				return puzzle, "", fmt.Errorf("unexpected %s", fact)
+			}
+			switch init := fact.list[1]; init.head() {
+			case "ichiban":
+				at, err = coord(init)
+				puzzle.Init.Ichiban = at
+				workers++
+			case "crate":
+				at, err = coord(init)
+				puzzle.Init.Crates = append(puzzle.Init.Crates, at)
+			}
+		}
+		if err != nil {
+			return puzzle, "", err
+		}
+	}
+	if role == "" || workers != 1 || len(puzzle.Terrain) == 0 {
+		return puzzle, "", fmt.Errorf("the rules do not describe a hexoban level")
+	}
+	return puzzle, role, nil
+}
+
+// Takes the previous turn's move, then chooses this turn's.  If the move taken
+// is not the one planned, or there is no plan, a new plan is made within the
+// play clock.  Without a plan the first legal move is chosen.
+func (match *match) play(moves term, margin time.Duration) (term, error) {
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+	if !moves.isAtom("nil") {
+		if len(moves.list) != 1 || moves.list[0].head() != "move" || len(moves.list[0].list) != 2 {
+			return term{}, fmt.Errorf("expected one move, not %s", moves)
+		}
+		letter := moves.list[0].list[1].atom
+		if len(letter) != 1 {
+			return term{}, fmt.Errorf("unknown direction in %s", moves)
+		}
+		dir, err := hexoban.ParseDirection(letter[0])
+		if err != nil {
+			return term{}, fmt.Errorf("unknown direction %q", letter)
+		}
+		if _, err := match.state.Move(dir); err != nil {
+			return term{}, err
+		}
+		if len(match.plan) > 0 && match.plan[0].Direction() == dir {
+			match.plan = match.plan[1:]
+		} else {
+			match.plan = nil
+		}
+	}
+	if match.plan == nil && !match.state.IsSolved() {
+		match.replan(match.playClock - margin)
+	}
+
+	if len(match.plan) > 0 {
+		return moveTerm(match.plan[0].Direction()), nil
+	}
+	for _, dir := range hexoban.Directions {
+		if _, err := match.state.Clone().Move(dir); err == nil {
+			return moveTerm(dir), nil
+		}
+	}
+	return term{}, fmt.Errorf("no legal moves")
+}
+
+// Searches for a solution from the current position for up to the duration,
+// or until the match ends.  The plan is nil if none was found.
+func (match *match) replan(limit time.Duration) {
+	ctx, cancel := context.WithTimeout(match.ctx, max(limit, 100*time.Millisecond))
+	defer cancel()
+	solution, _, err := hexoban.Solver{}.Solve(ctx, match.state)
+	if err != nil {
+		log.Printf("%s: no plan: %v", match.id, err)
+		match.plan = nil
+		return
+	}
+	match.plan = solution
+}
+
+func moveTerm(dir hexoban.Direction) term {
+	return list(atom("move"), atom(string(rune(dir))))
+}
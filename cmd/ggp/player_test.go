@@ -0,0 +1,235 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/ggp/player_test.go
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// A room with one crate, pushed right (r) onto the goal beside it.
+const TREASURE_ROOM = `{"id": "test/room", "terrain": [[1, 2], [1, 3], [2, 2], [2, 3], [2, 4]],
+  "init": {"goals": [[2, 4]], "crates": [[2, 3]], "ichiban": [2, 2]}}`
+
+func loadLevel(t *testing.T, path string) hexoban.Puzzle {
+	t.Helper()
+	filedata := []byte(TREASURE_ROOM)
+	if path != "" {
+		var err error
+		if filedata, err = os.ReadFile("../../levels/" + path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var puzzle hexoban.Puzzle
+	if err := json.Unmarshal(filedata, &puzzle); err != nil {
+		t.Fatal(err)
+	}
+	return puzzle
+}
+
+func TestParseTerms(t *testing.T) {
+	tests := []struct {
+		text   string
+		expect string // the terms written back, one per line.
+	}{
+		{"(info)", "(info)"},
+		{"(play M1 NIL)", "(play m1 nil)"},
+		{"( play  m1 (( move r ) ))", "(play m1 ((move r)))"},
+		{"; a comment (\n(role ichiban) ;(another\n(cell 0 1)", "(role ichiban)\n(cell 0 1)"},
+		{"()", "()"},
+	}
+	for _, tt := range tests {
+		terms, err := parseTerms(tt.text)
+		if err != nil {
+			t.Errorf("parseTerms(%q) error = %v", tt.text, err)
+			continue
+		}
+		written := []string{}
+		for _, term := range terms {
+			written = append(written, term.String())
+		}
+		if got := strings.Join(written, "\n"); got != tt.expect {
+			t.Errorf("parseTerms(%q) = %q, expected %q", tt.text, got, tt.expect)
+		}
+	}
+	for _, text := range []string{"(info", "info)", ")(", "(a) (b)", ""} {
+		if _, err := parseMessage(text); err == nil {
+			t.Errorf("parseMessage(%q), expected an error", text)
+		}
+	}
+}
+
+// The level read back from its rules plays the same as the level exported.
+func TestPuzzleOf(t *testing.T) {
+	for _, path := range []string{"", "DWS/001.json", "Heloban/The arm.json", "Heroban/The big trefle.json"} {
+		puzzle := loadLevel(t, path)
+		rules, err := hexoban.ToGDL(puzzle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		facts, err := parseTerms(rules)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		read, role, err := puzzleOf(list(facts...))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		expected, _ := puzzle.Fingerprint()
+		if got, err := read.Fingerprint(); err != nil || got != expected || role != "ichiban" {
+			t.Errorf("%s: read back as %s (%v) with role %q, expected %s", path, got, err, role, expected)
+		}
+	}
+
+	for _, rules := range []string{
+		"((role white) (role black) (cell 0 0))",
+		"((role ichiban) (cell 0 0))",
+		"((role ichiban) (cell 0 x) (init (ichiban 0 0)))",
+	} {
+		facts, _ := parseMessage(rules)
+		if _, _, err := puzzleOf(facts); err == nil {
+			t.Errorf("puzzleOf(%s), expected an error", rules)
+		}
+	}
+}
+
+func TestManager_Run(t *testing.T) {
+	server := httptest.NewServer(newPlayer("tester", 100*time.Millisecond))
+	defer server.Close()
+	manager := manager{client: server.Client(), url: server.URL, startClock: 5, playClock: 2}
+	for _, path := range []string{"", "DWS/001.json", "Heroban/Heroban 1.json"} {
+		puzzle := loadLevel(t, path)
+		result, err := manager.run(context.Background(), "match."+strings.ReplaceAll(path, " ", "_"), puzzle)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !result.Solved || result.Goal != 100 {
+			t.Errorf("%s: goal %d after %s", path, result.Goal, result.Steps)
+		}
+		state, _ := hexoban.NewState(puzzle)
+		if err := state.Apply(result.Steps); err != nil || !state.IsSolved() {
+			t.Errorf("%s: the steps %s do not solve the level: %v", path, result.Steps, err)
+		}
+	}
+}
+
+func TestPlayer_Messages(t *testing.T) {
+	player := newPlayer("tester", 100*time.Millisecond)
+	rules, err := hexoban.ToGDL(loadLevel(t, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := "(start m1 ichiban (" + rules + ") 5 2)"
+	// The room's worker begins beside the crate, the manager substitutes an
+	// up (u) move for the push on the first turn and the player replans.
+	tests := []struct {
+		message string
+		status  int
+		expect  string
+	}{
+		{"(info)", http.StatusOK, "((name tester) (status available))"},
+		{"(play m1 nil)", http.StatusBadRequest, ""},
+		{"(start m1 white (" + rules + ") 5 2)", http.StatusBadRequest, ""},
+		{"(start m1 ichiban ((role ichiban)) 5 2)", http.StatusBadRequest, ""},
+		{start, http.StatusOK, "ready"},
+		{"(INFO)", http.StatusOK, "((name tester) (status busy))"},
+		{"(start m2 ichiban (" + rules + ") 5 2)", http.StatusBadRequest, ""},
+		{"(play m1 nil)", http.StatusOK, "(move r)"},
+		{"(play m1 ((move u)))", http.StatusOK, "(move d)"},
+		{"(play m1 ((move d)))", http.StatusOK, "(move r)"},
+		{"(play m1 ((move x)))", http.StatusBadRequest, ""},
+		{"(play m1 ((move ())))", http.StatusBadRequest, ""},
+		{"(play m1 ((move ur)))", http.StatusBadRequest, ""},
+		{"(play m1 ((move l)))", http.StatusBadRequest, ""},
+		{"(stop m2 ((move r)))", http.StatusBadRequest, ""},
+		{"(stop m1 ((move r)))", http.StatusOK, "done"},
+		{"(info)", http.StatusOK, "((name tester) (status available))"},
+		{start, http.StatusOK, "ready"},
+		{"(abort m1)", http.StatusOK, "done"},
+		{"(hello)", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		player.ServeHTTP(recorder, httptest.NewRequest("POST", "/", strings.NewReader(tt.message)))
+		summary := tt.message
+		if len(summary) > 40 {
+			summary = summary[:40] + "..."
+		}
+		if recorder.Code != tt.status {
+			t.Errorf("%s: status %d, expected %d: %s", summary, recorder.Code, tt.status, recorder.Body)
+		} else if tt.status == http.StatusOK && recorder.Body.String() != tt.expect {
+			t.Errorf("%s: responded %s, expected %s", summary, recorder.Body, tt.expect)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	player.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status %d, expected %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// While a match is being planned (here, while its search holds the match's
+// mutex) the player still answers (info) as busy and accepts (abort).
+func TestPlayer_AnswersWhilePlanning(t *testing.T) {
+	player := newPlayer("tester", 100*time.Millisecond)
+	rules, err := hexoban.ToGDL(loadLevel(t, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := player.handle(mustParse(t, "(start m1 ichiban ("+rules+") 5 2)")); err != nil {
+		t.Fatal(err)
+	}
+	match := player.match
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+
+	answered := make(chan string)
+	go func() {
+		info, _ := player.handle(mustParse(t, "(info)"))
+		done, _ := player.handle(mustParse(t, "(abort m1)"))
+		answered <- info.String() + " " + done.String()
+	}()
+	select {
+	case response := <-answered:
+		if response != "((name tester) (status busy)) done" {
+			t.Errorf("responded %s", response)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no response while the match was being planned")
+	}
+	if match.ctx.Err() == nil || player.match != nil {
+		t.Errorf("the match was not ended by the abort")
+	}
+}
+
+func mustParse(t *testing.T, text string) term {
+	t.Helper()
+	message, err := parseMessage(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/hexoban/collection.go
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+// hexoban collection [-o file.hsb] [-title T] [-author A] <levels-dir>
+// hexoban collection -x <file.hsb> [-o levels-dir]
+//
+// Gathers all the levels in a directory into a single collection file, in the
+// text format of textmap.EncodeCollection.  With -x, a collection file is split
+// into separate level definitions instead, one .json file for each level, named
+// after the level's ID (or its position in the collection if it has none).
+func collectionCommand(args []string) error {
+	flags := flag.NewFlagSet("collection", flag.ExitOnError)
+	output := flags.String("o", "", "file or directory to write to (default stdout or current dir)")
+	extract := flags.String("x", "", "extract the levels of this collection file")
+	title := flags.String("title", "", "the collection's title (default the directory name)")
+	author := flags.String("author", "", "the collection's author (default the levels' author, if all the same)")
+	flags.Parse(args)
+
+	if *extract != "" {
+		outDir := *output
+		if outDir == "" {
+			outDir = "."
+		}
+		return extractCollection(*extract, outDir)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one levels directory, got %d", flags.NArg())
+	}
+	rootDir := flags.Arg(0)
+
+	paths, err := levelPaths(rootDir)
+	if err != nil {
+		return err
+	}
+	collection := textmap.Collection{Title: *title, Author: *author}
+	if collection.Title == "" {
+		collection.Title = filepath.Base(filepath.Clean(rootDir))
+	}
+	for _, path := range paths {
+		puzzle, err := loadPuzzle(path)
+		if err != nil {
+			return err
+		}
+		collection.Levels = append(collection.Levels, textmap.Level{Puzzle: puzzle})
+	}
+	if collection.Author == "" && len(collection.Levels) > 0 {
+		collection.Author = collection.Levels[0].Author
+		for _, level := range collection.Levels {
+			if level.Author != collection.Author {
+				collection.Author = ""
+				break
+			}
+		}
+	}
+
+	var writer io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+	return textmap.EncodeCollection(writer, collection)
+}
+
+// Writes each level of the collection as a JSON file in the output directory.
+// Existing files are not overwritten, an error is returned instead.
+func extractCollection(collectionPath, outDir string) error {
+	file, err := os.Open(collectionPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	collection, err := textmap.DecodeCollection(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", collectionPath, err)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	for index, level := range collection.Levels {
+		puzzle := level.Puzzle
+		if puzzle.Author == "" {
+			puzzle.Author = collection.Author
+		}
+		if puzzle.Source == "" {
+			puzzle.Source = collection.Source
+		}
+		name := fmt.Sprintf("%03d", index+1)
+		if puzzle.Identity != "" {
+			name = path.Base(puzzle.Identity)
+		}
+		name = strings.TrimSuffix(name, ".json") + ".json"
+
+		filedata, err := json.Marshal(puzzle)
+		if err != nil {
+			return err
+		}
+		outPath := filepath.Join(outDir, name)
+		out, err := os.OpenFile(outPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(filedata)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(outPath)
+	}
+	return nil
+}
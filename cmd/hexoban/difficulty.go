@@ -0,0 +1,151 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/hexoban/difficulty.go
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/internal/jsonedit"
+)
+
+// hexoban difficulty [-w] [-calibrate] [-timeout 10s] [-tolerance 2] [levels-dir]
+//
+// Measures every level and reports its estimated difficulty next to the one
+// assigned by hand, if any, highlighting those which disagree.  With -w the
+// estimate is written into each level file that does not yet have one.
+func difficultyCommand(args []string) error {
+	flags := flag.NewFlagSet("difficulty", flag.ExitOnError)
+	write := flags.Bool("w", false, "write estimates into level files missing a difficulty")
+	calibrate := flags.Bool("calibrate", false,
+		"fit the model to the hand-assigned difficulties before estimating")
+	timeout := flags.Duration("timeout", 10*time.Second, "time limit for measuring each level")
+	tolerance := flags.Int("tolerance", 2,
+		"report hand-assigned difficulties that differ from the estimate by more than this")
+	flags.Parse(args)
+
+	rootDir := "levels"
+	if flags.NArg() > 0 {
+		rootDir = flags.Arg(0)
+	}
+	paths, err := levelPaths(rootDir)
+	if err != nil {
+		return err
+	}
+
+	// Measure all levels before estimating, calibration needs all of them.
+	measured := make([]string, 0, len(paths))
+	puzzles := make(map[string]hexoban.Puzzle)
+	stats := make(map[string]hexoban.DifficultyStats)
+	for _, path := range paths {
+		puzzle, err := loadPuzzle(path)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		measure, err := hexoban.MeasureDifficulty(ctx, puzzle)
+		cancel()
+		if err != nil {
+			fmt.Printf("%-40s  skipped: %s\n", path, err)
+			continue
+		}
+		measured = append(measured, path)
+		puzzles[path] = puzzle
+		stats[path] = measure
+	}
+
+	model := hexoban.DefaultDifficultyModel
+	if *calibrate {
+		samples, ratings := make([]hexoban.DifficultyStats, 0), make([]int, 0)
+		for _, path := range measured {
+			if puzzles[path].Difficulty > 0 {
+				samples = append(samples, stats[path])
+				ratings = append(ratings, puzzles[path].Difficulty)
+			}
+		}
+		if model, err = hexoban.CalibrateDifficulty(samples, ratings); err != nil {
+			return err
+		}
+		fmt.Printf("calibrated model from %d rated levels: %.3f\n", len(samples), model)
+	}
+
+	filled, disagreements := 0, 0
+	for _, path := range measured {
+		puzzle, measure := puzzles[path], stats[path]
+		estimate := model.Estimate(measure)
+		detail := fmt.Sprintf("(%d nodes, %d moves, %d pushes, %d cells)",
+			measure.BaselineNodes, measure.Moves, measure.Pushes, measure.Cells)
+
+		if puzzle.Difficulty == 0 {
+			fmt.Printf("%-40s  estimate %2d  unrated  %s\n", path, estimate, detail)
+			if *write {
+				if err := writeDifficulty(path, estimate); err != nil {
+					return err
+				}
+				filled++
+			}
+			continue
+		}
+
+		rated := min(puzzle.Difficulty, hexoban.DIFFICULTY_MAX)
+		fmt.Printf("%-40s  estimate %2d  rated %2d  %s", path, estimate, puzzle.Difficulty, detail)
+		if estimate-rated > *tolerance || rated-estimate > *tolerance {
+			fmt.Print("  <- disagrees")
+			disagreements++
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("\n%d levels measured, %d skipped, %d filled in, %d disagree with their rating\n",
+		len(measured), len(paths)-len(measured), filled, disagreements)
+	return nil
+}
+
+// Adds the difficulty to the level file, keeping the rest of its contents and
+// layout as they are.  The file must not already define a difficulty.
+func writeDifficulty(path string, difficulty int) error {
+	filedata, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	updated, err := insertDifficulty(filedata, difficulty)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return os.WriteFile(path, updated, 0644)
+}
+
+// Appends the difficulty as the last property of the JSON object (or replaces
+// a difficulty of zero, which is unrated), keeping the rest of the file as it
+// was.
+func insertDifficulty(filedata []byte, difficulty int) ([]byte, error) {
+	object, err := jsonedit.Parse(filedata, 0)
+	if err != nil {
+		return nil, err
+	}
+	if existing, exists := object.Get("difficulty"); exists && string(existing) != "0" {
+		return nil, fmt.Errorf("a difficulty of %s is already defined", existing)
+	}
+	if err := object.Set("difficulty", fmt.Sprint(difficulty)); err != nil {
+		return nil, err
+	}
+	return object.Bytes(), nil
+}
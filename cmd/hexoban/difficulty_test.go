@@ -0,0 +1,60 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/hexoban/difficulty_test.go
+
+package main
+
+import "testing"
+
+func TestInsertDifficulty(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		expect  string
+		wantErr bool
+	}{
+		{
+			"level layout",
+			"{\n  \"id\": \"x/01\",\n  \"init\": {\n    \"goals\": []\n  }\n}",
+			"{\n  \"id\": \"x/01\",\n  \"init\": {\n    \"goals\": []\n  },\n  \"difficulty\": 7\n}",
+			false,
+		},
+		{
+			"trailing newline",
+			"{\n  \"id\": \"x/02\"\n}\n",
+			"{\n  \"id\": \"x/02\",\n  \"difficulty\": 7\n}\n",
+			false,
+		},
+		{
+			"unrated",
+			"{\n  \"difficulty\": 0,\n  \"id\": \"x/03\"\n}",
+			"{\n  \"difficulty\": 7,\n  \"id\": \"x/03\"\n}",
+			false,
+		},
+		{"already rated", "{\n  \"difficulty\": 3\n}", "", true},
+		{"not an object", "[1, 2]", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := insertDifficulty([]byte(tt.input), 7)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("insertDifficulty() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.expect {
+				t.Errorf("insertDifficulty()\n%s\nexpected:\n%s", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/hexoban/gif.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render"
+	"github.com/SymbolNotFound/hexoban/render/raster"
+)
+
+// hexoban gif [-o out.gif] [flags] <level.json> <solution.txt | ->
+//
+// Replays the solution (in hex-LURD notation, read from stdin for "-") on the
+// level and writes it as an animated GIF, by default named after the level's
+// identity.
+func gifCommand(args []string) error {
+	flags := flag.NewFlagSet("gif", flag.ExitOnError)
+	outPath := flags.String("o", "", "the file to write (default <level id>.gif)")
+	size := flags.Int("size", raster.DEFAULT_TILE_SIZE, "the width of each hex in pixels")
+	delay := flags.Duration("delay", 10*time.Millisecond*raster.DEFAULT_FRAME_DELAY,
+		"how long each step is shown")
+	hold := flags.Duration("hold", 10*time.Millisecond*raster.DEFAULT_HOLD_DELAY,
+		"how long the first and last positions are shown")
+	pushes := flags.Bool("pushes", false, "skip the frames of walking, only showing each push")
+	counter := flags.Bool("counter", false, "show the moves and pushes made beneath the level")
+	orient := flags.String("orient", "0",
+		"rotation in degrees counter-clockwise, a multiple of 60, with m to mirror first (e.g. 120m)")
+	themeName := flags.String("theme", "light", "the color theme, light or dark")
+	dead := flags.Bool("dead", false, "mark the dead cells")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: hexoban gif [flags] <level.json> <solution.txt | ->")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a level and a solution, got %d arguments", flags.NArg())
+	}
+
+	puzzle, err := loadPuzzle(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var lurd []byte
+	if flags.Arg(1) == "-" {
+		lurd, err = io.ReadAll(os.Stdin)
+	} else {
+		lurd, err = os.ReadFile(flags.Arg(1))
+	}
+	if err != nil {
+		return err
+	}
+	solution, err := hexoban.ParseSolution(string(lurd))
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(1), err)
+	}
+	// The solution is verified before any file is written.
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return err
+	}
+	if err := state.Apply(solution); err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(1), err)
+	}
+	orientation, err := render.ParseOrientation(*orient)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeName)
+	if err != nil {
+		return err
+	}
+
+	if *outPath == "" {
+		name := strings.ReplaceAll(puzzle.Identity, "/", "-")
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(flags.Arg(0)), ".json")
+		}
+		*outPath = name + ".gif"
+	}
+	file, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	err = raster.EncodeGIF(file, puzzle, solution, raster.AnimationOptions{
+		Options: raster.Options{
+			Overlays:    render.Overlays{Dead: *dead},
+			Orientation: orientation,
+			Theme:       theme,
+			TileSize:    *size,
+		},
+		Delay:     max(1, int(*delay/(10*time.Millisecond))),
+		Hold:      max(1, int(*hold/(10*time.Millisecond))),
+		SkipWalks: *pushes,
+		Counter:   *counter,
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(1), err)
+	}
+
+	result := "solved"
+	if !state.IsSolved() {
+		result = "not solved"
+	}
+	fmt.Printf("%s: %d moves, %d pushes (%s)\n", *outPath, solution.Moves(), solution.Pushes(), result)
+	return nil
+}
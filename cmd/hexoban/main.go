@@ -0,0 +1,112 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/hexoban/main.go
+
+package main
+
+// Main entry point for hexoban.exe
+//
+// A collection of subcommands for maintaining the levels collection and for
+// working with puzzles and their solutions.  Run without arguments for usage.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// A subcommand receives the arguments following its name.
+type command struct {
+	run     func(args []string) error
+	summary string
+}
+
+var commands = map[string]command{
+	"collection": {collectionCommand,
+		"gather levels into one collection file, or split one into levels"},
+	"difficulty": {difficultyCommand,
+		"estimate difficulty for levels, filling in those which are missing"},
+	"gif": {gifCommand,
+		"replay a solution on its level as an animated GIF"},
+	"pddl": {pddlCommand,
+		"write levels as PDDL problems, or verify a planner's plan for a level"},
+	"render": {renderCommand,
+		"draw levels as PNG or SVG images"},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: hexoban <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
+
+// Returns the paths of all puzzle definitions (.json files) within the
+// directory and its subdirectories, in lexical order.
+func levelPaths(rootDir string) ([]string, error) {
+	paths := make([]string, 0)
+	err := filepath.WalkDir(rootDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(path, ".json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// Reads a puzzle from its JSON representation.
+func loadPuzzle(path string) (hexoban.Puzzle, error) {
+	var puzzle hexoban.Puzzle
+	filedata, err := os.ReadFile(path)
+	if err != nil {
+		return puzzle, err
+	}
+	if err = json.Unmarshal(filedata, &puzzle); err != nil {
+		return puzzle, fmt.Errorf("%s: %w", path, err)
+	}
+	return puzzle, nil
+}
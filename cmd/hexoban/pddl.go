@@ -0,0 +1,124 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/hexoban/pddl.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// The name of the domain file written alongside the problem files.
+const PDDL_DOMAIN_FILE = "hexoban-domain.pddl"
+
+// hexoban pddl [-o pddl] [levels-dir | level.json]...
+// hexoban pddl -plan <plan-file> <level.json>
+//
+// Writes the hexoban domain and a problem for each level into the output
+// directory, problems are named after the level's identity.  With -plan, reads
+// a plan found by a planner for the level's problem and verifies that it solves
+// the level, printing the solution in hex-LURD notation.
+func pddlCommand(args []string) error {
+	flags := flag.NewFlagSet("pddl", flag.ExitOnError)
+	outDir := flags.String("o", "pddl", "directory to write the domain and problem files into")
+	planPath := flags.String("plan", "", "verify this plan file instead of writing problems")
+	flags.Parse(args)
+
+	if *planPath != "" {
+		if flags.NArg() != 1 {
+			return fmt.Errorf("verifying a plan requires exactly one level, got %d", flags.NArg())
+		}
+		return verifyPlan(*planPath, flags.Arg(0))
+	}
+
+	roots := flags.Args()
+	if len(roots) == 0 {
+		roots = []string{"levels"}
+	}
+	paths := make([]string, 0)
+	for _, root := range roots {
+		found, err := levelPaths(root)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, found...)
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return err
+	}
+	domainPath := filepath.Join(*outDir, PDDL_DOMAIN_FILE)
+	if err := os.WriteFile(domainPath, []byte(hexoban.PDDLDomain), 0644); err != nil {
+		return err
+	}
+	fmt.Println(domainPath)
+
+	for _, path := range paths {
+		puzzle, err := loadPuzzle(path)
+		if err != nil {
+			return err
+		}
+		problem, err := hexoban.ToPDDLProblem(puzzle)
+		if err != nil {
+			fmt.Printf("%-40s  skipped: %s\n", path, err)
+			continue
+		}
+		name := strings.ReplaceAll(puzzle.Identity, "/", "-") + ".pddl"
+		problemPath := filepath.Join(*outDir, name)
+		if err := os.WriteFile(problemPath, []byte(problem), 0644); err != nil {
+			return err
+		}
+		fmt.Println(problemPath)
+	}
+	return nil
+}
+
+// Reads the plan and replays it on the level, reporting the solution if the
+// plan is valid and solves the level, or an error describing what is wrong.
+func verifyPlan(planPath, levelPath string) error {
+	puzzle, err := loadPuzzle(levelPath)
+	if err != nil {
+		return err
+	}
+	plan, err := os.Open(planPath)
+	if err != nil {
+		return err
+	}
+	defer plan.Close()
+
+	solution, err := hexoban.ParsePDDLPlan(puzzle, plan)
+	if err != nil {
+		return fmt.Errorf("%s: %w", planPath, err)
+	}
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return err
+	}
+	if err := state.Apply(solution); err != nil {
+		return fmt.Errorf("%s: %w", planPath, err)
+	}
+	if !state.IsSolved() {
+		return fmt.Errorf("%s: the plan does not solve %s", planPath, puzzle.Identity)
+	}
+	fmt.Printf("%s solves %s in %d moves, %d pushes\n%s\n",
+		planPath, puzzle.Identity, solution.Moves(), solution.Pushes(), solution)
+	return nil
+}
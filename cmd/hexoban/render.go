@@ -0,0 +1,117 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/hexoban/render.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render"
+	"github.com/SymbolNotFound/hexoban/render/raster"
+	"github.com/SymbolNotFound/hexoban/render/svg"
+)
+
+// hexoban render [-format png|svg] [-o dir] [flags] [levels-dir | level.json]...
+//
+// Draws each level's initial position into an image file named after the
+// level's identity, as PNG (at -size pixels per hex) or SVG.
+func renderCommand(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	format := flags.String("format", "png", "the image format, png or svg")
+	outDir := flags.String("o", ".", "directory to write the images into")
+	size := flags.Int("size", raster.DEFAULT_TILE_SIZE, "the width of each hex in pixels (png)")
+	width := flags.Int("width", 0, "the width of the image in pixels (svg, default is unsized)")
+	orient := flags.String("orient", "0",
+		"rotation in degrees counter-clockwise, a multiple of 60, with m to mirror first (e.g. 120m)")
+	themeName := flags.String("theme", "light", "the color theme, light or dark")
+	overlays := render.Overlays{}
+	flags.BoolVar(&overlays.Dead, "dead", false, "mark the dead cells")
+	flags.BoolVar(&overlays.Coordinates, "coords", false, "label each hex with its coordinate")
+	flags.BoolVar(&overlays.Matching, "matching", false, "join each crate to its matched goal")
+	flags.Parse(args)
+
+	orientation, err := render.ParseOrientation(*orient)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeName)
+	if err != nil {
+		return err
+	}
+	var encode func(file *os.File, puzzle hexoban.Puzzle) error
+	switch *format {
+	case "png":
+		options := raster.Options{
+			Overlays: overlays, Orientation: orientation, Theme: theme, TileSize: *size}
+		encode = func(file *os.File, puzzle hexoban.Puzzle) error {
+			return raster.EncodePNG(file, puzzle, options)
+		}
+	case "svg":
+		options := svg.Options{
+			Overlays: overlays, Orientation: orientation, Theme: theme, Width: *width}
+		encode = func(file *os.File, puzzle hexoban.Puzzle) error {
+			return svg.Encode(file, puzzle, options)
+		}
+	default:
+		return fmt.Errorf("unknown image format %q, expected png or svg", *format)
+	}
+
+	roots := flags.Args()
+	if len(roots) == 0 {
+		roots = []string{"levels"}
+	}
+	paths := make([]string, 0)
+	for _, root := range roots {
+		found, err := levelPaths(root)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, found...)
+	}
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		puzzle, err := loadPuzzle(path)
+		if err != nil {
+			return err
+		}
+		name := strings.ReplaceAll(puzzle.Identity, "/", "-")
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		imagePath := filepath.Join(*outDir, name+"."+*format)
+		file, err := os.Create(imagePath)
+		if err != nil {
+			return err
+		}
+		err = encode(file, puzzle)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Println(imagePath)
+	}
+	return nil
+}
@@ -19,18 +19,33 @@ package main
 // Main entry point for inspector.exe
 //
 // Inspects a puzzle, validates that it is well-formed, reports statistics.
+// Each puzzle's map is shown with its dead cells marked, in color when writing
+// to a terminal.
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path"
 	"strings"
 
 	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render/ansi"
 )
 
 func main() {
+	renderMode := flag.String("render", "auto",
+		"how maps are drawn, one of "+strings.Join(ansi.MODE_NAMES, ", "))
+	renderScale := flag.Int("scale", 1, "the size of each hex when rendering unicode")
+	flag.Parse()
+	mode, err := ansi.ParseMode(*renderMode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	options := ansi.Options{Mode: ansi.ForOutput(mode, os.Stdout), Scale: *renderScale, Dead: true}
+
 	for puzzlePath := range allPuzzles("../levels/") {
 		filedata, err := os.ReadFile(puzzlePath)
 		if err != nil {
@@ -44,9 +59,12 @@ func main() {
 			return
 		}
 
-		fmt.Printf("**%s** *by %s*\n", puzzle.Name, puzzle.Author)
+		fmt.Printf("**%s** *by %s*\n", puzzle.Title, puzzle.Author)
 		fmt.Printf("retrieved from %s\n", puzzle.Source)
 		fmt.Printf("%d traversible tiles.\n", len(puzzle.Terrain))
+		if rendered, err := ansi.Render(puzzle, options); err == nil {
+			fmt.Println(rendered)
+		}
 
 		errorlog := validatePuzzle(puzzle)
 		if errorlog == nil {
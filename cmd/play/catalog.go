@@ -0,0 +1,94 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/play/catalog.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// A collection is a directory of levels, in the order of their file names.
+type collection struct {
+	name   string
+	levels []level
+}
+
+type level struct {
+	path   string
+	puzzle hexoban.Puzzle
+}
+
+// Reads all of the levels in each subdirectory of the root directory.  Levels
+// directly within the root directory are in a collection of the root's name.
+func loadCatalog(rootDir string) ([]collection, error) {
+	byDir := make(map[string]*collection)
+	err := filepath.WalkDir(rootDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		puzzle, err := loadLevel(path)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Dir(path)
+		if byDir[dir] == nil {
+			name, _ := filepath.Rel(rootDir, dir)
+			if name == "." {
+				name = filepath.Base(rootDir)
+			}
+			byDir[dir] = &collection{name: filepath.ToSlash(name)}
+		}
+		byDir[dir].levels = append(byDir[dir].levels, level{path, puzzle})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	collections := make([]collection, 0, len(byDir))
+	for _, found := range byDir {
+		collections = append(collections, *found)
+	}
+	sort.Slice(collections, func(a, b int) bool {
+		return collections[a].name < collections[b].name
+	})
+	if len(collections) == 0 {
+		return nil, fmt.Errorf("no levels found in %s", rootDir)
+	}
+	return collections, nil
+}
+
+func loadLevel(path string) (hexoban.Puzzle, error) {
+	var puzzle hexoban.Puzzle
+	filedata, err := os.ReadFile(path)
+	if err != nil {
+		return puzzle, err
+	}
+	if err := json.Unmarshal(filedata, &puzzle); err != nil {
+		return puzzle, fmt.Errorf("%s: %w", path, err)
+	}
+	if puzzle.Identity == "" {
+		puzzle.Identity = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	return puzzle, nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/play/game.go
+
+package main
+
+import (
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render/ansi"
+)
+
+// A level being played, its state and the steps which can be redone.
+type game struct {
+	puzzle hexoban.Puzzle
+	state  *hexoban.State
+	redos  hexoban.Solution // the most recently undone step is last.
+}
+
+func newGame(puzzle hexoban.Puzzle) (*game, error) {
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return nil, err
+	}
+	return &game{puzzle: puzzle, state: state}, nil
+}
+
+// Moves the ichiban, pushing a crate if there is one in the way.  Taking the
+// step that would be redone keeps the rest of the steps for redoing.
+func (play *game) move(dir hexoban.Direction) error {
+	step, err := play.state.Move(dir)
+	if err != nil {
+		return err
+	}
+	if len(play.redos) > 0 && play.redos[len(play.redos)-1] == step {
+		play.redos = play.redos[:len(play.redos)-1]
+	} else {
+		play.redos = nil
+	}
+	return nil
+}
+
+func (play *game) undo() bool {
+	step, ok := play.state.Undo()
+	if ok {
+		play.redos = append(play.redos, step)
+	}
+	return ok
+}
+
+func (play *game) redo() bool {
+	if len(play.redos) == 0 {
+		return false
+	}
+	step := play.redos[len(play.redos)-1]
+	play.redos = play.redos[:len(play.redos)-1]
+	_, err := play.state.Move(step.Direction())
+	return err == nil
+}
+
+// Returns to the level's initial state, all of the steps taken can be redone.
+func (play *game) restart() {
+	for play.undo() {
+	}
+}
+
+func (play *game) solved() bool {
+	return play.state.IsSolved()
+}
+
+func (play *game) history() hexoban.Solution {
+	return play.state.History()
+}
+
+// Returns the current state rendered with the options, in the text map layout
+// for MODE_ASCII.
+func (play *game) board(options ansi.Options) (string, error) {
+	current := play.puzzle
+	current.Init.Crates = play.state.Crates()
+	current.Init.Ichiban = play.state.Ichiban()
+	return ansi.Render(current, options)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/play/game_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render/ansi"
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+// A small level, solved by pushing the crate right twice.
+const testMap = " # # # # #\n# @ $   . #\n # # # # #"
+
+func testGame(t *testing.T) *game {
+	puzzle, err := textmap.Decode(strings.NewReader(testMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	puzzle.Identity, puzzle.Title = "test/1", "Test"
+	playing, err := newGame(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return playing
+}
+
+func TestGame(t *testing.T) {
+	playing := testGame(t)
+	if err := playing.move(hexoban.DIR_LEFT); err == nil {
+		t.Errorf("moved into a wall")
+	}
+	if err := playing.move(hexoban.DIR_RIGHT); err != nil {
+		t.Fatal(err)
+	}
+	board, _ := playing.board(ansi.Options{Mode: ansi.MODE_ASCII})
+	if board != " # # # # #\n#   @ $ . #\n # # # # #" {
+		t.Errorf("board() after a push =\n%s", board)
+	}
+
+	// Undo, redo and retaking the undone step keep the other redo steps.
+	playing.move(hexoban.DIR_RIGHT)
+	playing.undo()
+	playing.undo()
+	if !playing.redo() || playing.history().String() != "R" {
+		t.Errorf("after redo, history %q", playing.history())
+	}
+	playing.undo()
+	playing.move(hexoban.DIR_RIGHT)
+	if !playing.redo() || !playing.solved() {
+		t.Errorf("after retaking a step and redo, history %q", playing.history())
+	}
+
+	playing.restart()
+	if len(playing.history()) != 0 || playing.solved() {
+		t.Errorf("after restart, history %q", playing.history())
+	}
+	playing.redo()
+	playing.redo()
+	if !playing.solved() {
+		t.Errorf("redo after restart did not replay the solution")
+	}
+	if playing.redo() {
+		t.Errorf("redo past the end")
+	}
+}
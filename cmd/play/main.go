@@ -0,0 +1,103 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/play/main.go
+
+package main
+
+// Main entry point for play.exe
+//
+// Plays the levels in the terminal, starting from the level select screen or
+// from the level given as an argument.  Solutions are saved in hex-LURD
+// notation as they are found.
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban/internal/terminal"
+	"github.com/SymbolNotFound/hexoban/render/ansi"
+)
+
+func main() {
+	levelsDir := flag.String("levels", "levels", "the directory of level collections")
+	solutionsDir := flag.String("solutions", "solutions",
+		"the directory to save solutions into, named after each level's ID")
+	renderMode := flag.String("render", "auto",
+		"how the board is drawn, one of "+strings.Join(ansi.MODE_NAMES, ", "))
+	renderScale := flag.Int("scale", 1, "the size of each hex when rendering unicode")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr,
+			"usage: play [-levels dir] [-solutions dir] [-render mode] [-scale n] [level.json]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	mode, err := ansi.ParseMode(*renderMode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	collections, err := loadCatalog(*levelsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	screen := &playScreen{
+		collections:  collections,
+		solutionsDir: *solutionsDir,
+		render:       ansi.Options{Mode: ansi.ForOutput(mode, os.Stdout), Scale: *renderScale, Dead: true},
+	}
+	if flag.NArg() > 0 {
+		if err := screen.open(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if err := terminal.Run(screen.draw, screen.handle); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Starts playing the level at the path, selecting it within its collection if
+// it is one of the catalog's levels, otherwise as a collection of its own.
+func (screen *playScreen) open(path string) error {
+	for current, found := range screen.collections {
+		for selected, candidate := range found.levels {
+			if filepath.Clean(candidate.path) == filepath.Clean(path) {
+				screen.current, screen.selected = current, selected
+				screen.start()
+				return nil
+			}
+		}
+	}
+	puzzle, err := loadLevel(path)
+	if err != nil {
+		return err
+	}
+	screen.collections = append(screen.collections,
+		collection{filepath.Base(path), []level{{path, puzzle}}})
+	screen.current, screen.selected = len(screen.collections)-1, 0
+	screen.start()
+	if screen.playing == nil {
+		return fmt.Errorf("%s", screen.message)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/play/screen.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/internal/terminal"
+	"github.com/SymbolNotFound/hexoban/render/ansi"
+)
+
+const (
+	PLAY_HELP = "move: w e a d z x, arrows   u/U: undo/redo   r: restart\r\n" +
+		"n/p: next/previous level   l: level select   q: quit"
+	SELECT_HELP = "left/right: collection   up/down: level   enter: play   q: quit"
+)
+
+// The number of levels listed at once on the level select screen.
+const SELECT_ROWS = 16
+
+// The state of the terminal client, selecting a level or playing one.
+type playScreen struct {
+	collections  []collection
+	current      int // the index of the selected collection,
+	selected     int // and of its selected level.
+	playing      *game
+	solutionsDir string
+	render       ansi.Options
+	message      string
+}
+
+// Returns the level which is selected (or being played).
+func (screen *playScreen) level() level {
+	return screen.collections[screen.current].levels[screen.selected]
+}
+
+// Starts playing the selected level.
+func (screen *playScreen) start() {
+	playing, err := newGame(screen.level().puzzle)
+	if err != nil {
+		screen.playing, screen.message = nil, fmt.Sprintf("%s: %s", screen.level().path, err)
+		return
+	}
+	screen.playing, screen.message = playing, ""
+}
+
+// Applies the key to the client, returns true when it should exit.
+func (screen *playScreen) handle(key string) bool {
+	if key == "q" || key == terminal.KEY_CTRL_C {
+		return true
+	}
+	if screen.playing == nil {
+		screen.handleSelect(key)
+	} else {
+		screen.handlePlay(key)
+	}
+	return false
+}
+
+func (screen *playScreen) handleSelect(key string) {
+	levels := len(screen.collections[screen.current].levels)
+	switch key {
+	case terminal.KEY_UP, "k":
+		screen.selected = (screen.selected + levels - 1) % levels
+	case terminal.KEY_DOWN, "j":
+		screen.selected = (screen.selected + 1) % levels
+	case terminal.KEY_LEFT, terminal.KEY_RIGHT, "h", "l":
+		step := 1
+		if key == terminal.KEY_LEFT || key == "h" {
+			step = len(screen.collections) - 1
+		}
+		screen.current = (screen.current + step) % len(screen.collections)
+		screen.selected = 0
+	case terminal.KEY_ENTER, " ":
+		screen.start()
+	}
+}
+
+// The ichiban moves with terminal.MOVES, as the full-screen editor's cursor does.
+func (screen *playScreen) handlePlay(key string) {
+	playing := screen.playing
+	screen.message = ""
+	if dir, ok := terminal.MOVES[key]; ok {
+		if playing.solved() {
+			return
+		}
+		if err := playing.move(dir); err != nil {
+			return // (blocked moves are ignored, as in the webapp)
+		}
+		if playing.solved() {
+			screen.message = screen.saveSolution()
+		}
+		return
+	}
+	switch key {
+	case "u", terminal.KEY_CTRL_Z:
+		if !playing.undo() {
+			screen.message = "nothing to undo"
+		}
+	case "U", terminal.KEY_CTRL_R, terminal.KEY_CTRL_Y:
+		if !playing.redo() {
+			screen.message = "nothing to redo"
+		} else if playing.solved() {
+			screen.message = screen.saveSolution()
+		}
+	case "r":
+		playing.restart()
+	case "n", "p":
+		levels := len(screen.collections[screen.current].levels)
+		step := 1
+		if key == "p" {
+			step = levels - 1
+		}
+		screen.selected = (screen.selected + step) % levels
+		screen.start()
+	case "l", terminal.KEY_ESCAPE:
+		screen.playing = nil
+	}
+}
+
+// Returns the path of the level's solution, named after the level's identity.
+func (screen *playScreen) solutionPath(puzzle hexoban.Puzzle) string {
+	return filepath.Join(screen.solutionsDir, filepath.FromSlash(puzzle.Identity)+".txt")
+}
+
+// Writes the solution in hex-LURD notation, unless a solution with fewer moves
+// was already saved.  Returns a message describing the result.
+func (screen *playScreen) saveSolution() string {
+	solution := screen.playing.history()
+	solved := fmt.Sprintf("Solved in %d moves, %d pushes!", solution.Moves(), solution.Pushes())
+	path := screen.solutionPath(screen.playing.puzzle)
+	if filedata, err := os.ReadFile(path); err == nil {
+		if best, err := hexoban.ParseSolution(string(filedata)); err == nil &&
+			best.Moves() <= solution.Moves() {
+			return fmt.Sprintf("%s  (the saved solution has %d moves)", solved, best.Moves())
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Sprintf("%s  (not saved: %s)", solved, err)
+	}
+	if err := os.WriteFile(path, []byte(solution.String()+"\n"), 0644); err != nil {
+		return fmt.Sprintf("%s  (not saved: %s)", solved, err)
+	}
+	return fmt.Sprintf("%s  Saved to %s", solved, path)
+}
+
+// Returns the escape sequences and text for drawing the whole screen.
+func (screen *playScreen) draw() string {
+	var text strings.Builder
+	text.WriteString(terminal.CLEAR)
+	if screen.playing == nil {
+		screen.drawSelect(&text)
+	} else {
+		screen.drawPlay(&text)
+	}
+	return text.String()
+}
+
+func (screen *playScreen) drawSelect(text *strings.Builder) {
+	for index, found := range screen.collections {
+		if index == screen.current {
+			fmt.Fprintf(text, "%s[%s]%s ", terminal.BOLD, found.name, terminal.RESET)
+		} else {
+			fmt.Fprintf(text, " %s  ", found.name)
+		}
+	}
+	text.WriteString("\r\n\r\n")
+
+	levels := screen.collections[screen.current].levels
+	first := max(0, min(screen.selected-SELECT_ROWS/2, len(levels)-SELECT_ROWS))
+	for index := first; index < min(first+SELECT_ROWS, len(levels)); index++ {
+		puzzle := levels[index].puzzle
+		marker, solved := "  ", " "
+		if index == screen.selected {
+			marker = "> "
+		}
+		if _, err := os.Stat(screen.solutionPath(puzzle)); err == nil {
+			solved = "*"
+		}
+		fmt.Fprintf(text, "%s%s %-12s %s", marker, solved, puzzle.Identity, puzzle.Title)
+		if puzzle.Author != "" {
+			fmt.Fprintf(text, " by %s", puzzle.Author)
+		}
+		text.WriteString("\r\n")
+	}
+	fmt.Fprintf(text, "\r\n%s\r\n\r\n%s%s%s", screen.message, terminal.DIM, SELECT_HELP, terminal.RESET)
+}
+
+func (screen *playScreen) drawPlay(text *strings.Builder) {
+	playing := screen.playing
+	levels := screen.collections[screen.current].levels
+	fmt.Fprintf(text, "%s%s%s by %s  (%s, %d of %d)\r\n\r\n", terminal.BOLD,
+		playing.puzzle.Title, terminal.RESET, playing.puzzle.Author,
+		screen.collections[screen.current].name, screen.selected+1, len(levels))
+	board, err := playing.board(screen.render)
+	if err != nil {
+		board = err.Error()
+	}
+	text.WriteString(strings.ReplaceAll(board, "\n", "\r\n"))
+
+	history := playing.history()
+	fmt.Fprintf(text, "\r\n\r\nmoves %d  pushes %d", history.Moves(), history.Pushes())
+	if playing.solved() {
+		text.WriteString("  solved, n: next level")
+	}
+	text.WriteString("\r\n")
+	fmt.Fprintf(text, "%s\r\n\r\n%s%s%s", screen.message, terminal.DIM, PLAY_HELP, terminal.RESET)
+}
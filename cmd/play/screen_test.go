@@ -0,0 +1,123 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/play/screen_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban/internal/terminal"
+)
+
+func testScreen(t *testing.T) *playScreen {
+	playing := testGame(t)
+	other := testGame(t)
+	other.puzzle.Identity = "test/2"
+	return &playScreen{
+		collections: []collection{
+			{"test", []level{{"1.json", playing.puzzle}, {"2.json", other.puzzle}}},
+			{"more", []level{{"3.json", playing.puzzle}}},
+		},
+		solutionsDir: t.TempDir(),
+	}
+}
+
+func TestPlayScreen_Select(t *testing.T) {
+	screen := testScreen(t)
+	for _, key := range []string{terminal.KEY_DOWN, terminal.KEY_DOWN, terminal.KEY_UP} {
+		screen.handle(key)
+	}
+	if screen.selected != 1 {
+		t.Errorf("selected %d, want 1", screen.selected)
+	}
+	screen.handle(terminal.KEY_LEFT)
+	if screen.current != 1 || screen.selected != 0 {
+		t.Errorf("after left, %d/%d", screen.current, screen.selected)
+	}
+	screen.handle(terminal.KEY_RIGHT)
+	screen.handle(terminal.KEY_ENTER)
+	if screen.playing == nil || screen.current != 0 {
+		t.Fatalf("not playing after enter: %q", screen.message)
+	}
+	if !strings.Contains(screen.draw(), "(test, 1 of 2)") {
+		t.Errorf("draw() =\n%s", screen.draw())
+	}
+	screen.handle("n")
+	if screen.selected != 1 || screen.playing.puzzle.Identity != "test/2" {
+		t.Errorf("after next level, %d", screen.selected)
+	}
+	screen.handle("l")
+	if screen.playing != nil || !strings.Contains(screen.draw(), "> ") {
+		t.Errorf("not back at level select")
+	}
+	if !screen.handle("q") {
+		t.Errorf("q did not quit")
+	}
+}
+
+func TestPlayScreen_Solve(t *testing.T) {
+	screen := testScreen(t)
+	screen.handle(terminal.KEY_ENTER)
+	screen.handle("d")
+	screen.handle("d")
+	path := filepath.Join(screen.solutionsDir, "test", "1.txt")
+	if !strings.HasPrefix(screen.message, "Solved in 2 moves, 2 pushes!  Saved to "+path) {
+		t.Errorf("message = %q", screen.message)
+	}
+	if filedata, err := os.ReadFile(path); err != nil || string(filedata) != "RR\n" {
+		t.Errorf("saved solution %q, %v", filedata, err)
+	}
+	for _, key := range []string{"x", "u", "U"} {
+		screen.handle(key)
+	}
+	if !strings.Contains(screen.draw(), "moves 2  pushes 2  solved") {
+		t.Errorf("draw() =\n%s", screen.draw())
+	}
+
+	// A longer solution does not replace the saved one.
+	screen.handle("r")
+	for _, key := range []string{"d", "a", "d", "d"} {
+		screen.handle(key)
+	}
+	if !strings.Contains(screen.message, "(the saved solution has 2 moves)") {
+		t.Errorf("message = %q", screen.message)
+	}
+	if filedata, _ := os.ReadFile(path); string(filedata) != "RR\n" {
+		t.Errorf("saved solution replaced with %q", filedata)
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	collections, err := loadCatalog("../../levels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, found := range collections {
+		count += len(found.levels)
+		for _, level := range found.levels {
+			if _, err := newGame(level.puzzle); err != nil {
+				t.Errorf("%s: %s", level.path, err)
+			}
+		}
+	}
+	if collections[0].name != "DWS" || count < 100 {
+		t.Errorf("%d collections, first %q, %d levels", len(collections), collections[0].name, count)
+	}
+}
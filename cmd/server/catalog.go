@@ -0,0 +1,111 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/catalog.go
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render/svg"
+)
+
+// The levels served by the API, read once at startup and not modified after.
+type catalog struct {
+	levels []*level // by collection and then by file name.
+	byID   map[string]*level
+}
+
+type level struct {
+	puzzle      hexoban.Puzzle
+	collection  string
+	fingerprint string // see hexoban.Puzzle.Fingerprint()
+	revision    string // the fingerprint with a hash of the level's file.
+	image       []byte // the rendered SVG.
+}
+
+// Reads each collection's levels from the subdirectories of the file system,
+// in the order of their file names.  Levels without an identity are given one
+// from their collection and file name, e.g. "DWS/001".
+func loadCatalog(levels fs.FS) (*catalog, error) {
+	entries, err := fs.ReadDir(levels, ".")
+	if err != nil {
+		return nil, err
+	}
+	result := &catalog{byID: make(map[string]*level)}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		paths, err := fs.Glob(levels, entry.Name()+"/*.json")
+		if err != nil {
+			return nil, err
+		}
+		for _, filepath := range paths {
+			level, err := loadLevel(levels, filepath, entry.Name())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filepath, err)
+			}
+			if result.byID[level.puzzle.Identity] != nil {
+				return nil, fmt.Errorf("%s: identity %q is used more than once",
+					filepath, level.puzzle.Identity)
+			}
+			result.byID[level.puzzle.Identity] = level
+			result.levels = append(result.levels, level)
+		}
+	}
+	if len(result.levels) == 0 {
+		return nil, fmt.Errorf("no levels found")
+	}
+	return result, nil
+}
+
+func loadLevel(levels fs.FS, filepath, collection string) (*level, error) {
+	filedata, err := fs.ReadFile(levels, filepath)
+	if err != nil {
+		return nil, err
+	}
+	level := &level{collection: collection}
+	if err := json.Unmarshal(filedata, &level.puzzle); err != nil {
+		return nil, err
+	}
+	if level.puzzle.Identity == "" {
+		level.puzzle.Identity = collection + "/" + strings.TrimSuffix(path.Base(filepath), ".json")
+	}
+	if level.fingerprint, err = level.puzzle.Fingerprint(); err != nil {
+		return nil, err
+	}
+	// The fingerprint does not cover the title, author and other metadata, so
+	// the revision (which the responses' ETags are made from) also hashes the
+	// file and the identity and collection that may have come from its path.
+	digest := sha256.New()
+	fmt.Fprintf(digest, "%s\n%s\n", level.puzzle.Identity, collection)
+	digest.Write(filedata)
+	level.revision = level.fingerprint + "-" + hex.EncodeToString(digest.Sum(nil)[:8])
+	var image bytes.Buffer
+	if err := svg.Encode(&image, level.puzzle, svg.Options{}); err != nil {
+		return nil, err
+	}
+	level.image = image.Bytes()
+	return level, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/journal.go
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// A file of JSON values, one per line, which is only ever appended to.  The
+// stores keep their state in memory and use a journal to restore it.
+type journal struct {
+	path string
+	file *os.File
+}
+
+// Opens the journal at the path, creating the file (and its directory) if it
+// does not exist yet, and calls replay with each line already written.
+//
+// A server stopped while appending may leave the last line incomplete.  That
+// line is removed from the file (and not replayed) so that the next entry
+// begins on a line of its own, as is a last line which fails to replay.  Any
+// other line which fails to replay is an error.
+func openJournal(path string, replay func(line []byte) error) (*journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(file)
+	var replayed int64 // the length of the lines replayed.
+	for line := 1; ; line++ {
+		text, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(text) == 0 {
+			break
+		} else if err != nil && err != io.EOF {
+			file.Close()
+			return nil, err
+		}
+		if err == io.EOF {
+			err = fmt.Errorf("the line is incomplete")
+		} else if err = replay(bytes.TrimSuffix(text, []byte("\n"))); err == nil {
+			replayed += int64(len(text))
+			continue
+		}
+		if _, peekErr := reader.Peek(1); peekErr != io.EOF {
+			file.Close()
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		log.Printf("%s line %d: removing the last line: %v", path, line, err)
+		if err := file.Truncate(replayed); err != nil {
+			file.Close()
+			return nil, err
+		}
+		break
+	}
+	return &journal{path: path, file: file}, nil
+}
+
+// Writes the entry as a line of JSON, returning once it is on disk.
+func (journal *journal) append(entry any) error {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := journal.file.Write(append(encoded, '\n')); err != nil {
+		return err
+	}
+	return journal.file.Sync()
+}
+
+func (journal *journal) Close() error {
+	return journal.file.Close()
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/journal_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenJournal(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		replayed []int
+		kept     string // the file after opening, if it was opened.
+		wantErr  bool
+	}{
+		{"empty", "", nil, "", false},
+		{"complete", "1\n2\n", []int{1, 2}, "1\n2\n", false},
+		{"cut off while appending", "1\n2\n3", []int{1, 2}, "1\n2\n", false},
+		{"bad last line", "1\n2\n{\"x\n", []int{1, 2}, "1\n2\n", false},
+		{"bad line in the middle", "1\n{\"x\n3\n", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data", "journal.jsonl")
+			if tt.contents != "" {
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte(tt.contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var replayed []int
+			journal, err := openJournal(path, func(line []byte) error {
+				var value int
+				if err := json.Unmarshal(line, &value); err != nil {
+					return err
+				}
+				replayed = append(replayed, value)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openJournal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(replayed, tt.replayed) {
+				t.Errorf("replayed %v, expected %v", replayed, tt.replayed)
+			}
+
+			// The next entry is appended on a line of its own.
+			if err := journal.append(4); err != nil {
+				t.Fatal(err)
+			}
+			journal.Close()
+			filedata, _ := os.ReadFile(path)
+			if string(filedata) != tt.kept+"4\n" {
+				t.Errorf("journal is %q, expected %q", filedata, tt.kept+"4\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/main.go
+
+package main
+
+// Main entry point for server.exe
+//
+// Serves the level collections over HTTP for the webapp, see server.go for the
+// API's routes.  The levels are read once at startup, nothing is fetched from
+// elsewhere while running.  Verified solutions and the sessions in
+// progress are kept in local files.
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "the address to listen on")
+	levelsDir := flag.String("levels", "levels", "the directory of level collections")
+	solutionsPath := flag.String("solutions", "data/solutions.jsonl", "the file to keep verified solutions in")
+	sessionsPath := flag.String("sessions", "data/sessions.jsonl", "the journal of the sessions in progress")
+	solvers := flag.Int("solvers", max(runtime.NumCPU()/2, 1), "how many solver jobs may run at once")
+	solveTime := flag.Duration("solve-time", time.Minute, "how long each solver job may run")
+	grace := flag.Duration("grace", 10*time.Second, "how long to wait for requests when shutting down")
+	flag.Parse()
+
+	catalog, err := loadCatalog(os.DirFS(*levelsDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *levelsDir, err)
+		os.Exit(1)
+	}
+	solutions, err := openSolutionStore(*solutionsPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer solutions.Close()
+	sessions, err := openSessionStore(*sessionsPath, catalog)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer sessions.Close()
+	pool := newSolverPool(*solvers, *solveTime)
+	defer pool.Close()
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	log.Printf("serving %d levels on http://%s/api/puzzles", len(catalog.levels), listener.Addr())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	httpServer := &http.Server{Handler: newServer(catalog, solutions, sessions, pool),
+		ReadHeaderTimeout: 10 * time.Second}
+	// Event streams last as long as their jobs, which are stopped so that
+	// shutting down need not wait for them.
+	httpServer.RegisterOnShutdown(pool.Close)
+	if err := serve(ctx, httpServer, listener, *grace); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	log.Print("shut down")
+}
+
+// Serves requests until the context is done, then stops accepting connections
+// and waits up to the grace period for the requests in progress to finish.
+func serve(ctx context.Context, server *http.Server, listener net.Listener, grace time.Duration) error {
+	serving := make(chan error, 1)
+	go func() { serving <- server.Serve(listener) }()
+
+	select {
+	case err := <-serving:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		return err
+	}
+	if err := <-serving; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
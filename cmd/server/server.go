@@ -0,0 +1,586 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/server.go
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// Serves the catalog's levels as JSON and SVG:
+//
+//	GET  /api/puzzles                  summaries of the levels, see filterOf()
+//	GET  /api/puzzles/{id}             the level's puzzle definition
+//	GET  /api/puzzles/{id}.svg         the level rendered as an image
+//	GET  /api/puzzles/{id}/solutions   the level's records board
+//	POST /api/puzzles/{id}/solutions   verifies and stores a solution
+//	POST /api/sessions                 starts playing a level, see createSession()
+//	GET  /api/sessions/{sid}           the session's current state
+//	POST /api/sessions/{sid}/moves     takes steps, see moveSession()
+//	POST /api/sessions/{sid}/undo      reverts steps, see undoSession()
+//	POST /api/solve                    starts a solver job, see startSolver()
+//	GET  /api/solve/{jid}              the job's status and latest progress
+//	GET  /api/solve/{jid}/events       the same as a stream of server-sent events
+//	DELETE /api/solve/{jid}            cancels the job
+//
+// Identities contain a slash (e.g. "DWS/001") and are matched after the path
+// has been unescaped, so "Heloban/The arm" may be requested as
+// /api/puzzles/Heloban/The%20arm.  The puzzles' responses carry an ETag
+// derived from the levels' revisions and honor If-None-Match.
+type server struct {
+	catalog   *catalog
+	solutions *solutionStore
+	sessions  *sessionStore
+	solvers   *solverPool
+	mux       *http.ServeMux
+}
+
+func newServer(catalog *catalog, solutions *solutionStore, sessions *sessionStore,
+	solvers *solverPool) *server {
+	server := &server{catalog: catalog, solutions: solutions, sessions: sessions,
+		solvers: solvers, mux: http.NewServeMux()}
+	server.mux.HandleFunc("/api/puzzles", server.listPuzzles)
+	server.mux.HandleFunc("/api/puzzles/", server.routePuzzle)
+	server.mux.HandleFunc("/api/sessions", server.createSession)
+	server.mux.HandleFunc("/api/sessions/", server.routeSession)
+	server.mux.HandleFunc("/api/solve", server.startSolver)
+	server.mux.HandleFunc("/api/solve/", server.routeSolver)
+	return server
+}
+
+func (server *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The webapp is served from elsewhere (or from its dev server).
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	server.mux.ServeHTTP(w, r)
+}
+
+// The fields of a level that are listed by GET /api/puzzles.
+type puzzleSummary struct {
+	Identity    string `json:"id"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Collection  string `json:"collection"`
+	Difficulty  int    `json:"difficulty,omitempty"`
+	Hexes       int    `json:"hexes"`
+	Crates      int    `json:"crates"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// The response of GET /api/puzzles/{id}, the puzzle with where it was found.
+type puzzleDetail struct {
+	hexoban.Puzzle
+	Collection  string `json:"collection"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+func (level *level) summary() puzzleSummary {
+	return puzzleSummary{
+		Identity:    level.puzzle.Identity,
+		Title:       level.puzzle.Title,
+		Author:      level.puzzle.Author,
+		Collection:  level.collection,
+		Difficulty:  level.puzzle.Difficulty,
+		Hexes:       len(level.puzzle.Terrain),
+		Crates:      len(level.puzzle.Init.Crates),
+		Fingerprint: level.fingerprint,
+	}
+}
+
+func (server *server) listPuzzles(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	filter, err := filterOf(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	summaries := []puzzleSummary{}
+	digest := sha256.New()
+	for _, level := range server.catalog.levels {
+		if filter(level) {
+			summaries = append(summaries, level.summary())
+			fmt.Fprintf(digest, "%s %s\n", level.puzzle.Identity, level.revision)
+		}
+	}
+	if notModified(w, r, hex.EncodeToString(digest.Sum(nil)[:16])) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(summaries), "puzzles": summaries})
+}
+
+// Finds the level for the routes beneath /api/puzzles/, by its identity and
+// the suffix after it.
+func (server *server) routePuzzle(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/puzzles/")
+	if id, found := strings.CutSuffix(id, "/solutions"); found {
+		if level := server.findLevel(w, id); level != nil {
+			if r.Method == http.MethodPost {
+				server.submitSolution(w, r, level)
+			} else if allowMethods(w, r, http.MethodGet, http.MethodHead, http.MethodPost) {
+				writeJSON(w, http.StatusOK, server.solutions.Records(id, level.fingerprint))
+			}
+		}
+		return
+	}
+	id, isImage := strings.CutSuffix(id, ".svg")
+	if level := server.findLevel(w, id); level != nil &&
+		allowMethods(w, r, http.MethodGet, http.MethodHead) {
+		server.getPuzzle(w, r, level, isImage)
+	}
+}
+
+// Returns the level with the identity, or writes a 404 status and returns nil.
+func (server *server) findLevel(w http.ResponseWriter, id string) *level {
+	level := server.catalog.byID[id]
+	if level == nil {
+		writeError(w, http.StatusNotFound, "no puzzle with id %q", id)
+	}
+	return level
+}
+
+func (server *server) getPuzzle(w http.ResponseWriter, r *http.Request, level *level, isImage bool) {
+	if isImage {
+		if notModified(w, r, level.revision+".svg") {
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write(level.image)
+		return
+	}
+	if notModified(w, r, level.revision+".json") {
+		return
+	}
+	writeJSON(w, http.StatusOK, puzzleDetail{level.puzzle, level.collection, level.fingerprint})
+}
+
+// The body of POST /api/puzzles/{id}/solutions.
+type submission struct {
+	Solution string `json:"solution"` // in hex-LURD notation.
+	Player   string `json:"player"`   // optional, whom to credit.
+}
+
+// Replays the submitted solution from the level's initial state.  A solution
+// with an illegal step is rejected with the step's (1-based) index and a valid
+// one which does not solve the level is also rejected, both as 422 errors.
+// Solutions are stored once, later submissions of the same one are answered
+// with 200 rather than 201 and do not change the records.
+func (server *server) submitSolution(w http.ResponseWriter, r *http.Request, level *level) {
+	var body submission
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	solution, err := hexoban.ParseSolution(body.Solution)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid solution: %v", err)
+		return
+	}
+	state, err := hexoban.NewState(level.puzzle)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	var stepErr *hexoban.StepError
+	if err := state.Apply(solution); errors.As(err, &stepErr) {
+		writeJSON(w, http.StatusUnprocessableEntity,
+			map[string]any{"error": stepErr.Error(), "step": stepErr.Index})
+		return
+	} else if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "%v", err)
+		return
+	}
+	if !state.IsSolved() {
+		writeError(w, http.StatusUnprocessableEntity, "the solution does not solve the puzzle")
+		return
+	}
+
+	record := solutionRecord{
+		Level:       level.puzzle.Identity,
+		Fingerprint: level.fingerprint,
+		Solution:    solution.String(),
+		Moves:       solution.Moves(),
+		Pushes:      solution.Pushes(),
+		Player:      strings.TrimSpace(body.Player),
+		Submitted:   time.Now().UTC(),
+	}
+	added, set, err := server.solutions.Add(record)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storing the solution: %v", err)
+		return
+	}
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]any{
+		"moves":   record.Moves,
+		"pushes":  record.Pushes,
+		"records": set,
+		"board":   server.solutions.Records(record.Level, record.Fingerprint),
+	})
+}
+
+// Starts a session for the level named by the body, {"level": "<id>"}.
+func (server *server) createSession(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var body struct {
+		Level string `json:"level"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	level := server.findLevel(w, body.Level)
+	if level == nil {
+		return
+	}
+	view, err := server.sessions.Create(level)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "creating the session: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, view)
+}
+
+func (server *server) routeSession(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/sessions/"), "/")
+	switch action {
+	case "":
+		if allowMethods(w, r, http.MethodGet, http.MethodHead) {
+			view, found := server.sessions.Get(id)
+			if !found {
+				writeError(w, http.StatusNotFound, "no session with id %q", id)
+				return
+			}
+			writeJSON(w, http.StatusOK, view)
+		}
+	case "moves":
+		if allowMethods(w, r, http.MethodPost) {
+			server.moveSession(w, r, id)
+		}
+	case "undo":
+		if allowMethods(w, r, http.MethodPost) {
+			server.undoSession(w, r, id)
+		}
+	default:
+		writeError(w, http.StatusNotFound, "unknown session action %q", action)
+	}
+}
+
+// Takes the steps in the body, {"moves": "udr"}, in hex-LURD notation but where
+// the case of each letter is ignored, the server decides which steps push.  If
+// any step is illegal then none are taken, the 422 error names the step.
+func (server *server) moveSession(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		Moves string `json:"moves"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	directions, err := hexoban.ParseSolution(body.Moves)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid moves: %v", err)
+		return
+	}
+	view, err := server.sessions.Move(id, directions)
+	server.writeSession(w, id, view, err)
+}
+
+// Reverts the most recent step, or the number of steps in the body if there
+// is one, e.g. {"count": 5}.  Undoing more steps than were taken returns the
+// session to the level's initial state.
+func (server *server) undoSession(w http.ResponseWriter, r *http.Request, id string) {
+	body := struct {
+		Count int `json:"count"`
+	}{Count: 1}
+	if r.ContentLength != 0 && !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Count < 0 {
+		writeError(w, http.StatusBadRequest, "cannot undo %d steps", body.Count)
+		return
+	}
+	view, err := server.sessions.Undo(id, body.Count)
+	server.writeSession(w, id, view, err)
+}
+
+func (server *server) writeSession(w http.ResponseWriter, id string, view sessionView, err error) {
+	var stepErr *hexoban.StepError
+	switch {
+	case errors.Is(err, errNoSession):
+		writeError(w, http.StatusNotFound, "no session with id %q", id)
+	case errors.As(err, &stepErr):
+		writeJSON(w, http.StatusUnprocessableEntity,
+			map[string]any{"error": stepErr.Error(), "step": stepErr.Index})
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "%v", err)
+	default:
+		writeJSON(w, http.StatusOK, view)
+	}
+}
+
+// Starts searching for a solution of a level, given as
+// {"level": "<id>", "advisor": "weighted", "limit": 100000} where the advisor
+// (see ADVISORS) and the limit on the positions expanded are optional.  The
+// number of jobs running at once is limited, beyond that a 503 is returned.
+func (server *server) startSolver(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	body := struct {
+		Level   string `json:"level"`
+		Advisor string `json:"advisor"`
+		Limit   int    `json:"limit"`
+	}{Advisor: "weighted"}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if ADVISORS[body.Advisor] == nil || body.Limit < 0 {
+		writeError(w, http.StatusBadRequest, "unknown advisor %q or invalid limit %d", body.Advisor, body.Limit)
+		return
+	}
+	level := server.findLevel(w, body.Level)
+	if level == nil {
+		return
+	}
+	view, err := server.solvers.Start(level, body.Advisor, body.Limit)
+	if errors.Is(err, errTooManyJobs) {
+		w.Header().Set("Retry-After", "10")
+		writeError(w, http.StatusServiceUnavailable, "%v", err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	w.Header().Set("Location", "/api/solve/"+view.Identity)
+	writeJSON(w, http.StatusAccepted, view)
+}
+
+func (server *server) routeSolver(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/solve/"), "/")
+	job := server.solvers.Job(id)
+	if job == nil {
+		writeError(w, http.StatusNotFound, "no solver job with id %q", id)
+		return
+	}
+	switch action {
+	case "":
+		if r.Method == http.MethodDelete {
+			job.Cancel()
+			view, _ := job.Watch()
+			writeJSON(w, http.StatusAccepted, view)
+		} else if allowMethods(w, r, http.MethodGet, http.MethodHead, http.MethodDelete) {
+			view, _ := job.Watch()
+			writeJSON(w, http.StatusOK, view)
+		}
+	case "events":
+		if allowMethods(w, r, http.MethodGet) {
+			streamJob(w, r, job)
+		}
+	default:
+		writeError(w, http.StatusNotFound, "unknown solver action %q", action)
+	}
+}
+
+// The least time between the progress events of a stream, a job reports its
+// progress more often than is useful to watch.
+const EVENT_INTERVAL = 200 * time.Millisecond
+
+// Streams the job's view as server-sent events, a "progress" event when the
+// stream begins and as the search goes on, then a "done" event once the job
+// has ended, after which the stream is closed.
+func streamJob(w http.ResponseWriter, r *http.Request, job *solveJob) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for {
+		view, changed := job.Watch()
+		event := "progress"
+		if changed == nil {
+			event = "done"
+		}
+		encoded, _ := json.Marshal(view)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+		flusher.Flush()
+		if changed == nil {
+			return
+		}
+
+		wait := time.NewTimer(EVENT_INTERVAL)
+		select {
+		case <-wait.C:
+		case <-r.Context().Done():
+			wait.Stop()
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// The largest request body accepted.
+const MAX_BODY_BYTES = 1 << 20
+
+// Decodes the request's JSON body into value, or writes a 400 status and
+// returns false if it could not.
+func decodeBody(w http.ResponseWriter, r *http.Request, value any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MAX_BODY_BYTES))
+	if err := decoder.Decode(value); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return false
+	}
+	return true
+}
+
+// Returns the predicate for the query's filters, each of which is optional:
+//
+//	author=David W. Skinner  the author's name, ignoring case
+//	collection=DWS           the collection's name, ignoring case
+//	difficulty=3-5           the difficulty rating, see rangeOf()
+//	size=20-                 the number of hexes in the terrain
+//
+// Levels without a difficulty rating are excluded by any difficulty filter.
+func filterOf(r *http.Request) (func(*level) bool, error) {
+	query := r.URL.Query()
+	author, collection := query.Get("author"), query.Get("collection")
+	difficulty, err := rangeOf(query.Get("difficulty"))
+	if err != nil {
+		return nil, fmt.Errorf("difficulty: %w", err)
+	}
+	size, err := rangeOf(query.Get("size"))
+	if err != nil {
+		return nil, fmt.Errorf("size: %w", err)
+	}
+	return func(level *level) bool {
+		return (author == "" || strings.EqualFold(author, level.puzzle.Author)) &&
+			(collection == "" || strings.EqualFold(collection, level.collection)) &&
+			difficulty.contains(level.puzzle.Difficulty) &&
+			size.contains(len(level.puzzle.Terrain))
+	}, nil
+}
+
+// An inclusive range of values, where the zero value includes everything.
+type valueRange struct {
+	low, high int
+	bounded   bool
+}
+
+// Parses "N" as exactly N, "N-M" as from N to M, "N-" as at least N and "-M"
+// as at most M (but more than zero).  An empty string imposes no bounds.
+func rangeOf(text string) (valueRange, error) {
+	if text == "" {
+		return valueRange{}, nil
+	}
+	low, high, isRange := strings.Cut(text, "-")
+	if !isRange {
+		high = low
+	}
+	result := valueRange{low: 1, high: math.MaxInt, bounded: true}
+	var err error
+	if low != "" {
+		if result.low, err = strconv.Atoi(low); err != nil {
+			return valueRange{}, fmt.Errorf("invalid range %q", text)
+		}
+	}
+	if high != "" {
+		if result.high, err = strconv.Atoi(high); err != nil {
+			return valueRange{}, fmt.Errorf("invalid range %q", text)
+		}
+	}
+	if result.low > result.high || (low == "" && high == "") {
+		return valueRange{}, fmt.Errorf("invalid range %q", text)
+	}
+	return result, nil
+}
+
+func (bounds valueRange) contains(value int) bool {
+	return !bounds.bounded || (bounds.low <= value && value <= bounds.high)
+}
+
+// Writes the Allow header and a 405 status if the request's method is not one
+// of those allowed, returning false in that case.
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	return false
+}
+
+// Sets the response's ETag and, if the request's If-None-Match already has
+// it, writes a 304 status and returns true.  Clients are asked to revalidate
+// each time, which only costs them the request while nothing has changed.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	etag := `"` + tag + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(encoded, '\n'))
+}
+
+// Errors are reported as a JSON object with an "error" message.
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	encoded, _ := json.Marshal(map[string]string{"error": fmt.Sprintf(format, args...)})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(encoded, '\n'))
+}
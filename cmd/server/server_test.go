@@ -0,0 +1,575 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/server_test.go
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const TREASURE_ROOM = `"terrain": [[1, 2], [1, 3], [2, 2], [2, 3], [2, 4]],
+  "init": {"goals": [[2, 4]], "crates": [[2, 3]], "ichiban": [2, 2]}`
+
+const TREASURE_HALL = `"terrain": [[1, 2], [1, 3], [2, 2], [2, 3], [2, 4], [2, 5], [2, 6]],
+  "init": {"goals": [[2, 6]], "crates": [[2, 3]], "ichiban": [2, 2]}`
+
+// Three small levels in two collections, the last without an identity.
+func testCatalog(t *testing.T) *catalog {
+	t.Helper()
+	levels := fstest.MapFS{
+		"first/01.json": {Data: []byte(`{"id": "first/01", "title": "Room",
+  "author": "Ann Author", "difficulty": 2, ` + TREASURE_ROOM + `}`)},
+		"first/02.json": {Data: []byte(`{"id": "first/02", "title": "Hall",
+  "author": "Bo Builder", ` + TREASURE_HALL + `}`)},
+		"second/The room.json": {Data: []byte(`{"title": "Room again",
+  "author": "ann author", "difficulty": 5, ` + TREASURE_ROOM + `}`)},
+		"README.md": {Data: []byte("not a collection")},
+	}
+	catalog, err := loadCatalog(levels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return catalog
+}
+
+// A server for the test catalog, with empty stores in a temporary directory.
+func testServer(t *testing.T) *server {
+	t.Helper()
+	catalog := testCatalog(t)
+	pool := newSolverPool(2, 10*time.Second)
+	t.Cleanup(pool.Close)
+	return newServer(catalog, testStore(t),
+		testSessions(t, catalog, filepath.Join(t.TempDir(), "sessions.jsonl")), pool)
+}
+
+// An empty store in the test's temporary directory.
+func testStore(t *testing.T) *solutionStore {
+	t.Helper()
+	store, err := openSolutionStore(filepath.Join(t.TempDir(), "solutions.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// The sessions journaled at the path, which is created if it does not exist.
+func testSessions(t *testing.T, catalog *catalog, path string) *sessionStore {
+	t.Helper()
+	store, err := openSessionStore(path, catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestLoadCatalog(t *testing.T) {
+	catalog, err := loadCatalog(os.DirFS("../../levels"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := filepath.Glob("../../levels/*/*.json")
+	if len(catalog.levels) != len(paths) {
+		t.Errorf("loaded %d levels, expected %d", len(catalog.levels), len(paths))
+	}
+	if catalog.byID["Heloban/The arm"] == nil {
+		t.Error(`expected a level with id "Heloban/The arm"`)
+	}
+
+	duplicated := fstest.MapFS{
+		"a/1.json": {Data: []byte(`{"id": "x", ` + TREASURE_ROOM + `}`)},
+		"b/1.json": {Data: []byte(`{"id": "x", ` + TREASURE_HALL + `}`)},
+	}
+	if _, err := loadCatalog(duplicated); err == nil {
+		t.Error("expected an error for a repeated identity")
+	}
+	if _, err := loadCatalog(fstest.MapFS{}); err == nil {
+		t.Error("expected an error when there are no levels")
+	}
+}
+
+func TestServer_ListPuzzles(t *testing.T) {
+	handler := testServer(t)
+	tests := []struct {
+		query  string
+		status int
+		expect []string // the ids listed.
+	}{
+		{"", http.StatusOK, []string{"first/01", "first/02", "second/The room"}},
+		{"?author=ANN+author", http.StatusOK, []string{"first/01", "second/The room"}},
+		{"?collection=First", http.StatusOK, []string{"first/01", "first/02"}},
+		{"?difficulty=2", http.StatusOK, []string{"first/01"}},
+		{"?difficulty=3-", http.StatusOK, []string{"second/The room"}},
+		{"?difficulty=-9", http.StatusOK, []string{"first/01", "second/The room"}},
+		{"?size=6-", http.StatusOK, []string{"first/02"}},
+		{"?size=5&collection=second", http.StatusOK, []string{"second/The room"}},
+		{"?author=nobody", http.StatusOK, []string{}},
+		{"?difficulty=hard", http.StatusBadRequest, nil},
+		{"?size=9-3", http.StatusBadRequest, nil},
+		{"?size=-", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/puzzles"+tt.query, nil))
+			if recorder.Code != tt.status {
+				t.Fatalf("status %d, expected %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+			if tt.expect == nil {
+				return
+			}
+			var response struct {
+				Count   int             `json:"count"`
+				Puzzles []puzzleSummary `json:"puzzles"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, summary := range response.Puzzles {
+				got = append(got, summary.Identity)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expect, ",") || response.Count != len(got) {
+				t.Errorf("listed %d %v, expected %v", response.Count, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestServer_GetPuzzle(t *testing.T) {
+	handler := testServer(t)
+	catalog := handler.catalog
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/api/puzzles/second/The%20room")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var detail puzzleDetail
+	if err := json.NewDecoder(response.Body).Decode(&detail); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if detail.Title != "Room again" || detail.Collection != "second" || len(detail.Terrain) != 5 {
+		t.Errorf("unexpected puzzle %+v", detail)
+	}
+	// The same layout as first/01, so the same fingerprint.
+	if detail.Fingerprint != catalog.byID["first/01"].fingerprint {
+		t.Errorf("fingerprint %s, expected %s", detail.Fingerprint, catalog.byID["first/01"].fingerprint)
+	}
+
+	response, err = http.Get(server.URL + "/api/puzzles/first/01.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "image/svg+xml" {
+		t.Errorf("svg: status %d, content type %q", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+
+	for path, status := range map[string]int{
+		"/api/puzzles/first/03":     http.StatusNotFound,
+		"/api/puzzles/first/03.svg": http.StatusNotFound,
+		"/api/levels":               http.StatusNotFound,
+	} {
+		response, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != status {
+			t.Errorf("%s: status %d, expected %d", path, response.StatusCode, status)
+		}
+	}
+	response, err = http.Post(server.URL+"/api/puzzles", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed || response.Header.Get("Allow") != "GET, HEAD" {
+		t.Errorf("POST: status %d, Allow %q", response.StatusCode, response.Header.Get("Allow"))
+	}
+}
+
+func TestServer_ETag(t *testing.T) {
+	handler := testServer(t)
+	for _, path := range []string{"/api/puzzles", "/api/puzzles?author=bo+builder",
+		"/api/puzzles/first/01", "/api/puzzles/first/01.svg"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		etag := recorder.Header().Get("ETag")
+		if recorder.Code != http.StatusOK || etag == "" {
+			t.Fatalf("%s: status %d, ETag %q", path, recorder.Code, etag)
+		}
+
+		for match, status := range map[string]int{
+			etag:               http.StatusNotModified,
+			`"other", ` + etag: http.StatusNotModified,
+			"W/" + etag:        http.StatusNotModified,
+			`"other"`:          http.StatusOK,
+		} {
+			request := httptest.NewRequest("GET", path, nil)
+			request.Header.Set("If-None-Match", match)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != status {
+				t.Errorf("%s with If-None-Match %s: status %d, expected %d",
+					path, match, recorder.Code, status)
+			}
+			if status == http.StatusNotModified && recorder.Body.Len() > 0 {
+				t.Errorf("%s: not modified but has a body", path)
+			}
+		}
+	}
+
+	// Filters that list different puzzles have different tags.
+	tags := make(map[string]bool)
+	for _, query := range []string{"", "?collection=first", "?collection=second"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/puzzles"+query, nil))
+		tags[recorder.Header().Get("ETag")] = true
+	}
+	if len(tags) != 3 {
+		t.Errorf("expected distinct ETags for each listing, got %v", tags)
+	}
+}
+
+// Editing a level's title keeps its fingerprint but changes what is served,
+// so each of its responses must have a different tag after a restart.
+func TestServer_ETagMetadata(t *testing.T) {
+	tagsFor := func(title string) map[string]string {
+		catalog, err := loadCatalog(fstest.MapFS{"first/01.json": {Data: []byte(
+			`{"id": "first/01", "title": "` + title + `", ` + TREASURE_ROOM + `}`)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler := newServer(catalog, nil, nil, nil)
+		tags := make(map[string]string)
+		for _, path := range []string{"/api/puzzles", "/api/puzzles/first/01", "/api/puzzles/first/01.svg"} {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+			tags[path] = recorder.Header().Get("ETag")
+		}
+		return tags
+	}
+	before, after := tagsFor("Room"), tagsFor("The Room")
+	for path, tag := range before {
+		if tag == "" || tag == after[path] {
+			t.Errorf("%s: ETag %q before renaming and %q after", path, tag, after[path])
+		}
+	}
+}
+
+func TestServe_Shutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	httpServer := &http.Server{Handler: testServer(t)}
+	done := make(chan error, 1)
+	go func() { done <- serve(ctx, httpServer, listener, time.Second) }()
+
+	response, err := http.Get("http://" + listener.Addr().String() + "/api/puzzles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serve() = %v, expected a clean shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve() did not return after the context was done")
+	}
+	if _, err := http.Get("http://" + listener.Addr().String() + "/api/puzzles"); err == nil {
+		t.Error("expected no connections after shutting down")
+	}
+}
+
+func TestServer_SubmitSolution(t *testing.T) {
+	server := httptest.NewServer(testServer(t))
+	defer server.Close()
+	post := func(path, body string) (int, map[string]any) {
+		t.Helper()
+		response, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		var result map[string]any
+		if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return response.StatusCode, result
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		expect map[string]any // a subset of the response's fields.
+	}{
+		{"solved", "/api/puzzles/first/01/solutions", `{"solution": "udR"}`,
+			http.StatusCreated, map[string]any{"moves": 3.0, "pushes": 1.0}},
+		{"fewer moves", "/api/puzzles/first/01/solutions", `{"solution": "R", "player": " Ann "}`,
+			http.StatusCreated, map[string]any{"moves": 1.0}},
+		{"again", "/api/puzzles/first/01/solutions", `{"solution": "R"}`,
+			http.StatusOK, map[string]any{"moves": 1.0}},
+		{"illegal step", "/api/puzzles/first/02/solutions", `{"solution": "RRRr"}`,
+			http.StatusUnprocessableEntity, map[string]any{"step": 4.0}},
+		{"casing", "/api/puzzles/first/02/solutions", `{"solution": "Rrr"}`,
+			http.StatusUnprocessableEntity, map[string]any{"step": 2.0}},
+		{"unsolved", "/api/puzzles/first/02/solutions", `{"solution": "RR"}`,
+			http.StatusUnprocessableEntity, map[string]any{"error": "the solution does not solve the puzzle"}},
+		{"not lurd", "/api/puzzles/first/02/solutions", `{"solution": "RRX"}`,
+			http.StatusBadRequest, nil},
+		{"not json", "/api/puzzles/first/02/solutions", `RRR`, http.StatusBadRequest, nil},
+		{"no level", "/api/puzzles/first/03/solutions", `{"solution": "R"}`, http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, result := post(tt.path, tt.body)
+			if status != tt.status {
+				t.Fatalf("status %d, expected %d: %v", status, tt.status, result)
+			}
+			for field, value := range tt.expect {
+				if result[field] != value {
+					t.Errorf("%s = %v, expected %v", field, result[field], value)
+				}
+			}
+		})
+	}
+
+	response, err := http.Get(server.URL + "/api/puzzles/first/01/solutions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var records levelRecords
+	if err := json.NewDecoder(response.Body).Decode(&records); err != nil {
+		t.Fatal(err)
+	}
+	if records.Solutions != 2 || records.Best.Moves.Solution != "R" || records.Best.Pushes.Solution != "R" {
+		t.Errorf("records board %+v, best moves %+v", records, records.Best.Moves)
+	}
+	if records.Best.Moves.Player != "Ann" {
+		t.Errorf("best by moves credited to %q, expected Ann", records.Best.Moves.Player)
+	}
+}
+
+// The webapp's submissions are JSON, so browsers ask before posting them.
+func TestServer_Preflight(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("OPTIONS", "/api/puzzles/first/01/solutions", nil)
+	testServer(t).ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusNoContent ||
+		recorder.Header().Get("Access-Control-Allow-Headers") != "Content-Type" ||
+		!strings.Contains(recorder.Header().Get("Access-Control-Allow-Methods"), "POST") {
+		t.Errorf("OPTIONS: status %d, headers %v", recorder.Code, recorder.Header())
+	}
+}
+
+func TestServer_Sessions(t *testing.T) {
+	server := httptest.NewServer(testServer(t))
+	defer server.Close()
+	request := func(method, path, body string) (int, sessionView, map[string]any) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		var encoded json.RawMessage
+		if err := json.NewDecoder(response.Body).Decode(&encoded); err != nil {
+			t.Fatal(err)
+		}
+		var view sessionView
+		var fields map[string]any
+		json.Unmarshal(encoded, &view)
+		json.Unmarshal(encoded, &fields)
+		return response.StatusCode, view, fields
+	}
+
+	status, created, _ := request("POST", "/api/sessions", `{"level": "first/01"}`)
+	if status != http.StatusCreated || created.Level != "first/01" || created.History != "" {
+		t.Fatalf("creating a session: status %d, %+v", status, created)
+	}
+	path := "/api/sessions/" + created.Identity
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		status  int
+		history string
+		solved  bool
+	}{
+		{"fetch", "GET", path, "", http.StatusOK, "", false},
+		{"walk", "POST", path + "/moves", `{"moves": "UD"}`, http.StatusOK, "ud", false},
+		{"blocked", "POST", path + "/moves", `{"moves": "ul"}`, http.StatusUnprocessableEntity, "", false},
+		{"push", "POST", path + "/moves", `{"moves": "r"}`, http.StatusOK, "udR", true},
+		{"undo", "POST", path + "/undo", "", http.StatusOK, "ud", false},
+		{"undo two", "POST", path + "/undo", `{"count": 2}`, http.StatusOK, "", false},
+		{"negative undo", "POST", path + "/undo", `{"count": -1}`, http.StatusBadRequest, "", false},
+		{"bad moves", "POST", path + "/moves", `{"moves": "rx"}`, http.StatusBadRequest, "", false},
+		{"moves by GET", "GET", path + "/moves", "", http.StatusMethodNotAllowed, "", false},
+		{"unknown action", "POST", path + "/redo", "", http.StatusNotFound, "", false},
+		{"unknown session", "GET", "/api/sessions/0123", "", http.StatusNotFound, "", false},
+		{"unknown level", "POST", "/api/sessions", `{"level": "first/03"}`, http.StatusNotFound, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, view, fields := request(tt.method, tt.path, tt.body)
+			if status != tt.status {
+				t.Fatalf("status %d, expected %d: %v", status, tt.status, fields)
+			}
+			if status == http.StatusUnprocessableEntity && fields["step"] != 2.0 {
+				t.Errorf("step = %v, expected 2", fields["step"])
+			}
+			if status == http.StatusOK && (view.History != tt.history || view.Solved != tt.solved) {
+				t.Errorf("history %q solved %t, expected %q %t", view.History, view.Solved, tt.history, tt.solved)
+			}
+		})
+	}
+}
+
+func TestServer_Solve(t *testing.T) {
+	large, err := os.ReadFile("../../levels/Heroban/The big trefle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := loadCatalog(fstest.MapFS{
+		"small/room.json":   {Data: []byte(`{"id": "room", ` + TREASURE_ROOM + `}`)},
+		"large/trefle.json": {Data: large},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := newSolverPool(1, 10*time.Second)
+	defer pool.Close()
+	server := httptest.NewServer(newServer(catalog, testStore(t),
+		testSessions(t, catalog, filepath.Join(t.TempDir(), "sessions.jsonl")), pool))
+	defer server.Close()
+
+	start := func(body string) (int, jobView) {
+		t.Helper()
+		response, err := http.Post(server.URL+"/api/solve", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		var view jobView
+		json.NewDecoder(response.Body).Decode(&view)
+		return response.StatusCode, view
+	}
+	// Reads the events of the job's stream until it is closed.
+	events := func(id string) (names []string, last jobView) {
+		t.Helper()
+		response, err := http.Get(server.URL + "/api/solve/" + id + "/events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		if response.Header.Get("Content-Type") != "text/event-stream" {
+			t.Errorf("events have content type %q", response.Header.Get("Content-Type"))
+		}
+		scanner := bufio.NewScanner(response.Body)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			if name, found := strings.CutPrefix(scanner.Text(), "event: "); found {
+				names = append(names, name)
+			} else if data, found := strings.CutPrefix(scanner.Text(), "data: "); found {
+				if err := json.Unmarshal([]byte(data), &last); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		return names, last
+	}
+
+	status, view := start(`{"level": "room", "advisor": "astar"}`)
+	if status != http.StatusAccepted || view.Status != JOB_RUNNING || view.Advisor != "astar" {
+		t.Fatalf("starting a job: status %d, %+v", status, view)
+	}
+	names, last := events(view.Identity)
+	if names[len(names)-1] != "done" || last.Status != JOB_SOLVED || last.Solution != "R" {
+		t.Errorf("events %v ending with %+v, expected the solution R", names, last)
+	}
+
+	// The large level is searched breadth-first, for longer than the test.
+	status, view = start(`{"level": "Heroban/The big trefle", "advisor": "baseline"}`)
+	if status != http.StatusAccepted {
+		t.Fatalf("starting a job: status %d, %+v", status, view)
+	}
+	if status, _ := start(`{"level": "room"}`); status != http.StatusServiceUnavailable {
+		t.Errorf("starting a second job: status %d, expected the limit of one", status)
+	}
+	go func() {
+		time.Sleep(3 * EVENT_INTERVAL)
+		request, _ := http.NewRequest("DELETE", server.URL+"/api/solve/"+view.Identity, nil)
+		if response, err := http.DefaultClient.Do(request); err == nil {
+			response.Body.Close()
+		}
+	}()
+	names, last = events(view.Identity)
+	if len(names) < 2 || names[0] != "progress" || names[len(names)-1] != "done" {
+		t.Errorf("events %v, expected progress and then done", names)
+	}
+	if last.Status != JOB_CANCELLED || last.Progress.Nodes == 0 || last.Progress.Cells == 0 {
+		t.Errorf("cancelled job ended as %+v", last)
+	}
+
+	for body, status := range map[string]int{
+		`{"level": "room", "advisor": "psychic"}`: http.StatusBadRequest,
+		`{"level": "room", "limit": -1}`:          http.StatusBadRequest,
+		`{"level": "hall"}`:                       http.StatusNotFound,
+		`{"level": "room", "limit": 1}`:           http.StatusAccepted,
+	} {
+		if got, _ := start(body); got != status {
+			t.Errorf("%s: status %d, expected %d", body, got, status)
+		}
+	}
+	response, err := http.Get(server.URL + "/api/solve/0123/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("events of an unknown job: status %d", response.StatusCode)
+	}
+}
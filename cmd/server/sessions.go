@@ -0,0 +1,252 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/sessions.go
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// A level being played, whose state the server keeps so that the player can
+// continue it later (or elsewhere).  Only legal steps are ever added to it.
+type session struct {
+	id      string
+	level   *level
+	state   *hexoban.State
+	created time.Time
+	updated time.Time
+}
+
+// What a session's journal entries record, a session is created and then has
+// steps taken and undone.
+const (
+	SESSION_CREATE = "create"
+	SESSION_MOVE   = "move"
+	SESSION_UNDO   = "undo"
+)
+
+type sessionEntry struct {
+	Session     string    `json:"session"`
+	Op          string    `json:"op"`
+	Time        time.Time `json:"time"`
+	Level       string    `json:"level,omitempty"` // for SESSION_CREATE,
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Steps       string    `json:"steps,omitempty"` // for SESSION_MOVE,
+	Count       int       `json:"count,omitempty"` // and for SESSION_UNDO.
+}
+
+// The sessions in progress, restored by replaying their journal.  Sessions
+// for levels which have been removed or whose layout has changed since cannot
+// be continued and are not restored.
+type sessionStore struct {
+	mutex    sync.Mutex
+	journal  *journal
+	sessions map[string]*session
+}
+
+func openSessionStore(path string, catalog *catalog) (*sessionStore, error) {
+	store := &sessionStore{sessions: make(map[string]*session)}
+	abandoned := make(map[string]bool)
+	journal, err := openJournal(path, func(line []byte) error {
+		var entry sessionEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		if abandoned[entry.Session] {
+			return nil
+		}
+		if entry.Op == SESSION_CREATE {
+			level := catalog.byID[entry.Level]
+			if level == nil || level.fingerprint != entry.Fingerprint {
+				abandoned[entry.Session] = true
+				return nil
+			}
+			state, err := hexoban.NewState(level.puzzle)
+			if err != nil {
+				return err
+			}
+			store.sessions[entry.Session] = &session{id: entry.Session, level: level,
+				state: state, created: entry.Time, updated: entry.Time}
+			return nil
+		}
+		session := store.sessions[entry.Session]
+		if session == nil {
+			return fmt.Errorf("session %s was not created", entry.Session)
+		}
+		return session.replay(entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	store.journal = journal
+	return store, nil
+}
+
+func (store *sessionStore) Close() error {
+	return store.journal.Close()
+}
+
+// Applies a journal entry that was written for this session.
+func (session *session) replay(entry sessionEntry) error {
+	switch entry.Op {
+	case SESSION_MOVE:
+		steps, err := hexoban.ParseSolution(entry.Steps)
+		if err != nil {
+			return err
+		}
+		if err := session.state.Apply(steps); err != nil {
+			return err
+		}
+	case SESSION_UNDO:
+		for count := 0; count < entry.Count; count++ {
+			session.state.Undo()
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", entry.Op)
+	}
+	session.updated = entry.Time
+	return nil
+}
+
+// Starts a new session at the level's initial state.
+func (store *sessionStore) Create(level *level) (sessionView, error) {
+	state, err := hexoban.NewState(level.puzzle)
+	if err != nil {
+		return sessionView{}, err
+	}
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return sessionView{}, err
+	}
+	now := time.Now().UTC()
+	session := &session{id: hex.EncodeToString(random[:]), level: level,
+		state: state, created: now, updated: now}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	err = store.journal.append(sessionEntry{Session: session.id, Op: SESSION_CREATE,
+		Time: now, Level: level.puzzle.Identity, Fingerprint: level.fingerprint})
+	if err != nil {
+		return sessionView{}, err
+	}
+	store.sessions[session.id] = session
+	return session.view(), nil
+}
+
+// Returns the current state of the session, and false if there is no session
+// with that identity.
+func (store *sessionStore) Get(id string) (sessionView, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if session := store.sessions[id]; session != nil {
+		return session.view(), true
+	}
+	return sessionView{}, false
+}
+
+// Moves the worker in each of the directions in turn.  Whether a step pushes
+// is decided by the rules, not the case of its letter.  If any step is illegal
+// the error is a *hexoban.StepError for it and the session is unchanged.
+func (store *sessionStore) Move(id string, directions hexoban.Solution) (sessionView, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	session := store.sessions[id]
+	if session == nil {
+		return sessionView{}, errNoSession
+	}
+	next := session.state.Clone()
+	taken, err := next.Walk(directions)
+	if err != nil {
+		return sessionView{}, err
+	}
+	if len(taken) == 0 {
+		return session.view(), nil
+	}
+	now := time.Now().UTC()
+	err = store.journal.append(sessionEntry{Session: id, Op: SESSION_MOVE,
+		Time: now, Steps: taken.String()})
+	if err != nil {
+		return sessionView{}, err
+	}
+	session.state, session.updated = next, now
+	return session.view(), nil
+}
+
+// Reverts up to count of the most recent steps, fewer if the session has not
+// taken that many.
+func (store *sessionStore) Undo(id string, count int) (sessionView, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	session := store.sessions[id]
+	if session == nil {
+		return sessionView{}, errNoSession
+	}
+	count = min(count, len(session.state.History()))
+	if count <= 0 {
+		return session.view(), nil
+	}
+	now := time.Now().UTC()
+	err := store.journal.append(sessionEntry{Session: id, Op: SESSION_UNDO,
+		Time: now, Count: count})
+	if err != nil {
+		return sessionView{}, err
+	}
+	for undone := 0; undone < count; undone++ {
+		session.state.Undo()
+	}
+	session.updated = now
+	return session.view(), nil
+}
+
+var errNoSession = errors.New("no such session")
+
+// The state of a session as it is sent to clients.
+type sessionView struct {
+	Identity string             `json:"id"`
+	Level    string             `json:"level"`
+	History  string             `json:"history"` // the steps taken, in hex-LURD.
+	Moves    int                `json:"moves"`
+	Pushes   int                `json:"pushes"`
+	Ichiban  hexoban.HexCoord   `json:"ichiban"`
+	Crates   []hexoban.HexCoord `json:"crates"`
+	Solved   bool               `json:"solved"`
+	Created  time.Time          `json:"created"`
+	Updated  time.Time          `json:"updated"`
+}
+
+func (session *session) view() sessionView {
+	history := session.state.History()
+	return sessionView{
+		Identity: session.id,
+		Level:    session.level.puzzle.Identity,
+		History:  history.String(),
+		Moves:    history.Moves(),
+		Pushes:   history.Pushes(),
+		Ichiban:  session.state.Ichiban(),
+		Crates:   session.state.Crates(),
+		Solved:   session.state.IsSolved(),
+		Created:  session.created,
+		Updated:  session.updated,
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/sessions_test.go
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+func TestSessionStore(t *testing.T) {
+	catalog := testCatalog(t)
+	path := filepath.Join(t.TempDir(), "sessions.jsonl")
+	store, err := openSessionStore(path, catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	room, err := store.Create(catalog.byID["first/01"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hall, err := store.Create(catalog.byID["first/02"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if room.Identity == hall.Identity || len(room.Identity) != 32 {
+		t.Errorf("session identities %q and %q", room.Identity, hall.Identity)
+	}
+
+	steps := func(lurd string) hexoban.Solution {
+		solution, err := hexoban.ParseSolution(lurd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return solution
+	}
+	tests := []struct {
+		name    string
+		id      string
+		action  func(id string) (sessionView, error)
+		history string
+		step    int // of a StepError, if the action fails.
+	}{
+		{"walk", room.Identity, func(id string) (sessionView, error) { return store.Move(id, steps("ud")) }, "ud", 0},
+		{"push", room.Identity, func(id string) (sessionView, error) { return store.Move(id, steps("r")) }, "udR", 0},
+		{"undo", room.Identity, func(id string) (sessionView, error) { return store.Undo(id, 2) }, "u", 0},
+		{"into a wall", room.Identity, func(id string) (sessionView, error) { return store.Move(id, steps("dl")) }, "u", 2},
+		{"undo too many", hall.Identity, func(id string) (sessionView, error) { return store.Undo(id, 3) }, "", 0},
+		{"push twice", hall.Identity, func(id string) (sessionView, error) { return store.Move(id, steps("rR")) }, "RR", 0},
+	}
+	for _, tt := range tests {
+		view, err := tt.action(tt.id)
+		var stepErr *hexoban.StepError
+		if tt.step != 0 && (!errors.As(err, &stepErr) || stepErr.Index != tt.step) {
+			t.Errorf("%s: error = %v, expected a StepError at step %d", tt.name, err, tt.step)
+			continue
+		} else if tt.step == 0 && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if view, _ := store.Get(tt.id); view.History != tt.history {
+			t.Errorf("%s: history %q, expected %q", tt.name, view.History, tt.history)
+		}
+		if tt.step == 0 && view.History != tt.history {
+			t.Errorf("%s: returned history %q, expected %q", tt.name, view.History, tt.history)
+		}
+	}
+	if _, err := store.Move("missing", steps("u")); !errors.Is(err, errNoSession) {
+		t.Errorf("Move() of a missing session, error = %v", err)
+	}
+	store.Close()
+
+	// Replaying the journal restores both sessions as they were left.
+	reopened := testSessions(t, catalog, path)
+	for id, expect := range map[string]string{room.Identity: "u", hall.Identity: "RR"} {
+		view, found := reopened.Get(id)
+		if !found || view.History != expect {
+			t.Errorf("reopened session %s: %t, history %q, expected %q", id, found, view.History, expect)
+		}
+	}
+	if view, _ := reopened.Get(room.Identity); !view.Created.Equal(room.Created) || view.Updated.Before(room.Updated) {
+		t.Errorf("reopened session created %v updated %v", view.Created, view.Updated)
+	}
+
+	// A session cannot continue once its level's layout has changed.
+	changed, err := loadCatalog(fstest.MapFS{
+		"first/01.json": {Data: []byte(`{"id": "first/01", ` + TREASURE_HALL + `}`)},
+		"first/02.json": {Data: []byte(`{"id": "first/02", ` + TREASURE_HALL + `}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.Close()
+	restored := testSessions(t, changed, path)
+	if _, found := restored.Get(room.Identity); found {
+		t.Error("restored a session for a level whose layout changed")
+	}
+	if view, found := restored.Get(hall.Identity); !found || view.History != "RR" {
+		t.Errorf("session for the unchanged level: %t, %+v", found, view)
+	}
+}
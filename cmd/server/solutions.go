@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/solutions.go
+
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// A solution which was verified to solve its level when it was submitted.
+type solutionRecord struct {
+	Level       string    `json:"level"` // the level's identity.
+	Fingerprint string    `json:"fingerprint"`
+	Solution    string    `json:"solution"` // in hex-LURD notation.
+	Moves       int       `json:"moves"`
+	Pushes      int       `json:"pushes"`
+	Player      string    `json:"player,omitempty"`
+	Submitted   time.Time `json:"submitted"`
+}
+
+// The records board of a level, ranked as Sokoban sites do: the fewest moves
+// (with ties broken by the fewest pushes) and the fewest pushes (with ties
+// broken by the fewest moves).  Of equal solutions, the first submitted holds
+// the record.
+type levelRecords struct {
+	Level       string `json:"id"`
+	Fingerprint string `json:"fingerprint"`
+	Solutions   int    `json:"solutions"` // the number of distinct solutions.
+	Best        struct {
+		Moves  *solutionRecord `json:"moves"`
+		Pushes *solutionRecord `json:"pushes"`
+	} `json:"best"`
+	known map[string]bool // the solutions submitted so far.
+}
+
+// Keeps the verified solutions in a journal, one line per solution.  Records
+// are kept for a level's identity and its fingerprint together, a level whose
+// layout has been edited since starts a new records board (its old solutions
+// stay in the journal).
+type solutionStore struct {
+	mutex   sync.Mutex
+	journal *journal
+	records map[string]*levelRecords // by recordsKey().
+}
+
+func recordsKey(level, fingerprint string) string {
+	return level + " " + fingerprint
+}
+
+// Reads the solutions stored at the path and opens it for adding more.
+func openSolutionStore(path string) (*solutionStore, error) {
+	store := &solutionStore{records: make(map[string]*levelRecords)}
+	journal, err := openJournal(path, func(line []byte) error {
+		var record solutionRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		store.insert(&record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	store.journal = journal
+	return store, nil
+}
+
+func (store *solutionStore) Close() error {
+	return store.journal.Close()
+}
+
+// Returns a copy of the level's records board, which is empty if nothing has
+// been submitted for it yet.
+func (store *solutionStore) Records(level, fingerprint string) levelRecords {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if records := store.records[recordsKey(level, fingerprint)]; records != nil {
+		return *records
+	}
+	return levelRecords{Level: level, Fingerprint: fingerprint}
+}
+
+// Stores the (already verified) solution unless the same solution was stored
+// before.  Returns whether it was added and which of the level's records it
+// set, "moves" and/or "pushes".
+func (store *solutionStore) Add(record solutionRecord) (bool, []string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if records := store.records[recordsKey(record.Level, record.Fingerprint)]; records != nil &&
+		records.known[record.Solution] {
+		return false, []string{}, nil
+	}
+	if err := store.journal.append(record); err != nil {
+		return false, nil, err
+	}
+	return true, store.insert(&record), nil
+}
+
+// Adds the record to its level's board, returning the records that it set.
+func (store *solutionStore) insert(record *solutionRecord) []string {
+	key := recordsKey(record.Level, record.Fingerprint)
+	records := store.records[key]
+	if records == nil {
+		records = &levelRecords{Level: record.Level, Fingerprint: record.Fingerprint,
+			known: make(map[string]bool)}
+		store.records[key] = records
+	}
+	if records.known[record.Solution] {
+		return []string{}
+	}
+	records.known[record.Solution] = true
+	records.Solutions++
+
+	set := []string{}
+	if best := records.Best.Moves; best == nil || record.Moves < best.Moves ||
+		(record.Moves == best.Moves && record.Pushes < best.Pushes) {
+		records.Best.Moves = record
+		set = append(set, "moves")
+	}
+	if best := records.Best.Pushes; best == nil || record.Pushes < best.Pushes ||
+		(record.Pushes == best.Pushes && record.Moves < best.Moves) {
+		records.Best.Pushes = record
+		set = append(set, "pushes")
+	}
+	return set
+}
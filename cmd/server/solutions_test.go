@@ -0,0 +1,94 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/solutions_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSolutionStore_Records(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "solutions.jsonl")
+	store, err := openSolutionStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitted := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	record := func(solution string, moves, pushes int) solutionRecord {
+		submitted = submitted.Add(time.Minute)
+		return solutionRecord{Level: "a/1", Fingerprint: "f1", Solution: solution,
+			Moves: moves, Pushes: pushes, Submitted: submitted}
+	}
+	tests := []struct {
+		record solutionRecord
+		added  bool
+		set    string
+	}{
+		{record("first", 20, 10), true, "moves,pushes"},
+		{record("fewer moves", 18, 12), true, "moves"},
+		{record("fewer pushes", 24, 8), true, "pushes"},
+		{record("tied moves, fewer pushes", 18, 11), true, "moves"},
+		{record("tied pushes, fewer moves", 22, 8), true, "pushes"},
+		{record("tied both", 22, 8), true, ""},
+		{record("first", 20, 10), false, ""},
+	}
+	for _, tt := range tests {
+		added, set, err := store.Add(tt.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != tt.added || strings.Join(set, ",") != tt.set {
+			t.Errorf("Add(%s) = %t %v, expected %t [%s]", tt.record.Solution, added, set, tt.added, tt.set)
+		}
+	}
+	expect := func(store *solutionStore) {
+		t.Helper()
+		records := store.Records("a/1", "f1")
+		if records.Solutions != 6 || records.Best.Moves.Solution != "tied moves, fewer pushes" ||
+			records.Best.Pushes.Solution != "tied pushes, fewer moves" {
+			t.Errorf("Records() = %d solutions, best moves %+v, best pushes %+v",
+				records.Solutions, records.Best.Moves, records.Best.Pushes)
+		}
+		if other := store.Records("a/1", "f2"); other.Solutions != 0 || other.Best.Moves != nil {
+			t.Errorf("a changed layout has records %+v", other)
+		}
+	}
+	expect(store)
+	store.Close()
+
+	// Reopening the store reads the same records back.
+	reopened, err := openSolutionStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	expect(reopened)
+	if records := reopened.Records("a/1", "f1"); !records.Best.Moves.Submitted.Equal(tests[3].record.Submitted) {
+		t.Errorf("reopened record submitted at %v, expected %v",
+			records.Best.Moves.Submitted, tests[3].record.Submitted)
+	}
+
+	corrupt := filepath.Join(t.TempDir(), "corrupt.jsonl")
+	// A bad last line is dropped (see TestOpenJournal), one before it is not.
+	os.WriteFile(corrupt, []byte("{\"level\": \"a/1\"}\nnot json\n{\"level\": \"a/1\"}\n"), 0644)
+	if _, err := openSolutionStore(corrupt); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("openSolutionStore() error = %v, expected one on line 2", err)
+	}
+}
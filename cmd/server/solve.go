@@ -0,0 +1,218 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/server/solve.go
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// The states of a solver job, it is running until it ends in one of the others.
+const (
+	JOB_RUNNING    = "running"
+	JOB_SOLVED     = "solved"
+	JOB_UNSOLVABLE = "unsolvable" // the search visited every position.
+	JOB_EXHAUSTED  = "exhausted"  // the node limit or time limit was reached.
+	JOB_CANCELLED  = "cancelled"
+)
+
+// How long a job's result is kept after it ends.
+const JOB_RETENTION = 10 * time.Minute
+
+// The advisors that a job may be started with, by name.
+var ADVISORS = map[string]hexoban.Advisor{
+	"weighted": hexoban.WeightedAdvisor,
+	"astar":    hexoban.AStarAdvisor,
+	"baseline": hexoban.BaselineAdvisor,
+}
+
+// Runs solver searches in the background, at most limit at a time and each for
+// at most timeout.  Their progress can be followed while they run.
+type solverPool struct {
+	limit   int
+	timeout time.Duration
+	ctx     context.Context // the parent of each job's context.
+	stop    context.CancelFunc
+
+	mutex sync.Mutex
+	jobs  map[string]*solveJob
+	wg    sync.WaitGroup
+}
+
+var errTooManyJobs = errors.New("too many solver jobs are running")
+
+func newSolverPool(limit int, timeout time.Duration) *solverPool {
+	ctx, stop := context.WithCancel(context.Background())
+	return &solverPool{limit: max(limit, 1), timeout: timeout, ctx: ctx, stop: stop,
+		jobs: make(map[string]*solveJob)}
+}
+
+// Cancels every running job and waits for their searches to return.
+func (pool *solverPool) Close() {
+	pool.stop()
+	pool.wg.Wait()
+}
+
+type solveJob struct {
+	id      string
+	level   string
+	started time.Time
+	cancel  context.CancelFunc
+
+	mutex    sync.Mutex
+	view     jobView
+	changed  chan struct{} // closed (and replaced) each time the view changes.
+	finished time.Time
+}
+
+// A job's status and its latest progress, as sent to clients.
+type jobView struct {
+	Identity string      `json:"id"`
+	Level    string      `json:"level"`
+	Advisor  string      `json:"advisor"`
+	Status   string      `json:"status"`
+	Progress jobProgress `json:"progress"`
+	Solution string      `json:"solution,omitempty"` // once solved.
+	Error    string      `json:"error,omitempty"`    // unless solved.
+}
+
+type jobProgress struct {
+	Nodes      int     `json:"nodes"`     // positions expanded,
+	Generated  int     `json:"generated"` // and added to the open list.
+	Cells      int     `json:"cells"`     // feature-space cells visited.
+	LowerBound int     `json:"bound"`     // the least bound of the positions expanded.
+	Best       string  `json:"best"`      // the steps to the position with that bound.
+	Elapsed    float64 `json:"elapsed"`   // in seconds.
+}
+
+// Starts searching for a solution of the level, returning errTooManyJobs if
+// the pool's limit of running jobs has been reached.  A maxNodes of zero
+// searches until the job's time runs out.
+func (pool *solverPool) Start(level *level, advisor string, maxNodes int) (jobView, error) {
+	state, err := hexoban.NewState(level.puzzle)
+	if err != nil {
+		return jobView{}, err
+	}
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return jobView{}, err
+	}
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	running := 0
+	for id, job := range pool.jobs {
+		job.mutex.Lock()
+		if job.view.Status == JOB_RUNNING {
+			running++
+		} else if time.Since(job.finished) > JOB_RETENTION {
+			delete(pool.jobs, id)
+		}
+		job.mutex.Unlock()
+	}
+	if running >= pool.limit {
+		return jobView{}, errTooManyJobs
+	}
+
+	ctx, cancel := context.WithTimeout(pool.ctx, pool.timeout)
+	job := &solveJob{id: hex.EncodeToString(random[:]), level: level.puzzle.Identity,
+		started: time.Now(), cancel: cancel, changed: make(chan struct{})}
+	job.view = jobView{Identity: job.id, Level: job.level, Advisor: advisor, Status: JOB_RUNNING}
+	pool.jobs[job.id] = job
+	view := job.view // before the search can change it.
+
+	solver := hexoban.Solver{Advisor: ADVISORS[advisor], MaxNodes: maxNodes, Progress: job.update}
+	pool.wg.Add(1)
+	go func() {
+		defer pool.wg.Done()
+		defer cancel()
+		solution, _, err := solver.Solve(ctx, state)
+		job.finish(solution, err)
+	}()
+	return view, nil
+}
+
+// Returns the job, or nil if there is no job with that identity (or it ended
+// too long ago).
+func (pool *solverPool) Job(id string) *solveJob {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return pool.jobs[id]
+}
+
+// Returns the job's current view and a channel that is closed when it next
+// changes (or nil if the job has ended and will not change again).
+func (job *solveJob) Watch() (jobView, <-chan struct{}) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	if job.view.Status != JOB_RUNNING {
+		return job.view, nil
+	}
+	return job.view, job.changed
+}
+
+// Stops the job's search, it ends as cancelled unless it already ended.
+func (job *solveJob) Cancel() {
+	job.cancel()
+}
+
+// Called by the solver with its progress.
+func (job *solveJob) update(progress hexoban.SolverProgress) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	job.view.Progress = jobProgress{
+		Nodes:      progress.Nodes,
+		Generated:  progress.Generated,
+		Cells:      progress.FeatureCells,
+		LowerBound: progress.LowerBound,
+		Best:       progress.Best.String(),
+		Elapsed:    time.Since(job.started).Seconds(),
+	}
+	job.notify()
+}
+
+func (job *solveJob) finish(solution hexoban.Solution, err error) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	switch {
+	case err == nil:
+		job.view.Status, job.view.Solution = JOB_SOLVED, solution.String()
+	case errors.Is(err, hexoban.ErrNoSolution):
+		job.view.Status = JOB_UNSOLVABLE
+	case errors.Is(err, context.Canceled):
+		job.view.Status = JOB_CANCELLED
+	default:
+		job.view.Status = JOB_EXHAUSTED
+	}
+	if err != nil {
+		job.view.Error = err.Error()
+	}
+	job.finished = time.Now()
+	job.notify()
+}
+
+// Wakes the job's watchers, the caller holds the job's mutex.
+func (job *solveJob) notify() {
+	close(job.changed)
+	job.changed = make(chan struct{})
+}
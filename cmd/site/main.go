@@ -0,0 +1,46 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/site/main.go
+
+package main
+
+// Main entry point for site.exe
+//
+// Generates a static website for the level collections, with a page for each
+// collection, author and level and a manifest of the levels for the webapp.
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	levelsDir := flag.String("levels", "levels", "the directory of level collections")
+	outDir := flag.String("o", "_site", "the directory to write the site into")
+	flag.Parse()
+
+	site, err := loadSite(*levelsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := site.generate(*outDir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d levels in %d collections written to %s\n",
+		site.LevelCount(), len(site.Collections), *outDir)
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/site/site.go
+
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render"
+	"github.com/SymbolNotFound/hexoban/render/svg"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+// The levels of each collection (a subdirectory of the levels directory) and
+// the same levels grouped by their author.
+type site struct {
+	Collections []*siteCollection
+	Authors     []*siteAuthor
+}
+
+type siteCollection struct {
+	Name   string
+	Levels []*siteLevel
+}
+
+type siteAuthor struct {
+	Name   string
+	Slug   string // for the author's page, see slugOf().
+	Levels []*siteLevel
+}
+
+type siteLevel struct {
+	Puzzle     hexoban.Puzzle
+	Collection string
+	Name       string // the base name of its files, e.g. "001" for 001.json.
+	Stats      levelStats
+	Previous   *siteLevel // within its collection, nil for the first level,
+	Next       *siteLevel // and nil for the last.
+	filedata   []byte
+}
+
+type levelStats struct {
+	Hexes     int
+	Crates    int
+	DeadCells int
+	Rows      int
+	Columns   int // the width of the map, in hexes.
+}
+
+// The data of each page's template, the fields used depend on the page.
+type page struct {
+	Root       string // the relative path from the page to the site's root.
+	Site       *site
+	Collection *siteCollection
+	Author     *siteAuthor
+	Level      *siteLevel
+	Levels     []*siteLevel // for the "level-list" template, see With().
+}
+
+// Returns a copy of the page listing the levels.
+func (p page) With(levels []*siteLevel) page {
+	p.Levels = levels
+	return p
+}
+
+// Reads each collection's levels from the subdirectories of the levels
+// directory, in the order of their file names.
+func loadSite(levelsDir string) (*site, error) {
+	entries, err := os.ReadDir(levelsDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &site{}
+	authors := make(map[string]*siteAuthor)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		paths, err := filepath.Glob(filepath.Join(levelsDir, entry.Name(), "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		collection := &siteCollection{Name: entry.Name()}
+		for _, path := range paths {
+			level, err := loadLevel(path, entry.Name())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if count := len(collection.Levels); count > 0 {
+				level.Previous = collection.Levels[count-1]
+				level.Previous.Next = level
+			}
+			collection.Levels = append(collection.Levels, level)
+
+			slug := level.AuthorSlug()
+			if authors[slug] == nil {
+				authors[slug] = &siteAuthor{Name: level.Puzzle.Author, Slug: slug}
+				result.Authors = append(result.Authors, authors[slug])
+			}
+			authors[slug].Levels = append(authors[slug].Levels, level)
+		}
+		if len(collection.Levels) > 0 {
+			result.Collections = append(result.Collections, collection)
+		}
+	}
+	if len(result.Collections) == 0 {
+		return nil, fmt.Errorf("no levels found in %s", levelsDir)
+	}
+	sort.Slice(result.Authors, func(a, b int) bool {
+		return result.Authors[a].Slug < result.Authors[b].Slug
+	})
+	return result, nil
+}
+
+func loadLevel(path, collection string) (*siteLevel, error) {
+	filedata, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	level := &siteLevel{
+		Collection: collection,
+		Name:       strings.TrimSuffix(filepath.Base(path), ".json"),
+		filedata:   filedata,
+	}
+	if err := json.Unmarshal(filedata, &level.Puzzle); err != nil {
+		return nil, err
+	}
+	if level.Puzzle.Author == "" {
+		level.Puzzle.Author = "Anonymous"
+	}
+	scene, err := render.NewScene(level.Puzzle, render.Orientation{}, render.Overlays{Dead: true})
+	if err != nil {
+		return nil, err
+	}
+
+	level.Stats = levelStats{
+		Hexes:     len(level.Puzzle.Terrain),
+		Crates:    len(level.Puzzle.Init.Crates),
+		DeadCells: len(scene.Dead),
+	}
+	// The bounds include the walls, one row and one hex (two columns) beyond
+	// the floors on each side.
+	minCol, minRow, maxCol, maxRow := scene.Bounds()
+	level.Stats.Rows = maxRow - minRow - 1
+	level.Stats.Columns = (maxCol-minCol)/2 - 1
+	return level, nil
+}
+
+func (level *siteLevel) AuthorSlug() string {
+	return slugOf(level.Puzzle.Author)
+}
+
+// Returns the name in lowercase, with each run of other than letters and
+// digits replaced by a hyphen, e.g. "David W. Skinner" is "david-w-skinner".
+func slugOf(name string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, char := range strings.ToLower(name) {
+		if unicode.IsLetter(char) || unicode.IsDigit(char) {
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(char)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	if slug.Len() == 0 {
+		return "anonymous"
+	}
+	return slug.String()
+}
+
+func (site *site) LevelCount() int {
+	count := 0
+	for _, collection := range site.Collections {
+		count += len(collection.Levels)
+	}
+	return count
+}
+
+// The files of every level are listed in the manifest, relative to the root.
+type manifest struct {
+	Collections []manifestCollection `json:"collections"`
+}
+
+type manifestCollection struct {
+	Name   string          `json:"name"`
+	Index  string          `json:"index"`
+	Levels []manifestLevel `json:"levels"`
+}
+
+type manifestLevel struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Author     string `json:"author"`
+	Difficulty int    `json:"difficulty,omitempty"`
+	Hexes      int    `json:"hexes"`
+	Crates     int    `json:"crates"`
+	JSON       string `json:"json"`
+	SVG        string `json:"svg"`
+	Page       string `json:"page"`
+}
+
+// Writes the site into the output directory: an index of the collections and
+// authors, a page for each collection and author, and for each level its page,
+// its map as SVG and a copy of its definition.  The manifest.json lists them.
+func (site *site) generate(outDir string) error {
+	templates := make(map[string]*template.Template)
+	for _, name := range []string{"index", "collection", "author", "level"} {
+		parsed, err := template.New(name).Funcs(template.FuncMap{"difficulty": difficultyText}).
+			ParseFS(templateFiles, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return err
+		}
+		templates[name] = parsed
+	}
+	writePage := func(path, name string, data page) error {
+		var html bytes.Buffer
+		if err := templates[name].ExecuteTemplate(&html, "layout", data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return writeFile(filepath.Join(outDir, path), html.Bytes())
+	}
+
+	if err := writePage("index.html", "index", page{Site: site}); err != nil {
+		return err
+	}
+	contents := manifest{Collections: []manifestCollection{}}
+	for _, collection := range site.Collections {
+		root := strings.Repeat("../", strings.Count(collection.Name, "/")+1)
+		err := writePage(collection.Name+"/index.html", "collection",
+			page{Root: root, Site: site, Collection: collection})
+		if err != nil {
+			return err
+		}
+		listed := manifestCollection{Name: collection.Name, Index: collection.Name + "/index.html"}
+		for _, level := range collection.Levels {
+			base := collection.Name + "/" + level.Name
+			if err := writeFile(filepath.Join(outDir, base+".json"), level.filedata); err != nil {
+				return err
+			}
+			var image bytes.Buffer
+			if err := svg.Encode(&image, level.Puzzle, svg.Options{}); err != nil {
+				return fmt.Errorf("%s: %w", base, err)
+			}
+			if err := writeFile(filepath.Join(outDir, base+".svg"), image.Bytes()); err != nil {
+				return err
+			}
+			err := writePage(base+".html", "level", page{Root: root, Site: site, Level: level})
+			if err != nil {
+				return err
+			}
+			listed.Levels = append(listed.Levels, manifestLevel{
+				ID:         level.Puzzle.Identity,
+				Title:      level.Puzzle.Title,
+				Author:     level.Puzzle.Author,
+				Difficulty: level.Puzzle.Difficulty,
+				Hexes:      level.Stats.Hexes,
+				Crates:     level.Stats.Crates,
+				JSON:       base + ".json",
+				SVG:        base + ".svg",
+				Page:       base + ".html",
+			})
+		}
+		contents.Collections = append(contents.Collections, listed)
+	}
+	for _, author := range site.Authors {
+		err := writePage("authors/"+author.Slug+".html", "author",
+			page{Root: "../", Site: site, Author: author})
+		if err != nil {
+			return err
+		}
+	}
+
+	encoded, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(outDir, "manifest.json"), append(encoded, '\n'))
+}
+
+func difficultyText(difficulty int) string {
+	if difficulty <= 0 {
+		return "unrated"
+	}
+	return "difficulty " + strconv.Itoa(difficulty)
+}
+
+// Writes the file, creating its directory if needed.
+func writeFile(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, contents, 0644)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/site/site_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	site, err := loadSite("../../levels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := filepath.Glob("../../levels/*/*.json")
+	if site.LevelCount() != len(paths) {
+		t.Errorf("loaded %d levels, expected %d", site.LevelCount(), len(paths))
+	}
+	outDir := t.TempDir()
+	if err := site.generate(outDir); err != nil {
+		t.Fatal(err)
+	}
+
+	filedata, err := os.ReadFile(filepath.Join(outDir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents manifest
+	if err := json.Unmarshal(filedata, &contents); err != nil {
+		t.Fatalf("manifest.json: %v", err)
+	}
+	listed := 0
+	for _, collection := range contents.Collections {
+		for _, level := range collection.Levels {
+			listed++
+			for _, path := range []string{level.JSON, level.SVG, level.Page} {
+				if _, err := os.Stat(filepath.Join(outDir, path)); err != nil {
+					t.Errorf("manifest lists a missing file: %v", err)
+				}
+			}
+		}
+	}
+	if listed != len(paths) {
+		t.Errorf("manifest lists %d levels, expected %d", listed, len(paths))
+	}
+
+	// Copies of the definitions keep the same paths as in the levels directory.
+	original, _ := os.ReadFile("../../levels/DWS/001.json")
+	copied, _ := os.ReadFile(filepath.Join(outDir, "DWS/001.json"))
+	if string(copied) != string(original) {
+		t.Errorf("DWS/001.json was not copied as it is")
+	}
+	text, _ := os.ReadFile(filepath.Join(outDir, "DWS/001.html"))
+	for _, expect := range []string{
+		`<a href="../authors/david-w-skinner.html">David W. Skinner</a>`,
+		`<img src="001.svg"`, `<a href="002.html">002 &rarr;</a>`, "<dd>7</dd>",
+	} {
+		if !strings.Contains(string(text), expect) {
+			t.Errorf("DWS/001.html is missing %s", expect)
+		}
+	}
+	for _, path := range []string{"index.html", "DWS/index.html", "authors/david-w-skinner.html"} {
+		if _, err := os.Stat(filepath.Join(outDir, path)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestGenerate_Escaping(t *testing.T) {
+	levelsDir := t.TempDir()
+	level := `{"id": "x/1", "title": "<b>Bold</b>", "author": "Ann & Bob",
+		"terrain": [[0, 0], [0, 1], [0, 2]], "init": {"goals": [[0, 2]], "crates": [[0, 1]]}}`
+	if err := writeFile(filepath.Join(levelsDir, "x", "1.json"), []byte(level)); err != nil {
+		t.Fatal(err)
+	}
+	site, err := loadSite(levelsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	if err := site.generate(outDir); err != nil {
+		t.Fatal(err)
+	}
+	text, err := os.ReadFile(filepath.Join(outDir, "authors", "ann-bob.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(text), "<b>") || !strings.Contains(string(text), "&lt;b&gt;Bold") ||
+		!strings.Contains(string(text), "Ann &amp; Bob") {
+		t.Errorf("author page is not escaped\n%s", text)
+	}
+
+	if _, err := loadSite(t.TempDir()); err == nil {
+		t.Errorf("loadSite() expected an error for an empty directory")
+	}
+}
+
+func TestSlugOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect string
+	}{
+		{"David W. Skinner", "david-w-skinner"},
+		{"  LukaszM  ", "lukaszm"},
+		{"Ann & Bob", "ann-bob"},
+		{"François Marques", "françois-marques"},
+		{"...", "anonymous"},
+	}
+	for _, tt := range tests {
+		if got := slugOf(tt.name); got != tt.expect {
+			t.Errorf("slugOf(%q) = %q, expected %q", tt.name, got, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/wasm/api.go
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// The functions exported to JavaScript, as properties of the global `hexoban`
+// object (see main_js.go):
+//
+//	load(json)        loads a puzzle from its JSON definition (a string or an object)
+//	move(dirs)        moves the worker, see api.move()
+//	undo([count])     reverts the last step, or the last count steps
+//	state()           describes the current position
+//	isSolved()        whether every crate is on a goal
+//	deadlocks()       the crates which can no longer reach a goal
+//	isDeadCell(i, j)  whether a crate pushed onto the hex could never reach a goal
+//	hint()            suggests the next push, see hexoban.Hint()
+//
+// Their arguments are converted from JavaScript values to strings, float64s
+// and bools (nil when undefined) and their results are made of maps, slices,
+// strings, ints and bools so that js.ValueOf() can convert them back.
+// Coordinates are written as [i, j] and steps in hex-LURD.  A function that
+// fails returns {error: "..."} instead, and {error, step} when one of the
+// steps given to move() was illegal.
+type api struct {
+	puzzle hexoban.Puzzle
+	state  *hexoban.State // nil until a puzzle is loaded.
+}
+
+type function func(args []any) (any, error)
+
+func (api *api) functions() map[string]function {
+	return map[string]function{
+		"load":       api.load,
+		"move":       api.loaded(api.move),
+		"undo":       api.loaded(api.undo),
+		"state":      api.loaded(api.position),
+		"isSolved":   api.loaded(api.isSolved),
+		"deadlocks":  api.loaded(api.deadlocks),
+		"isDeadCell": api.loaded(api.isDeadCell),
+		"hint":       api.loaded(api.hint),
+	}
+}
+
+// Calls the function, converting any error into the object returned for it.
+func (api *api) call(fn function, args []any) any {
+	result, err := fn(args)
+	if err != nil {
+		var stepErr *hexoban.StepError
+		if errors.As(err, &stepErr) {
+			return map[string]any{"error": stepErr.Err.Error(), "step": stepErr.Index}
+		}
+		return map[string]any{"error": err.Error()}
+	}
+	return result
+}
+
+// Wraps a function which needs a puzzle to have been loaded.
+func (api *api) loaded(fn function) function {
+	return func(args []any) (any, error) {
+		if api.state == nil {
+			return nil, fmt.Errorf("no puzzle is loaded")
+		}
+		return fn(args)
+	}
+}
+
+func (api *api) load(args []any) (any, error) {
+	definition, err := stringArg(args, 0, "the puzzle's definition")
+	if err != nil {
+		return nil, err
+	}
+	var puzzle hexoban.Puzzle
+	if err := json.Unmarshal([]byte(definition), &puzzle); err != nil {
+		return nil, fmt.Errorf("invalid puzzle: %w", err)
+	}
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return nil, err
+	}
+	api.puzzle, api.state = puzzle, state
+	return map[string]any{
+		"id":     puzzle.Identity,
+		"hexes":  len(puzzle.Terrain),
+		"crates": len(puzzle.Init.Crates),
+	}, nil
+}
+
+// Moves in each of the directions, given in hex-LURD where the case of each
+// letter is ignored, see hexoban.State.Walk().
+func (api *api) move(args []any) (any, error) {
+	dirs, err := stringArg(args, 0, "the directions to move in")
+	if err != nil {
+		return nil, err
+	}
+	directions, err := hexoban.ParseSolution(dirs)
+	if err != nil {
+		return nil, err
+	}
+	if len(directions) == 0 {
+		return nil, fmt.Errorf("no directions to move in")
+	}
+	taken, err := api.state.Walk(directions)
+	if err != nil {
+		return nil, err
+	}
+	return api.counts(map[string]any{"steps": taken.String()}), nil
+}
+
+// Reverts the last step, or the last count of them (all or none of them).
+func (api *api) undo(args []any) (any, error) {
+	count, err := intArg(args, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	history := api.state.History()
+	if count < 1 || count > len(history) {
+		return nil, fmt.Errorf("cannot undo %d of the %d steps taken", count, len(history))
+	}
+	for undone := 0; undone < count; undone++ {
+		api.state.Undo()
+	}
+	return api.counts(map[string]any{"steps": history[len(history)-count:].String()}), nil
+}
+
+func (api *api) position(args []any) (any, error) {
+	return api.counts(map[string]any{
+		"ichiban": coord(api.state.Ichiban()),
+		"crates":  coords(api.state.Crates()),
+		"history": api.state.History().String(),
+	}), nil
+}
+
+func (api *api) isSolved(args []any) (any, error) {
+	return api.state.IsSolved(), nil
+}
+
+func (api *api) deadlocks(args []any) (any, error) {
+	return coords(api.state.Deadlocks()), nil
+}
+
+func (api *api) isDeadCell(args []any) (any, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("expected the hex's coordinates i and j")
+	}
+	i, err := intArg(args, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	j, err := intArg(args, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	return api.state.IsDeadCell(hexoban.NewHexCoord(i, j)), nil
+}
+
+// Suggests the next push.  The search is bounded by hexoban.HINT_MAX_NODES
+// rather than by time, the browser waits for it.
+func (api *api) hint(args []any) (any, error) {
+	suggestion, err := hexoban.Hint(context.Background(), api.state)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case suggestion.Solved:
+		return map[string]any{"solved": true}, nil
+	case suggestion.Deadlocked:
+		return map[string]any{"deadlocked": true, "crates": coords(suggestion.DeadCrates)}, nil
+	}
+	return map[string]any{
+		"steps":     suggestion.Steps.String(),
+		"crate":     coord(suggestion.Crate),
+		"direction": string(rune(suggestion.Direction)),
+		"remaining": suggestion.Remaining,
+	}, nil
+}
+
+// Adds the moves and pushes made so far and whether the puzzle is solved.
+func (api *api) counts(result map[string]any) map[string]any {
+	history := api.state.History()
+	result["moves"] = history.Moves()
+	result["pushes"] = history.Pushes()
+	result["solved"] = api.state.IsSolved()
+	return result
+}
+
+func stringArg(args []any, index int, name string) (string, error) {
+	if index < len(args) {
+		if value, ok := args[index].(string); ok {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("expected %s as argument %d", name, index+1)
+}
+
+// The argument as an integer, or fallback if it was not given.
+func intArg(args []any, index int, fallback int) (int, error) {
+	if index >= len(args) || args[index] == nil {
+		return fallback, nil
+	}
+	value, ok := args[index].(float64)
+	if !ok || value != float64(int(value)) {
+		return 0, fmt.Errorf("expected an integer as argument %d, not %v", index+1, args[index])
+	}
+	return int(value), nil
+}
+
+func coord(coord hexoban.HexCoord) []any {
+	return []any{coord.I(), coord.J()}
+}
+
+func coords(list []hexoban.HexCoord) []any {
+	written := make([]any, len(list))
+	for index, each := range list {
+		written[index] = coord(each)
+	}
+	return written
+}
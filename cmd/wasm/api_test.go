@@ -0,0 +1,150 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/wasm/api_test.go
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// A room with one crate, pushed right (r) onto the goal beside it.
+//
+//	  # # #
+//	 #     #
+//	# @ $ . #
+//	 # # # #
+const ROOM = `{"id": "test/room", "terrain": [[1, 2], [1, 3], [2, 2], [2, 3], [2, 4]],
+  "init": {"goals": [[2, 4]], "crates": [[2, 3]], "ichiban": [2, 2]}}`
+
+// A crate stuck in the room's corner, away from its goal.
+//
+//	  # # #
+//	 # $ @ #
+//	# .     #
+//	 # # # #
+const CORNER = `{"id": "test/corner", "terrain": [[1, 2], [1, 3], [2, 2], [2, 3], [2, 4]],
+  "init": {"goals": [[2, 2]], "crates": [[1, 2]], "ichiban": [1, 3]}}`
+
+// Each test is a sequence of calls, made as JavaScript would make them through
+// the functions exported from main_js.go, and the results expected of them.
+type call struct {
+	name     string
+	args     []any
+	expected any
+}
+
+func TestAPI_Calls(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{"play", []call{
+			{"load", []any{ROOM}, map[string]any{"id": "test/room", "hexes": 5, "crates": 1}},
+			{"state", nil, map[string]any{"ichiban": []any{2, 2}, "crates": []any{[]any{2, 3}},
+				"history": "", "moves": 0, "pushes": 0, "solved": false}},
+			{"move", []any{"ud"}, map[string]any{"steps": "ud", "moves": 2, "pushes": 0, "solved": false}},
+			{"hint", nil, map[string]any{"steps": "R", "crate": []any{2, 3}, "direction": "r", "remaining": 1}},
+			{"move", []any{"r"}, map[string]any{"steps": "R", "moves": 3, "pushes": 1, "solved": true}},
+			{"isSolved", nil, true},
+			{"hint", nil, map[string]any{"solved": true}},
+			{"undo", nil, map[string]any{"steps": "R", "moves": 2, "pushes": 0, "solved": false}},
+			{"isSolved", nil, false},
+			{"undo", []any{2.0}, map[string]any{"steps": "ud", "moves": 0, "pushes": 0, "solved": false}},
+			{"state", nil, map[string]any{"ichiban": []any{2, 2}, "crates": []any{[]any{2, 3}},
+				"history": "", "moves": 0, "pushes": 0, "solved": false}},
+		}},
+		{"deadlocks", []call{
+			{"load", []any{CORNER}, map[string]any{"id": "test/corner", "hexes": 5, "crates": 1}},
+			{"deadlocks", nil, []any{[]any{1, 2}}},
+			{"isDeadCell", []any{1.0, 2.0}, true},
+			{"isDeadCell", []any{2.0, 2.0}, false},
+			{"hint", nil, map[string]any{"deadlocked": true, "crates": []any{[]any{1, 2}}}},
+		}},
+		{"errors", []call{
+			{"move", []any{"r"}, map[string]any{"error": "no puzzle is loaded"}},
+			{"load", []any{"{"}, map[string]any{"error": "invalid puzzle: unexpected end of JSON input"}},
+			{"load", nil, map[string]any{"error": "expected the puzzle's definition as argument 1"}},
+			{"load", []any{ROOM}, map[string]any{"id": "test/room", "hexes": 5, "crates": 1}},
+			{"move", []any{"urrr"}, map[string]any{"error": "cannot move right from {1 3}, a wall is in the way", "step": 3}},
+			{"move", []any{""}, map[string]any{"error": "no directions to move in"}},
+			{"move", []any{3.0}, map[string]any{"error": "expected the directions to move in as argument 1"}},
+			{"undo", nil, map[string]any{"error": "cannot undo 1 of the 0 steps taken"}},
+			{"undo", []any{1.5}, map[string]any{"error": "expected an integer as argument 1, not 1.5"}},
+			{"isDeadCell", []any{1.0}, map[string]any{"error": "expected the hex's coordinates i and j"}},
+			{"state", nil, map[string]any{"ichiban": []any{2, 2}, "crates": []any{[]any{2, 3}},
+				"history": "", "moves": 0, "pushes": 0, "solved": false}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &api{}
+			functions := api.functions()
+			for index, call := range tt.calls {
+				fn, found := functions[call.name]
+				if !found {
+					t.Fatalf("call %d: %s is not exported", index+1, call.name)
+				}
+				result := api.call(fn, call.args)
+				if err := checkValue(result); err != "" {
+					t.Errorf("call %d: %s() returned %s", index+1, call.name, err)
+				}
+				if !reflect.DeepEqual(result, call.expected) {
+					t.Errorf("call %d: %s(%v)\n  got  %#v\n  want %#v",
+						index+1, call.name, call.args, result, call.expected)
+				}
+			}
+		})
+	}
+}
+
+// The functions documented in api.go are the ones exported.
+func TestAPI_Functions(t *testing.T) {
+	var names []string
+	for name := range (&api{}).functions() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expected := []string{"deadlocks", "hint", "isDeadCell", "isSolved", "load", "move", "state", "undo"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("exported %v, expected %v", names, expected)
+	}
+}
+
+// Describes the part of the value that js.ValueOf() would panic on, if any.
+func checkValue(value any) string {
+	switch value := value.(type) {
+	case nil, bool, int, float64, string:
+		return ""
+	case []any:
+		for _, element := range value {
+			if err := checkValue(element); err != "" {
+				return err
+			}
+		}
+		return ""
+	case map[string]any:
+		for _, element := range value {
+			if err := checkValue(element); err != "" {
+				return err
+			}
+		}
+		return ""
+	}
+	return fmt.Sprintf("a %T, which cannot be converted to a JavaScript value", value)
+}
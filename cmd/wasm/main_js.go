@@ -0,0 +1,68 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/cmd/wasm/main_js.go
+
+//go:build js && wasm
+
+package main
+
+// Main entry point for hexoban.wasm
+//
+// Runs the rules engine in the browser so that the webapp plays by exactly the
+// same rules as the Go tools.  Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o hexoban.wasm ./cmd/wasm
+//
+// and load it with the wasm_exec.js from the same Go installation (found in
+// $(go env GOROOT)/lib/wasm/ or misc/wasm/ for older releases).  Once it runs,
+// the functions in api.go are properties of the global `hexoban` object.
+
+import (
+	"syscall/js"
+)
+
+func main() {
+	api := &api{}
+	exports := js.Global().Get("Object").New()
+	for name, fn := range api.functions() {
+		fn := fn
+		exports.Set(name, js.FuncOf(func(this js.Value, args []js.Value) any {
+			values := make([]any, len(args))
+			for index, arg := range args {
+				values[index] = fromJS(arg)
+			}
+			return api.call(fn, values)
+		}))
+	}
+	js.Global().Set("hexoban", exports)
+
+	// The functions are called from JavaScript for as long as the page is open.
+	select {}
+}
+
+// Converts the argument to a Go value, objects as their JSON encoding.
+func fromJS(value js.Value) any {
+	switch value.Type() {
+	case js.TypeString:
+		return value.String()
+	case js.TypeNumber:
+		return value.Float()
+	case js.TypeBoolean:
+		return value.Bool()
+	case js.TypeObject:
+		return js.Global().Get("JSON").Call("stringify", value).String()
+	}
+	return nil
+}
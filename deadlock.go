@@ -0,0 +1,164 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/deadlock.go
+
+package hexoban
+
+// Static analysis of a puzzle's terrain and detection of positions which can
+// no longer be solved.  Only "simple" deadlocks are detected here: a crate on a
+// dead cell (one from which no goal can be reached, no matter how the other
+// crates are arranged) and crates which are frozen in place away from a goal.
+
+// Marks a cell as unreachable from any goal in the push distance table.
+const unreachable = -1
+
+// Computes the push distance from each cell to its nearest goal, as if there
+// were no other crates on the board.  Cells that cannot reach any goal are
+// assigned unreachable, these are the dead cells for the puzzle.
+//
+// The search is performed backwards from the goals by pulling: a crate at p can
+// be pushed in direction d only if the worker can stand on the opposite side.
+func (board *board) analyze() {
+	goals := make([]int, 0, len(board.cells))
+	for cell := range board.cells {
+		if board.goals[cell] {
+			goals = append(goals, cell)
+		}
+	}
+	board.distance = board.pullDistances(goals)
+}
+
+// Returns the push distance from each cell to the nearest of the target cells,
+// or unreachable if a crate there cannot be pushed onto any of them.
+func (board *board) pullDistances(targets []int) []int {
+	distance := make([]int, len(board.cells))
+	for cell := range distance {
+		distance[cell] = unreachable
+	}
+	queue := make([]int, 0, len(board.cells))
+	for _, cell := range targets {
+		distance[cell] = 0
+		queue = append(queue, cell)
+	}
+
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		for d := range Directions {
+			back := (d + 3) % 6
+			previous := board.neighbors[cell][back]
+			if previous < 0 || board.neighbors[previous][back] < 0 {
+				continue
+			}
+			if distance[previous] == unreachable {
+				distance[previous] = distance[cell] + 1
+				queue = append(queue, previous)
+			}
+		}
+	}
+	return distance
+}
+
+// Returns true if no crate at this cell can ever be pushed onto a goal.
+func (board *board) isDead(cell int) bool {
+	return board.distance[cell] == unreachable
+}
+
+// Returns true if the crate at this cell can no longer be moved along any of
+// the three axes of the grid.  An axis is blocked if there is a wall on either
+// side, dead cells on both sides, or a crate on either side that is itself
+// frozen (the crates being considered are treated as walls meanwhile).  All
+// crates found to be frozen along the way are collected into `group`.
+func (board *board) isFrozen(crates []bool, cell int, considering []bool, group *[]int) bool {
+	considering[cell] = true
+	defer func() { considering[cell] = false }()
+	found := len(*group)
+
+	for axis := 0; axis < 3; axis++ {
+		ahead, behind := board.neighbors[cell][axis], board.neighbors[cell][axis+3]
+		if ahead < 0 || behind < 0 || considering[ahead] || considering[behind] {
+			continue
+		}
+		if board.isDead(ahead) && board.isDead(behind) {
+			continue
+		}
+		if crates[ahead] && board.isFrozen(crates, ahead, considering, group) {
+			continue
+		}
+		if crates[behind] && board.isFrozen(crates, behind, considering, group) {
+			continue
+		}
+		// Any crates collected while exploring this one are not frozen after all.
+		*group = (*group)[:found]
+		return false
+	}
+	*group = append(*group, cell)
+	return true
+}
+
+// Returns the crates which are frozen along with the crate at this cell, if it
+// is part of a frozen group containing any crate that is not on a goal.
+// Returns nil when the crate can still move or its group rests on goals.
+func (board *board) frozenDeadlock(crates []bool, cell int) []int {
+	group := make([]int, 0)
+	considering := make([]bool, len(board.cells))
+	if !board.isFrozen(crates, cell, considering, &group) {
+		return nil
+	}
+	for _, frozen := range group {
+		if !board.goals[frozen] {
+			return group
+		}
+	}
+	return nil
+}
+
+// Returns true if the coordinate is a dead cell of the puzzle, a crate there can
+// never be pushed onto a goal.  Coordinates outside of the terrain are not.
+func (state *State) IsDeadCell(coord HexCoord) bool {
+	cell := state.board.lookup(coord)
+	return cell >= 0 && state.board.isDead(cell)
+}
+
+// Returns the crates which are responsible for a deadlock in this state, those
+// on dead cells and those in a frozen group away from the goals.  The position
+// is unsolvable if any crates are returned, though an empty result does not
+// guarantee that the position can still be solved.
+func (state *State) Deadlocks() []HexCoord {
+	board := state.board
+	involved := make([]bool, len(board.cells))
+	for cell, present := range state.crates {
+		if !present || involved[cell] {
+			continue
+		}
+		if board.isDead(cell) {
+			involved[cell] = true
+			continue
+		}
+		if !board.goals[cell] {
+			for _, frozen := range board.frozenDeadlock(state.crates, cell) {
+				involved[frozen] = true
+			}
+		}
+	}
+
+	crates := make([]HexCoord, 0)
+	for cell, deadlocked := range involved {
+		if deadlocked {
+			crates = append(crates, board.cells[cell])
+		}
+	}
+	return crates
+}
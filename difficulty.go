@@ -0,0 +1,159 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/difficulty.go
+
+package hexoban
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Estimates a puzzle's difficulty on a scale of 1 to 10.  As suggested in the
+// FESS notes (/fess/README.md), a deliberately weak solver is a good measure of
+// how much searching a puzzle demands, this is combined with the length of a
+// solution and the size of the puzzle in a linear model.  The model's weights
+// were fit to the difficulty ratings assigned by hand to the levels collection
+// and can be recalibrated with CalibrateDifficulty().
+
+// The number of positions the baseline advisor may expand while measuring the
+// puzzle.  Puzzles reaching this limit are all treated as equally demanding.
+const DIFFICULTY_MAX_NODES = 200000
+
+// The lowest and highest values of an estimated difficulty.
+const (
+	DIFFICULTY_MIN = 1
+	DIFFICULTY_MAX = 10
+)
+
+// Measurements of a puzzle which difficulty is estimated from.
+type DifficultyStats struct {
+	Cells int // Traversable terrain.
+
+	// Positions the BaselineAdvisor expanded before solving, or the limit of
+	// DIFFICULTY_MAX_NODES if it did not find a solution in time.
+	BaselineNodes int
+
+	// Length of the solution found by the default solver.
+	Moves  int
+	Pushes int
+}
+
+// Solves the puzzle twice, once with the default solver to find a solution
+// and once with the BaselineAdvisor to measure the effort needed.  Returns an
+// error if the puzzle is not valid or no solution could be found.
+func MeasureDifficulty(ctx context.Context, puzzle Puzzle) (DifficultyStats, error) {
+	state, err := NewState(puzzle)
+	if err != nil {
+		return DifficultyStats{}, err
+	}
+	stats := DifficultyStats{Cells: len(state.board.cells)}
+
+	solution, _, err := Solver{}.Solve(ctx, state)
+	if err != nil {
+		return stats, fmt.Errorf("could not solve %s: %w", puzzle.Identity, err)
+	}
+	stats.Moves, stats.Pushes = solution.Moves(), solution.Pushes()
+
+	baseline := Solver{Advisor: BaselineAdvisor, MaxNodes: DIFFICULTY_MAX_NODES}
+	_, effort, err := baseline.Solve(ctx, state)
+	if err != nil && !errors.Is(err, ErrBudgetExhausted) {
+		return stats, err
+	}
+	stats.BaselineNodes = effort.Nodes
+	return stats, nil
+}
+
+// The inputs to the difficulty model, a constant term followed by the solver
+// effort, solution length and puzzle size, each on a logarithmic scale.
+func (stats DifficultyStats) Features() [4]float64 {
+	return [4]float64{
+		1,
+		math.Log2(float64(stats.BaselineNodes + 1)),
+		math.Log2(float64(stats.Moves + 1)),
+		math.Log2(float64(stats.Cells + 1)),
+	}
+}
+
+// Weights for each of the DifficultyStats.Features().
+type DifficultyModel [4]float64
+
+// The model fit to the hand-assigned difficulties of the levels collection.
+var DefaultDifficultyModel = DifficultyModel{-0.90, 0.15, 0.87, 0.09}
+
+// Returns the difficulty for the measured puzzle, between DIFFICULTY_MIN and
+// DIFFICULTY_MAX inclusive.
+func (model DifficultyModel) Estimate(stats DifficultyStats) int {
+	score := 0.0
+	for index, feature := range stats.Features() {
+		score += model[index] * feature
+	}
+	return int(math.Max(DIFFICULTY_MIN, math.Min(DIFFICULTY_MAX, math.Round(score))))
+}
+
+// Fits a model to the measured puzzles and the difficulty they were rated,
+// by least squares.  Ratings above DIFFICULTY_MAX are treated as the maximum.
+func CalibrateDifficulty(samples []DifficultyStats, ratings []int) (DifficultyModel, error) {
+	model := DifficultyModel{}
+	if len(samples) != len(ratings) {
+		return model, fmt.Errorf("%d samples but %d ratings", len(samples), len(ratings))
+	}
+	if len(samples) < len(model) {
+		return model, fmt.Errorf("at least %d rated samples are needed, have %d",
+			len(model), len(samples))
+	}
+
+	// Solve the normal equations (X'X) w = X'y by Gauss-Jordan elimination,
+	// augmenting each row of X'X with its entry from X'y.
+	const n = len(model)
+	var system [n][n + 1]float64
+	for index, sample := range samples {
+		features := sample.Features()
+		rating := math.Min(float64(ratings[index]), DIFFICULTY_MAX)
+		for row := 0; row < n; row++ {
+			for col := 0; col < n; col++ {
+				system[row][col] += features[row] * features[col]
+			}
+			system[row][n] += features[row] * rating
+		}
+	}
+	for pivot := 0; pivot < n; pivot++ {
+		best := pivot
+		for row := pivot + 1; row < n; row++ {
+			if math.Abs(system[row][pivot]) > math.Abs(system[best][pivot]) {
+				best = row
+			}
+		}
+		if math.Abs(system[best][pivot]) < 1e-9 {
+			return model, fmt.Errorf("samples do not vary enough to fit the model")
+		}
+		system[pivot], system[best] = system[best], system[pivot]
+		for row := 0; row < n; row++ {
+			if row == pivot {
+				continue
+			}
+			factor := system[row][pivot] / system[pivot][pivot]
+			for col := pivot; col <= n; col++ {
+				system[row][col] -= factor * system[pivot][col]
+			}
+		}
+	}
+	for index := range model {
+		model[index] = system[index][n] / system[index][index]
+	}
+	return model, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/difficulty_test.go
+
+package hexoban
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestMeasureDifficulty(t *testing.T) {
+	ctx := context.Background()
+	easy, err := MeasureDifficulty(ctx, loadLevel(t, "LukaszM/01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	harder, err := MeasureDifficulty(ctx, loadLevel(t, "Heroban/Heroban 1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if easy.Moves < 2 || easy.Pushes != 2 || easy.BaselineNodes == 0 {
+		t.Errorf("MeasureDifficulty() = %+v", easy)
+	}
+
+	model := DefaultDifficultyModel
+	if model.Estimate(easy) > DIFFICULTY_MIN+1 || model.Estimate(harder) <= model.Estimate(easy) {
+		t.Errorf("Estimate() = %d for %+v and %d for %+v", model.Estimate(easy), easy,
+			model.Estimate(harder), harder)
+	}
+	if model.Estimate(DifficultyStats{DIFFICULTY_MAX_NODES, 1 << 20, 1 << 20, 999}) != DIFFICULTY_MAX {
+		t.Error("expected estimates to be limited to DIFFICULTY_MAX")
+	}
+}
+
+func TestCalibrateDifficulty(t *testing.T) {
+	// Ratings produced by a known model are recovered, within rounding.
+	known := DifficultyModel{0.5, 0.25, 0.75, 0.1}
+	samples := []DifficultyStats{
+		{Cells: 10, BaselineNodes: 5, Moves: 10},
+		{Cells: 20, BaselineNodes: 50, Moves: 40},
+		{Cells: 40, BaselineNodes: 5000, Moves: 30},
+		{Cells: 80, BaselineNodes: 500, Moves: 100},
+		{Cells: 30, BaselineNodes: 50000, Moves: 60},
+	}
+	ratings := make([]int, len(samples))
+	exact := make([]float64, len(samples))
+	for index, sample := range samples {
+		for feature, value := range sample.Features() {
+			exact[index] += known[feature] * value
+		}
+		ratings[index] = int(math.Round(exact[index]))
+	}
+
+	model, err := CalibrateDifficulty(samples, ratings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, sample := range samples {
+		if difference := model.Estimate(sample) - ratings[index]; difference*difference > 1 {
+			t.Errorf("calibrated model %v estimates %d for sample %d, rated %d",
+				model, model.Estimate(sample), index, ratings[index])
+		}
+	}
+
+	if _, err := CalibrateDifficulty(samples[:2], ratings[:2]); err == nil {
+		t.Error("expected an error when there are too few samples")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/direction.go
+
+package hexoban
+
+import "fmt"
+
+// One of the six cardinal directions on the hexagonal grid.  The value of each
+// is its (lowercase) letter in hex-LURD notation, matching the Direction type
+// of the webapp (see /webapp/src/hexgrid/map.ts).
+//
+// .   backward /^\ up
+// .           /   \
+// .      left |   | right
+// .           \   /
+// .       down \v/ forward
+type Direction byte
+
+const (
+	DIR_UP       Direction = 'u'
+	DIR_BACKWARD Direction = 'b'
+	DIR_LEFT     Direction = 'l'
+	DIR_DOWN     Direction = 'd'
+	DIR_FORWARD  Direction = 'f'
+	DIR_RIGHT    Direction = 'r'
+)
+
+// All six directions, in counter-clockwise order beginning with DIR_UP.
+// A direction's opposite is always found three positions away in this list.
+var Directions = [6]Direction{
+	DIR_UP, DIR_BACKWARD, DIR_LEFT, DIR_DOWN, DIR_FORWARD, DIR_RIGHT,
+}
+
+// Returns the direction for the hex-LURD letter, case-insensitive.
+func ParseDirection(letter byte) (Direction, error) {
+	if 'A' <= letter && letter <= 'Z' {
+		letter += 'a' - 'A'
+	}
+	dir := Direction(letter)
+	if dir.Index() < 0 {
+		return dir, fmt.Errorf("unknown direction '%c'", letter)
+	}
+	return dir, nil
+}
+
+// Returns the position of this direction within Directions, or -1 if invalid.
+func (dir Direction) Index() int {
+	for index, other := range Directions {
+		if dir == other {
+			return index
+		}
+	}
+	return -1
+}
+
+// Returns the direction pointing the opposite way of this one.
+func (dir Direction) Opposite() Direction {
+	return Directions[(dir.Index()+3)%6]
+}
+
+// Returns the (di, dj) unit vector for this direction.
+func (dir Direction) Delta() (int, int) {
+	switch dir {
+	case DIR_UP:
+		return -1, 0
+	case DIR_BACKWARD:
+		return -1, -1
+	case DIR_LEFT:
+		return 0, -1
+	case DIR_DOWN:
+		return 1, 0
+	case DIR_FORWARD:
+		return 1, 1
+	case DIR_RIGHT:
+		return 0, 1
+	}
+	return 0, 0
+}
+
+// Satisfies the fmt.Stringer interface with the full name of the direction.
+func (dir Direction) String() string {
+	switch dir {
+	case DIR_UP:
+		return "up"
+	case DIR_BACKWARD:
+		return "backward"
+	case DIR_LEFT:
+		return "left"
+	case DIR_DOWN:
+		return "down"
+	case DIR_FORWARD:
+		return "forward"
+	case DIR_RIGHT:
+		return "right"
+	}
+	return fmt.Sprintf("Direction(%q)", byte(dir))
+}
+
+// Returns the adjacent coordinate in the indicated direction.  The neighbor
+// may not exist in a puzzle's terrain, check with the puzzle or its State.
+func (coord HexCoord) Neighbor(dir Direction) HexCoord {
+	di, dj := dir.Delta()
+	return HexCoord{coord.i + di, coord.j + dj}
+}
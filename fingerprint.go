@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/fingerprint.go
+
+package hexoban
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Returns a digest of the puzzle's layout which is the same for any puzzle
+// that plays identically, regardless of its metadata, the order in which its
+// coordinates are listed, where on the grid it is placed, or which of the
+// worker's initially reachable cells it begins on.  Returns an error for
+// puzzles that NewState() would not accept.
+func (puzzle Puzzle) Fingerprint() (string, error) {
+	state, err := NewState(puzzle)
+	if err != nil {
+		return "", err
+	}
+	board := state.board
+	origin := board.cells[0]
+	for _, coord := range board.cells {
+		origin = HexCoord{min(origin.i, coord.i), min(origin.j, coord.j)}
+	}
+	worker := board.cells[state.ichiban]
+	for cell, reachable := range board.reachable(state.crates, state.ichiban) {
+		if reachable && compareCoords(board.cells[cell], worker) < 0 {
+			worker = board.cells[cell]
+		}
+	}
+
+	var canonical strings.Builder
+	section := func(name string, coords []HexCoord) {
+		sorted := append([]HexCoord(nil), coords...)
+		slices.SortFunc(sorted, compareCoords)
+		canonical.WriteString(name)
+		for _, coord := range sorted {
+			fmt.Fprintf(&canonical, " %d,%d", coord.i-origin.i, coord.j-origin.j)
+		}
+		canonical.WriteByte('\n')
+	}
+	section("terrain", board.cells)
+	section("goals", puzzle.Init.Goals)
+	section("crates", puzzle.Init.Crates)
+	section("ichiban", []HexCoord{worker})
+
+	digest := sha256.Sum256([]byte(canonical.String()))
+	return hex.EncodeToString(digest[:16]), nil
+}
+
+// Orders coordinates back-to-front, by `i` and then by `j`.
+func compareCoords(a, b HexCoord) int {
+	if a.i != b.i {
+		return a.i - b.i
+	}
+	return a.j - b.j
+}
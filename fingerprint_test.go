@@ -0,0 +1,70 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/fingerprint_test.go
+
+package hexoban
+
+import "testing"
+
+func TestPuzzle_Fingerprint(t *testing.T) {
+	at := NewHexCoord
+	base := treasureRoom()
+	expected, err := base.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) != 32 {
+		t.Errorf("Fingerprint() = %q, expected 32 hex digits", expected)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Puzzle)
+		same   bool
+	}{
+		{"metadata", func(p *Puzzle) { p.Title, p.Author, p.Difficulty = "Other", "Someone", 3 }, true},
+		{"reordered terrain", func(p *Puzzle) {
+			p.Terrain = []HexCoord{at(2, 4), at(2, 3), at(2, 2), at(1, 3), at(1, 2)}
+		}, true},
+		{"translated", func(p *Puzzle) {
+			for i, coord := range p.Terrain {
+				p.Terrain[i] = at(coord.I()+3, coord.J()-1)
+			}
+			p.Init = Init{Goals: []HexCoord{at(5, 3)}, Crates: []HexCoord{at(5, 2)}, Ichiban: at(5, 1)}
+		}, true},
+		{"reachable ichiban", func(p *Puzzle) { p.Init.Ichiban = at(1, 2) }, true},
+		{"moved goal", func(p *Puzzle) { p.Init.Goals = []HexCoord{at(1, 3)} }, false},
+		{"extra floor", func(p *Puzzle) { p.Terrain = append(p.Terrain, at(3, 3)) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzle := treasureRoom()
+			tt.modify(&puzzle)
+			got, err := puzzle.Fingerprint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == expected) != tt.same {
+				t.Errorf("Fingerprint() = %s, base is %s, expected same = %t", got, expected, tt.same)
+			}
+		})
+	}
+
+	invalid := treasureRoom()
+	invalid.Init.Crates = nil
+	if _, err := invalid.Fingerprint(); err == nil {
+		t.Error("Fingerprint() of an unplayable puzzle, expected an error")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/gdl.go
+
+package hexoban
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Translates a single level into the Game Description Language (GDL), as a
+// rule sheet in KIF syntax that a General Game Player can load.  The level's
+// layout is given as ground facts and the rules of hexoban are given in terms
+// of the relations `role`, `init`, `base`, `input`, `legal`, `next`, `terminal`
+// and `goal`, see /fess/README.md.
+//
+// Coordinates are translated so that the least `i` and `j` are zero, because
+// some GDL tools do not accept negative numbers as constants.  The game has a
+// step limit to guarantee termination (GDL requires finite games), twenty steps
+// per cell of terrain is more than any of the known levels' solutions need.
+//
+// Relations specific to hexoban:
+//
+//	(cell ?i ?j)                    traversable terrain
+//	(target ?i ?j)                  a goal (crates should be moved here)
+//	(adjacent ?d ?i ?j ?ni ?nj)     (ni, nj) is next to (i, j) in direction ?d
+//	(ichiban ?i ?j)                 state: the position of the worker
+//	(crate ?i ?j)                   state: the position of a crate
+//	(step ?n)                       state: the number of steps taken
+//	(move ?d)                       the only action, moving and maybe pushing
+func ToGDL(puzzle Puzzle) (string, error) {
+	state, err := NewState(puzzle)
+	if err != nil {
+		return "", err
+	}
+	board := state.board
+
+	minI, minJ := board.cells[0].i, board.cells[0].j
+	for _, coord := range board.cells {
+		minI, minJ = min(minI, coord.i), min(minJ, coord.j)
+	}
+	at := func(coord HexCoord) string {
+		return fmt.Sprintf("%d %d", coord.i-minI, coord.j-minJ)
+	}
+	stepLimit := 20 * len(board.cells)
+
+	var gdl strings.Builder
+	fmt.Fprintf(&gdl, ";; %s by %s (%s)\n", puzzle.Title, puzzle.Author, puzzle.Identity)
+	fmt.Fprintf(&gdl, ";; hexoban level translated by (%d, %d), limited to %d steps.\n\n",
+		-minI, -minJ, stepLimit)
+	gdl.WriteString("(role ichiban)\n\n")
+
+	gdl.WriteString(";; The level's terrain, goals and their adjacency.\n")
+	for _, coord := range board.cells {
+		fmt.Fprintf(&gdl, "(cell %s)\n", at(coord))
+	}
+	for cell, coord := range board.cells {
+		if board.goals[cell] {
+			fmt.Fprintf(&gdl, "(target %s)\n", at(coord))
+		}
+	}
+	for _, dir := range Directions {
+		fmt.Fprintf(&gdl, "(direction %c)\n", dir)
+	}
+	for cell, coord := range board.cells {
+		for d, next := range board.neighbors[cell] {
+			if next >= 0 {
+				fmt.Fprintf(&gdl, "(adjacent %c %s %s)\n",
+					Directions[d], at(coord), at(board.cells[next]))
+			}
+		}
+	}
+	for step := 0; step < stepLimit; step++ {
+		fmt.Fprintf(&gdl, "(succ %d %d)\n", step, step+1)
+	}
+
+	gdl.WriteString("\n;; Initial state.\n")
+	fmt.Fprintf(&gdl, "(init (ichiban %s))\n", at(state.Ichiban()))
+	for _, crate := range state.Crates() {
+		fmt.Fprintf(&gdl, "(init (crate %s))\n", at(crate))
+	}
+	gdl.WriteString("(init (step 0))\n")
+
+	gdl.WriteString(gdlRules)
+	fmt.Fprintf(&gdl, "(<= terminal (true (step %d)))\n", stepLimit)
+	return gdl.String(), nil
+}
+
+// The rules which are the same for every level.
+const gdlRules = `
+;; Base propositions and inputs.
+(<= (base (ichiban ?i ?j)) (cell ?i ?j))
+(<= (base (crate ?i ?j)) (cell ?i ?j))
+(base (step 0))
+(<= (base (step ?n)) (succ ?m ?n))
+(<= (input ichiban (move ?d)) (direction ?d))
+
+;; The worker may move onto any empty cell, or push a crate onto an empty cell.
+(<= (legal ichiban (move ?d))
+    (true (ichiban ?i ?j))
+    (adjacent ?d ?i ?j ?ni ?nj)
+    (not (true (crate ?ni ?nj))))
+(<= (legal ichiban (move ?d))
+    (true (ichiban ?i ?j))
+    (adjacent ?d ?i ?j ?ni ?nj)
+    (true (crate ?ni ?nj))
+    (adjacent ?d ?ni ?nj ?mi ?mj)
+    (not (true (crate ?mi ?mj))))
+
+;; Moving updates the worker's position, and the position of a pushed crate.
+(<= (next (ichiban ?ni ?nj))
+    (does ichiban (move ?d))
+    (true (ichiban ?i ?j))
+    (adjacent ?d ?i ?j ?ni ?nj))
+(<= (pushed ?ni ?nj)
+    (does ichiban (move ?d))
+    (true (ichiban ?i ?j))
+    (adjacent ?d ?i ?j ?ni ?nj)
+    (true (crate ?ni ?nj)))
+(<= (next (crate ?mi ?mj))
+    (does ichiban (move ?d))
+    (true (ichiban ?i ?j))
+    (adjacent ?d ?i ?j ?ni ?nj)
+    (true (crate ?ni ?nj))
+    (adjacent ?d ?ni ?nj ?mi ?mj))
+(<= (next (crate ?i ?j))
+    (true (crate ?i ?j))
+    (not (pushed ?i ?j)))
+(<= (next (step ?n))
+    (true (step ?m))
+    (succ ?m ?n))
+
+;; The game is won when every goal has a crate on it.
+(<= open_target
+    (target ?i ?j)
+    (not (true (crate ?i ?j))))
+(<= (goal ichiban 100) (not open_target))
+(<= (goal ichiban 0) open_target)
+(<= terminal (not open_target))
+`
@@ -0,0 +1,87 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/gdl_test.go
+
+package hexoban
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToGDL(t *testing.T) {
+	gdl, err := ToGDL(treasureRoom())
+	if err != nil {
+		t.Fatalf("ToGDL() error = %v", err)
+	}
+
+	depth := 0
+	for _, line := range strings.Split(gdl, "\n") {
+		if strings.HasPrefix(line, ";") {
+			continue
+		}
+		for _, char := range line {
+			switch char {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth < 0 {
+				t.Fatalf("unbalanced ')' in line %q", line)
+			}
+		}
+	}
+	if depth != 0 {
+		t.Errorf("ToGDL() has %d unclosed '('", depth)
+	}
+
+	// The treasure room's coordinates are translated by (-1, -2).
+	for _, want := range []string{
+		"(role ichiban)",
+		"(cell 0 0)",
+		"(cell 1 2)",
+		"(target 1 2)",
+		"(adjacent r 1 0 1 1)",
+		"(adjacent l 1 1 1 0)",
+		"(adjacent f 0 0 1 1)",
+		"(adjacent b 1 1 0 0)",
+		"(init (ichiban 1 0))",
+		"(init (crate 1 1))",
+		"(init (step 0))",
+		"(<= terminal (true (step 100)))",
+		"(<= (goal ichiban 100) (not open_target))",
+	} {
+		if !strings.Contains(gdl, want+"\n") {
+			t.Errorf("ToGDL() is missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"(cell 2 2)", "(adjacent r 1 2", "(target 1 1)"} {
+		if strings.Contains(gdl, unwanted) {
+			t.Errorf("ToGDL() unexpectedly contains %q", unwanted)
+		}
+	}
+
+	// Every adjacency has its opposite, 2 per edge between cells of terrain.
+	if count := strings.Count(gdl, "\n(adjacent "); count != 14 {
+		t.Errorf("ToGDL() has %d adjacency facts, want 14", count)
+	}
+
+	invalid := treasureRoom()
+	invalid.Init.Crates = nil
+	if _, err := ToGDL(invalid); err == nil {
+		t.Errorf("ToGDL() of an invalid puzzle should return an error")
+	}
+}
@@ -70,10 +70,11 @@ func (puzzle Puzzle) Info() string {
 }
 
 // The HexCoord has a basis of two unit vectors (i, j) corresponding to
-// forward (downward-right in pixel coordinates) and down (or downward-left
-// in pixel coords).  This results in a back-to-front ordering if sorted by
-// (i + j), with ties broken by ascending `i`.  It is also a more suitable
-// representation for translations, rotations and other affine transformations.
+// down (downward-left in pixel coordinates) and right.  This results in a
+// back-to-front ordering if sorted by `i`, with ties broken by ascending `j`.
+// The third axis, forward (downward-right), is their sum (i + 1, j + 1).  It is
+// also a more suitable representation for translations, rotations and other
+// affine transformations.
 //
 // The representation is serialized into JSON as a two-element array, [i, j].
 type HexCoord struct {
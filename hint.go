@@ -0,0 +1,76 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/hint.go
+
+package hexoban
+
+import (
+	"context"
+	"errors"
+)
+
+// The number of positions Hint will expand before giving up, in addition to
+// any deadline or cancellation of the context it is given.
+const HINT_MAX_NODES = 250000
+
+// The result of asking for a hint, either the next push to make or the reason
+// that no push can be suggested (already solved, or deadlocked).
+type Suggestion struct {
+	Solved     bool       // The position is already solved.
+	Deadlocked bool       // The position can no longer be solved.
+	DeadCrates []HexCoord // Crates causing the deadlock, if they were identified.
+
+	Steps     Solution  // Moves to reach the crate, ending with the push itself.
+	Crate     HexCoord  // The crate to push, before it is pushed.
+	Direction Direction // The direction to push it in.
+	Remaining int       // Pushes in the solution found, including this one.
+}
+
+// Solves from the state's current position, within the budget of the context
+// and HINT_MAX_NODES, and suggests the next push to make.  Instead of spoiling
+// the whole solution only the first push (and the walk to reach it) is given.
+// If the position is deadlocked the suggestion says so and lists the crates
+// responsible when the deadlock is a simple one.  An error (wrapping
+// ErrBudgetExhausted) is returned if no solution was found within the budget.
+func Hint(ctx context.Context, state *State) (Suggestion, error) {
+	if state.IsSolved() {
+		return Suggestion{Solved: true}, nil
+	}
+	if dead := state.Deadlocks(); len(dead) > 0 {
+		return Suggestion{Deadlocked: true, DeadCrates: dead}, nil
+	}
+
+	solver := Solver{Advisor: WeightedAdvisor, MaxNodes: HINT_MAX_NODES}
+	solution, _, err := solver.Solve(ctx, state)
+	if errors.Is(err, ErrNoSolution) {
+		return Suggestion{Deadlocked: true}, nil
+	}
+	if err != nil {
+		return Suggestion{}, err
+	}
+
+	suggestion := Suggestion{Remaining: solution.Pushes()}
+	walker := state.Clone()
+	for index, step := range solution {
+		if step.IsPush() {
+			suggestion.Steps = solution[:index+1]
+			suggestion.Crate = walker.Ichiban().Neighbor(step.Direction())
+			suggestion.Direction = step.Direction()
+			break
+		}
+		walker.Move(step.Direction())
+	}
+	return suggestion, nil
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/internal/jsonedit/jsonedit.go
+
+// Package jsonedit changes the properties of a JSON object within its text,
+// so that the properties it does not touch, their order and the file's layout
+// are all kept as they were.  It is shared by the tools which update the
+// levels' files.
+package jsonedit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A JSON object's text and the location of its properties' values.
+type Object struct {
+	data       []byte
+	properties map[string][2]int // the start and end offsets of each value.
+	keys       []string          // in the order they appear.
+	opening    int               // the offset just after the opening brace.
+	closing    int               // the offset of the closing brace.
+	indent     string            // the indentation of its properties.
+}
+
+// Scans the top-level properties of the JSON object.  The depth indicates the
+// indentation of the object itself, in spaces.
+func Parse(data []byte, depth int) (*Object, error) {
+	object := &Object{data: data, properties: make(map[string][2]int)}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	object.opening = int(decoder.InputOffset())
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		end := int(decoder.InputOffset())
+		key := token.(string)
+		object.properties[key] = [2]int{end - len(value), end}
+		object.keys = append(object.keys, key)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	object.closing = int(decoder.InputOffset()) - 1
+
+	object.indent = strings.Repeat(" ", depth+2)
+	if len(object.keys) > 0 {
+		start := object.properties[object.keys[0]][0]
+		lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+		line := data[lineStart:start]
+		object.indent = string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+	}
+	return object, nil
+}
+
+// Returns the object's text, including any changes made to it.
+func (object *Object) Bytes() []byte {
+	return object.data
+}
+
+// Returns the indentation of the object's properties, which is also used for
+// the properties added to it.
+func (object *Object) Indent() string {
+	return object.indent
+}
+
+func (object *Object) Has(key string) bool {
+	_, exists := object.properties[key]
+	return exists
+}
+
+// Returns the text of the property's value, if the property exists.
+func (object *Object) Get(key string) ([]byte, bool) {
+	span, exists := object.properties[key]
+	if !exists {
+		return nil, false
+	}
+	return object.data[span[0]:span[1]], true
+}
+
+// Replaces the value of the property, or adds it as the last property.  The
+// value must be valid JSON, or an error is returned and the object unchanged.
+func (object *Object) Set(key, value string) error {
+	var updated []byte
+	if span, exists := object.properties[key]; exists {
+		updated = append(updated, object.data[:span[0]]...)
+		updated = append(updated, value...)
+		updated = append(updated, object.data[span[1]:]...)
+	} else {
+		body := bytes.TrimRight(object.data[:object.closing], " \t\r\n")
+		updated = append(updated, body...)
+		if len(object.keys) > 0 {
+			updated = append(updated, ',')
+		}
+		updated = append(updated, fmt.Sprintf("\n%s%q: %s", object.indent, key, value)...)
+		closing := object.data[len(body):]
+		if bytes.IndexByte(closing[:object.closing-len(body)], '\n') < 0 {
+			// The closing brace was on the same line, move it to its own.
+			updated = append(updated, '\n')
+			updated = append(updated, object.indent[min(2, len(object.indent)):]...)
+			closing = object.data[object.closing:]
+		}
+		updated = append(updated, closing...)
+	}
+
+	if err := object.reparse(updated); err != nil {
+		return fmt.Errorf("invalid JSON after setting %q: %w", key, err)
+	}
+	return nil
+}
+
+// Removes the property, along with the comma separating it from another.
+func (object *Object) Remove(key string) error {
+	index := slices.Index(object.keys, key)
+	if index < 0 {
+		return nil
+	}
+	// From the end of the value before it, or from the opening brace through
+	// the comma after it when it is the first property.
+	start, end := object.opening, object.properties[key][1]
+	if index > 0 {
+		start = object.properties[object.keys[index-1]][1]
+	} else if len(object.keys) > 1 {
+		end += bytes.IndexByte(object.data[end:], ',') + 1
+	}
+	updated := append(append([]byte{}, object.data[:start]...), object.data[end:]...)
+	if err := object.reparse(updated); err != nil {
+		return fmt.Errorf("invalid JSON after removing %q: %w", key, err)
+	}
+	return nil
+}
+
+// Scans the updated text again, for the offsets of the properties after the
+// change.  The object is unchanged if the text is not a valid object.
+func (object *Object) reparse(updated []byte) error {
+	reparsed, err := Parse(updated, 0)
+	if err != nil {
+		return err
+	}
+	reparsed.indent = object.indent
+	*object = *reparsed
+	return nil
+}
+
+// Quotes the string as JSON, without escaping HTML characters (as found in
+// the source URLs).
+func Quote(value string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimRight(buffer.String(), "\n")
+}
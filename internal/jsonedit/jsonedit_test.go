@@ -0,0 +1,91 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/internal/jsonedit/jsonedit_test.go
+
+package jsonedit
+
+import "testing"
+
+func TestObject_Set(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		key   string
+		value string
+		want  string
+	}{
+		{"replace", "{\n  \"a\": 1,\n  \"b\": 2\n}", "a", "[3]", "{\n  \"a\": [3],\n  \"b\": 2\n}"},
+		{"append", "{\n  \"a\": 1\n}\n", "b", "2", "{\n  \"a\": 1,\n  \"b\": 2\n}\n"},
+		{"empty", "{}", "a", `"x"`, "{\n  \"a\": \"x\"\n}"},
+		{"one line", `{"a": 1}`, "b", "2", "{\"a\": 1,\n\"b\": 2\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object, err := Parse([]byte(tt.json), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := object.Set(tt.key, tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if string(object.Bytes()) != tt.want || !object.Has(tt.key) {
+				t.Errorf("Set(%q) = %q, want %q", tt.key, object.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestObject_Remove(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		key  string
+		want string
+	}{
+		{"first", "{\n  \"a\": 1,\n  \"b\": [2, 3]\n}", "a", "{\n  \"b\": [2, 3]\n}"},
+		{"last", "{\n  \"a\": 1,\n  \"b\": [2, 3]\n}", "b", "{\n  \"a\": 1\n}"},
+		{"only", "{\n  \"a\": 1\n}", "a", "{\n}"},
+		{"one line", `{"a": 1, "b": 2, "c": 3}`, "b", `{"a": 1, "c": 3}`},
+		{"missing", `{"a": 1}`, "b", `{"a": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object, err := Parse([]byte(tt.json), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := object.Remove(tt.key); err != nil {
+				t.Fatal(err)
+			}
+			if string(object.Bytes()) != tt.want || object.Has(tt.key) {
+				t.Errorf("Remove(%q) = %s, want %s", tt.key, object.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+// Setting a value which is not valid JSON is an error, not a panic.
+func TestObject_SetInvalid(t *testing.T) {
+	object, err := Parse([]byte(`{"a": 1}`), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := object.Set("a", "{"); err == nil {
+		t.Errorf("Set() of invalid JSON succeeded: %s", object.Bytes())
+	}
+	if string(object.Bytes()) != `{"a": 1}` {
+		t.Errorf("failed Set() changed the object to %s", object.Bytes())
+	}
+}
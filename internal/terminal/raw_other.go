@@ -0,0 +1,38 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/internal/terminal/raw_other.go
+
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package terminal
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type State struct{}
+
+func MakeRaw(fd int) (*State, error) {
+	return nil, fmt.Errorf("raw terminal mode is not supported on %s", runtime.GOOS)
+}
+
+func Restore(fd int, state *State) error {
+	return nil
+}
+
+func IsTerminal(fd int) bool {
+	return false
+}
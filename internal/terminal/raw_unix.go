@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/internal/terminal/raw_unix.go
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// The terminal's settings, as they were before entering raw mode.
+type State struct {
+	termios syscall.Termios
+}
+
+// Puts the terminal into raw mode, each key is read as it is pressed, without
+// echo, line editing or signals from control keys.  Returns the previous state
+// for restoring with Restore.
+func MakeRaw(fd int) (*State, error) {
+	state := &State{}
+	if err := ioctlTermios(fd, IOCTL_GET_TERMIOS, &state.termios); err != nil {
+		return nil, err
+	}
+	raw := state.termios
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctlTermios(fd, IOCTL_SET_TERMIOS, &raw); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func Restore(fd int, state *State) error {
+	return ioctlTermios(fd, IOCTL_SET_TERMIOS, &state.termios)
+}
+
+// Returns true if the file descriptor is a terminal (and not a file or pipe).
+func IsTerminal(fd int) bool {
+	var termios syscall.Termios
+	return ioctlTermios(fd, IOCTL_GET_TERMIOS, &termios) == nil
+}
+
+func ioctlTermios(fd int, request uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
+		uintptr(fd), request, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/internal/terminal/terminal.go
+
+// Package terminal runs full-screen programs in a terminal using only its raw
+// mode and ANSI escape sequences, without any dependencies (so that they also
+// work over ssh).  Keys are read as they are pressed and the whole screen is
+// drawn again after each of them.
+package terminal
+
+import (
+	"io"
+	"os"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// Names for the keys which are not a single printable character.
+const (
+	KEY_UP        = "up"
+	KEY_DOWN      = "down"
+	KEY_RIGHT     = "right"
+	KEY_LEFT      = "left"
+	KEY_BACKSPACE = "backspace"
+	KEY_DELETE    = "delete"
+	KEY_ENTER     = "\r"
+	KEY_ESCAPE    = "\x1b"
+	KEY_CTRL_C    = "\x03"
+	KEY_CTRL_R    = "\x12"
+	KEY_CTRL_S    = "\x13"
+	KEY_CTRL_Y    = "\x19"
+	KEY_CTRL_Z    = "\x1a"
+)
+
+// The keys for moving in the six directions of the hex grid, those around 's':
+//
+//	 w e        backward  up
+//	a   d     left          right
+//	 z x          down  forward
+//
+// or the arrow keys (for left, right, up and down).
+var MOVES = map[string]hexoban.Direction{
+	"w": hexoban.DIR_BACKWARD, "e": hexoban.DIR_UP,
+	"a": hexoban.DIR_LEFT, "d": hexoban.DIR_RIGHT,
+	"z": hexoban.DIR_DOWN, "x": hexoban.DIR_FORWARD,
+	KEY_LEFT: hexoban.DIR_LEFT, KEY_RIGHT: hexoban.DIR_RIGHT,
+	KEY_UP: hexoban.DIR_UP, KEY_DOWN: hexoban.DIR_DOWN,
+}
+
+// Escape sequences for drawing the screen.
+const (
+	CLEAR = "\x1b[H\x1b[2J" // moves the cursor to the top-left, clears the screen.
+	BOLD  = "\x1b[1m"
+	DIM   = "\x1b[2m"
+	RESET = "\x1b[0m"
+)
+
+// Runs the program on stdin and stdout, in the terminal's alternate screen with
+// its cursor hidden.  The screen is filled with the result of draw() and then
+// each key pressed is passed to handle(), until it returns true.  Lines drawn
+// must end with "\r\n", raw mode does not return the cursor on a newline.
+func Run(draw func() string, handle func(key string) bool) error {
+	fd := int(os.Stdin.Fd())
+	saved, err := MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer Restore(fd, saved)
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	defer os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+	return loop(os.Stdin, os.Stdout, draw, handle)
+}
+
+func loop(input io.Reader, output io.Writer, draw func() string, handle func(key string) bool) error {
+	buffer := make([]byte, 64)
+	for {
+		if _, err := io.WriteString(output, draw()); err != nil {
+			return err
+		}
+		count, err := input.Read(buffer)
+		if err != nil {
+			return err
+		}
+		for _, key := range ParseKeys(buffer[:count]) {
+			if handle(key) {
+				return nil
+			}
+		}
+	}
+}
+
+// Splits the terminal's input into keys, naming the escape sequences of the
+// arrow keys and delete.  Other escape sequences are ignored.
+func ParseKeys(input []byte) []string {
+	keys := make([]string, 0, len(input))
+	for len(input) > 0 {
+		if input[0] == 0x1b && len(input) > 2 && (input[1] == '[' || input[1] == 'O') {
+			// A control sequence ends with a byte in the range '@' to '~'.
+			end := 2
+			for end < len(input) && (input[end] < '@' || input[end] > '~') {
+				end++
+			}
+			switch string(input[2:min(end+1, len(input))]) {
+			case "A":
+				keys = append(keys, KEY_UP)
+			case "B":
+				keys = append(keys, KEY_DOWN)
+			case "C":
+				keys = append(keys, KEY_RIGHT)
+			case "D":
+				keys = append(keys, KEY_LEFT)
+			case "3~":
+				keys = append(keys, KEY_DELETE)
+			}
+			input = input[min(end+1, len(input)):]
+			continue
+		}
+		switch input[0] {
+		case 0x7f, 0x08:
+			keys = append(keys, KEY_BACKSPACE)
+		case '\n':
+			keys = append(keys, KEY_ENTER)
+		default:
+			keys = append(keys, string(input[:1]))
+		}
+		input = input[1:]
+	}
+	return keys
+}
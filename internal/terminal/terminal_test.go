@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/internal/terminal/terminal_test.go
+
+package terminal
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"wed", []string{"w", "e", "d"}},
+		{"\x1b[A\x1b[Dx", []string{KEY_UP, KEY_LEFT, "x"}},
+		{"\x1bOC", []string{KEY_RIGHT}},
+		{"\x1b[3~\x7f", []string{KEY_DELETE, KEY_BACKSPACE}},
+		{"\x1b[1;5H#", []string{"#"}},
+		{"\x13\r\n", []string{KEY_CTRL_S, KEY_ENTER, KEY_ENTER}},
+		{"\x1b", []string{KEY_ESCAPE}},
+	}
+	for _, tt := range tests {
+		if got := ParseKeys([]byte(tt.input)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseKeys(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLoop(t *testing.T) {
+	var output strings.Builder
+	draws, keys := 0, []string{}
+	draw := func() string {
+		draws++
+		return CLEAR + "screen\r\n"
+	}
+	handle := func(key string) bool {
+		keys = append(keys, key)
+		return key == "q"
+	}
+
+	err := loop(strings.NewReader("ab\x1b[Aq"), &output, draw, handle)
+	if err != nil || !reflect.DeepEqual(keys, []string{"a", "b", KEY_UP, "q"}) {
+		t.Errorf("loop() = %v, keys %q", err, keys)
+	}
+	if draws != 1 || output.String() != CLEAR+"screen\r\n" {
+		t.Errorf("drawn %d times: %q", draws, output.String())
+	}
+
+	// The input ending before the program does is an error.
+	err = loop(strings.NewReader("ab"), io.Discard, draw, handle)
+	if err != io.EOF {
+		t.Errorf("loop() at end of input = %v", err)
+	}
+}
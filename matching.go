@@ -0,0 +1,124 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/matching.go
+
+package hexoban
+
+// Pairs each crate with a goal, such that the sum of the push distances between
+// them is the least possible.  The distances are measured as if there were no
+// other crates on the board, so this is the same relaxation as the solver's
+// lower bound and not necessarily the order in which a solution places them.
+
+// Returns the goal matched with each crate, for the crates that can be pushed
+// onto the goal they are matched with.  As many crates as possible are matched
+// with a goal they can reach, if some crates are left out the position cannot
+// be solved (though it may be unsolvable anyway).
+func (state *State) Matching() map[HexCoord]HexCoord {
+	board := state.board
+	crates, goals := []int{}, []int{}
+	for cell := range board.cells {
+		if state.crates[cell] {
+			crates = append(crates, cell)
+		}
+		if board.goals[cell] {
+			goals = append(goals, cell)
+		}
+	}
+
+	// Any pairing of reachable goals costs less than one unreachable goal does.
+	penalty := len(board.cells)*len(crates) + 1
+	cost := make([][]int, len(crates))
+	for index := range cost {
+		cost[index] = make([]int, len(goals))
+	}
+	for g, goal := range goals {
+		distance := board.pullDistances([]int{goal})
+		for c, crate := range crates {
+			cost[c][g] = distance[crate]
+			if distance[crate] == unreachable {
+				cost[c][g] = penalty
+			}
+		}
+	}
+
+	matching := make(map[HexCoord]HexCoord, len(crates))
+	for c, g := range minimumAssignment(cost) {
+		if g >= 0 && cost[c][g] != penalty {
+			matching[board.cells[crates[c]]] = board.cells[goals[g]]
+		}
+	}
+	return matching
+}
+
+// Solves the assignment problem for the (square) cost matrix with the Hungarian
+// algorithm, returning the column assigned to each row.
+func minimumAssignment(cost [][]int) []int {
+	n := len(cost)
+	// Potentials for rows (u) and columns (v), and the row assigned to each
+	// column (rowOf), are 1-indexed so that column 0 can stand for the row being
+	// added to the assignment.
+	u, v := make([]int, n+1), make([]int, n+1)
+	rowOf, way := make([]int, n+1), make([]int, n+1)
+	for row := 1; row <= n; row++ {
+		rowOf[0] = row
+		column := 0
+		least := make([]int, n+1)
+		used := make([]bool, n+1)
+		for index := range least {
+			least[index] = int(^uint(0) >> 1)
+		}
+		for rowOf[column] != 0 {
+			used[column] = true
+			current, delta, next := rowOf[column], int(^uint(0)>>1), 0
+			for col := 1; col <= n; col++ {
+				if used[col] {
+					continue
+				}
+				reduced := cost[current-1][col-1] - u[current] - v[col]
+				if reduced < least[col] {
+					least[col], way[col] = reduced, column
+				}
+				if least[col] < delta {
+					delta, next = least[col], col
+				}
+			}
+			for col := 0; col <= n; col++ {
+				if used[col] {
+					u[rowOf[col]] += delta
+					v[col] -= delta
+				} else {
+					least[col] -= delta
+				}
+			}
+			column = next
+		}
+		for column != 0 {
+			previous := way[column]
+			rowOf[column] = rowOf[previous]
+			column = previous
+		}
+	}
+
+	assigned := make([]int, n)
+	for index := range assigned {
+		assigned[index] = -1
+	}
+	for col := 1; col <= n; col++ {
+		if rowOf[col] != 0 {
+			assigned[rowOf[col]-1] = col - 1
+		}
+	}
+	return assigned
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/matching_test.go
+
+package hexoban
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestState_Matching(t *testing.T) {
+	at := NewHexCoord
+	tests := []struct {
+		name   string
+		puzzle Puzzle
+		crates []HexCoord
+		expect map[HexCoord]HexCoord
+	}{
+		{"dead corner", dws001(), []HexCoord{at(2, 3), at(6, 6), at(7, 7)},
+			map[HexCoord]HexCoord{at(6, 6): at(5, 5), at(7, 7): at(7, 7)}},
+		{"one crate", treasureRoom(), nil, map[HexCoord]HexCoord{at(2, 3): at(2, 4)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.crates != nil {
+				tt.puzzle.Init.Crates = tt.crates
+			}
+			state, err := NewState(tt.puzzle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := state.Matching(); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("Matching() = %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+// Compares the total distance of the matching to that of every permutation of
+// the goals, for levels with few enough crates to try them all.
+func TestState_Matching_Least(t *testing.T) {
+	for _, path := range []string{"DWS/001.json", "DWS/002.json", "DWS/011.json", "hexocet/01.json"} {
+		state, err := NewState(loadLevel(t, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		crates, goals := []int{}, []int{}
+		for cell := range state.board.cells {
+			if state.crates[cell] {
+				crates = append(crates, cell)
+			}
+			if state.board.goals[cell] {
+				goals = append(goals, cell)
+			}
+		}
+		distances := make(map[int][]int, len(goals))
+		for _, goal := range goals {
+			distances[goal] = state.board.pullDistances([]int{goal})
+		}
+
+		matching, total := state.Matching(), 0
+		if len(matching) != len(crates) {
+			t.Fatalf("%s: matched %d of %d crates", path, len(matching), len(crates))
+		}
+		for crate, goal := range matching {
+			total += distances[state.board.lookup(goal)][state.board.lookup(crate)]
+		}
+
+		least := -1
+		var permute func(int)
+		permute = func(k int) {
+			if k == len(goals) {
+				sum := 0
+				for index, goal := range goals {
+					distance := distances[goal][crates[index]]
+					if distance == unreachable {
+						return
+					}
+					sum += distance
+				}
+				if least < 0 || sum < least {
+					least = sum
+				}
+				return
+			}
+			for index := k; index < len(goals); index++ {
+				goals[k], goals[index] = goals[index], goals[k]
+				permute(k + 1)
+				goals[k], goals[index] = goals[index], goals[k]
+			}
+		}
+		permute(0)
+		if total != least {
+			t.Errorf("%s: Matching() totals %d pushes, expected %d", path, total, least)
+		}
+	}
+}
+
+func TestMinimumAssignment(t *testing.T) {
+	tests := []struct {
+		cost   [][]int
+		expect []int
+	}{
+		{[][]int{}, []int{}},
+		{[][]int{{5}}, []int{0}},
+		{[][]int{{4, 1, 3}, {2, 0, 5}, {3, 2, 2}}, []int{1, 0, 2}},
+		{[][]int{{1, 2, 3, 4}, {2, 4, 6, 8}, {3, 6, 9, 12}, {4, 8, 12, 16}},
+			[]int{3, 2, 1, 0}},
+	}
+	for _, tt := range tests {
+		if got := minimumAssignment(tt.cost); !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("minimumAssignment(%v) = %v, expected %v", tt.cost, got, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/pddl.go
+
+package hexoban
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Translations to and from the Planning Domain Definition Language (PDDL), so
+// that classical planners can be compared against our solvers.  The domain is
+// the same for every level and each level becomes a problem in that domain.
+// Plans produced by a planner can be read back in as a Solution.
+
+// The hexoban domain, in the style of the IPC sokoban domain but with six
+// directions.  A cell is `clear` when neither the worker nor a crate is on it.
+const PDDLDomain = `(define (domain hexoban)
+  (:requirements :strips :typing)
+  (:types cell direction)
+  (:constants up backward left down forward right - direction)
+  (:predicates
+    (adjacent ?from ?to - cell ?dir - direction)
+    (at-ichiban ?c - cell)
+    (at-crate ?c - cell)
+    (clear ?c - cell))
+
+  (:action move
+    :parameters (?from ?to - cell ?dir - direction)
+    :precondition (and (at-ichiban ?from) (clear ?to) (adjacent ?from ?to ?dir))
+    :effect (and (not (at-ichiban ?from)) (not (clear ?to))
+                 (at-ichiban ?to) (clear ?from)))
+
+  (:action push
+    :parameters (?from ?crate ?to - cell ?dir - direction)
+    :precondition (and (at-ichiban ?from) (at-crate ?crate) (clear ?to)
+                       (adjacent ?from ?crate ?dir) (adjacent ?crate ?to ?dir))
+    :effect (and (not (at-ichiban ?from)) (not (at-crate ?crate)) (not (clear ?to))
+                 (at-ichiban ?crate) (at-crate ?to) (clear ?from))))
+`
+
+// Returns the PDDL problem for the puzzle, in the domain of PDDLDomain.
+// Returns an error if the puzzle is not playable (see NewState).
+func ToPDDLProblem(puzzle Puzzle) (string, error) {
+	state, err := NewState(puzzle)
+	if err != nil {
+		return "", err
+	}
+	board := state.board
+
+	var pddl strings.Builder
+	fmt.Fprintf(&pddl, ";; %s by %s (%s)\n", puzzle.Title, puzzle.Author, puzzle.Identity)
+	fmt.Fprintf(&pddl, "(define (problem %s)\n", pddlProblemName(puzzle.Identity))
+	pddl.WriteString("  (:domain hexoban)\n  (:objects")
+	for index, coord := range board.cells {
+		if index%8 == 0 {
+			pddl.WriteString("\n   ")
+		}
+		fmt.Fprintf(&pddl, " %s", pddlCell(coord))
+	}
+	pddl.WriteString(" - cell)\n  (:init\n")
+	for cell, coord := range board.cells {
+		for d, next := range board.neighbors[cell] {
+			if next >= 0 {
+				fmt.Fprintf(&pddl, "    (adjacent %s %s %s)\n",
+					pddlCell(coord), pddlCell(board.cells[next]), Directions[d])
+			}
+		}
+	}
+	fmt.Fprintf(&pddl, "    (at-ichiban %s)\n", pddlCell(state.Ichiban()))
+	for cell, coord := range board.cells {
+		if state.crates[cell] {
+			fmt.Fprintf(&pddl, "    (at-crate %s)\n", pddlCell(coord))
+		} else if cell != state.ichiban {
+			fmt.Fprintf(&pddl, "    (clear %s)\n", pddlCell(coord))
+		}
+	}
+	pddl.WriteString("  )\n  (:goal (and")
+	for cell, coord := range board.cells {
+		if board.goals[cell] {
+			fmt.Fprintf(&pddl, "\n    (at-crate %s)", pddlCell(coord))
+		}
+	}
+	pddl.WriteString(")))\n")
+	return pddl.String(), nil
+}
+
+// Reads a plan for the puzzle's problem (as produced by ToPDDLProblem) and
+// returns the equivalent Solution.  Each action is replayed from the initial
+// state, an error is returned if an action does not agree with the position it
+// is played from or does not follow the rules.  Plans are expected to have one
+// action per line, as most planners write them, and may include step numbers
+// before the action, costs after it and comments beginning with ';'.
+//
+// The plan is not required to solve the puzzle, check with State.IsSolved().
+func ParsePDDLPlan(puzzle Puzzle, plan io.Reader) (Solution, error) {
+	state, err := NewState(puzzle)
+	if err != nil {
+		return nil, err
+	}
+	cells := make(map[string]HexCoord, len(state.board.cells))
+	for _, coord := range state.board.cells {
+		cells[pddlCell(coord)] = coord
+	}
+	directions := make(map[string]Direction, len(Directions))
+	for _, dir := range Directions {
+		directions[dir.String()] = dir
+	}
+
+	scanner := bufio.NewScanner(plan)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line, _, _ := strings.Cut(scanner.Text(), ";")
+		start, end := strings.Index(line, "("), strings.LastIndex(line, ")")
+		if start < 0 && end < 0 && strings.TrimSpace(line) == "" {
+			continue
+		}
+		if start < 0 || end < start {
+			return nil, fmt.Errorf("line %d: expected a parenthesized action", lineno)
+		}
+
+		fields := strings.Fields(strings.ToLower(line[start+1 : end]))
+		var want []string // the cells that the action's arguments must match.
+		switch {
+		case len(fields) == 4 && fields[0] == "move":
+			want = fields[1:3]
+		case len(fields) == 5 && fields[0] == "push":
+			want = fields[1:4]
+		default:
+			return nil, fmt.Errorf("line %d: unknown action %q", lineno, line[start:end+1])
+		}
+		dir, ok := directions[fields[len(fields)-1]]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown direction %q", lineno, fields[len(fields)-1])
+		}
+
+		at := state.Ichiban()
+		for _, name := range want {
+			if coord, exists := cells[name]; !exists || coord != at {
+				return nil, fmt.Errorf("line %d: expected %s at %s but found %s",
+					lineno, fields[0], pddlCell(at), name)
+			}
+			at = at.Neighbor(dir)
+		}
+		step, err := state.Move(dir)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineno, err)
+		}
+		if step.IsPush() != (fields[0] == "push") {
+			return nil, fmt.Errorf("line %d: %s does not agree with the crates at %v",
+				lineno, fields[0], state.Ichiban())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return state.History(), nil
+}
+
+// The object name for a cell, negative numbers are written with an 'n' prefix
+// because PDDL names may only contain letters, digits, '-' and '_'.
+func pddlCell(coord HexCoord) string {
+	number := func(n int) string {
+		if n < 0 {
+			return fmt.Sprintf("n%d", -n)
+		}
+		return fmt.Sprint(n)
+	}
+	return fmt.Sprintf("c%s_%s", number(coord.i), number(coord.j))
+}
+
+// Derives a valid PDDL name from the puzzle's identity.
+func pddlProblemName(identity string) string {
+	name := []byte("hexoban-")
+	for _, char := range []byte(strings.ToLower(identity)) {
+		if ('a' <= char && char <= 'z') || ('0' <= char && char <= '9') || char == '_' {
+			name = append(name, char)
+		} else {
+			name = append(name, '-')
+		}
+	}
+	return string(name)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/pddl_test.go
+
+package hexoban
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToPDDLProblem(t *testing.T) {
+	problem, err := ToPDDLProblem(treasureRoom())
+	if err != nil {
+		t.Fatalf("ToPDDLProblem() error = %v", err)
+	}
+	for _, want := range []string{
+		"(define (problem hexoban-testdata-treasure)",
+		"(:domain hexoban)",
+		"(adjacent c2_2 c2_3 right)",
+		"(adjacent c2_3 c1_2 backward)",
+		"(at-ichiban c2_2)",
+		"(at-crate c2_3)",
+		"(clear c2_4)",
+		"(:goal (and\n    (at-crate c2_4))",
+	} {
+		if !strings.Contains(problem, want) {
+			t.Errorf("ToPDDLProblem() is missing %q", want)
+		}
+	}
+	if strings.Contains(problem, "(clear c2_2)") || strings.Contains(problem, "(clear c2_3)") {
+		t.Errorf("ToPDDLProblem() should not have occupied cells be clear")
+	}
+	if strings.Count(problem, "(") != strings.Count(problem, ")") {
+		t.Errorf("ToPDDLProblem() has unbalanced parentheses")
+	}
+	if name := pddlCell(NewHexCoord(-3, 0)); name != "cn3_0" {
+		t.Errorf("pddlCell(-3, 0) = %s, want cn3_0", name)
+	}
+}
+
+func TestParsePDDLPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    string
+		expect  string
+		wantErr bool
+	}{
+		{"push", "(push c2_2 c2_3 c2_4 right)\n", "R", false},
+		{
+			"numbered with costs and comments",
+			"; found by a planner\n0: (MOVE C2_2 C1_2 UP) [1]\n1: (move c1_2 c2_2 down) [1]\n" +
+				"2: (push c2_2 c2_3 c2_4 right) [1]\n; cost = 3 (unit cost)\n",
+			"udR", false,
+		},
+		{"empty", "\n", "", false},
+		{"move into crate", "(move c2_2 c2_3 right)\n", "", true},
+		{"wrong position", "(move c1_2 c1_3 right)\n", "", true},
+		{"unknown action", "(jump c2_2 c2_4 right)\n", "", true},
+		{"unknown direction", "(move c2_2 c1_2 north)\n", "", true},
+		{"into wall", "(move c2_2 c2_1 left)\n", "", true},
+		{"not an action", "push c2_2 c2_3 c2_4 right\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solution, err := ParsePDDLPlan(treasureRoom(), strings.NewReader(tt.plan))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePDDLPlan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && solution.String() != tt.expect {
+				t.Errorf("ParsePDDLPlan() = %q, want %q", solution, tt.expect)
+			}
+		})
+	}
+}
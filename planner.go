@@ -0,0 +1,107 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/planner.go
+
+package hexoban
+
+import "fmt"
+
+// Finds the shortest sequence of steps (moves and pushes together) that takes
+// the crate at `from` to the cell at `to`, while all the other crates stay
+// where they are.  The state is not modified, the result can be passed to
+// State.Apply() to perform it.  When there is no such sequence an error is
+// returned describing why the crate cannot be moved there.
+//
+// This is a breadth-first search over (crate, worker) position pairs, so it
+// is quadratic in the size of the terrain, which is small for all known levels.
+func (state *State) PlanCratePath(from, to HexCoord) (Solution, error) {
+	board := state.board
+	crate, target := board.lookup(from), board.lookup(to)
+	if crate < 0 || !state.crates[crate] {
+		return nil, fmt.Errorf("there is no crate at %v", from)
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("%v is not on the terrain", to)
+	}
+	if target == crate {
+		return Solution{}, nil
+	}
+	if state.crates[target] {
+		return nil, fmt.Errorf("%v is occupied by another crate", to)
+	}
+
+	// Each search node is encoded as crate*size + worker.  The parent index and
+	// the step which reached each node are kept for reconstructing the path.
+	size := len(board.cells)
+	parent := make([]int, size*size)
+	for node := range parent {
+		parent[node] = -1
+	}
+	steps := make([]Step, size*size)
+
+	start := crate*size + state.ichiban
+	parent[start] = start
+	queue := []int{start}
+	pushed := false
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		crateAt, worker := node/size, node%size
+		if crateAt == target {
+			return tracePath(parent, steps, start, node), nil
+		}
+
+		for d, dir := range Directions {
+			next := board.neighbors[worker][d]
+			if next < 0 || (state.crates[next] && next != crate) {
+				continue
+			}
+			nextCrate, push := crateAt, false
+			if next == crateAt {
+				nextCrate = board.neighbors[crateAt][d]
+				if nextCrate < 0 || (state.crates[nextCrate] && nextCrate != crate) {
+					continue
+				}
+				push, pushed = true, true
+			}
+			following := nextCrate*size + next
+			if parent[following] < 0 {
+				parent[following] = node
+				steps[following] = NewStep(dir, push)
+				queue = append(queue, following)
+			}
+		}
+	}
+
+	if !pushed {
+		return nil, fmt.Errorf("the worker cannot reach a side of the crate at %v "+
+			"from which it can be pushed", from)
+	}
+	return nil, fmt.Errorf("the crate at %v cannot be pushed to %v "+
+		"without moving other crates", from, to)
+}
+
+// Reconstructs the steps from the start node to the end node by following the
+// parent links backwards, then reversing them.
+func tracePath(parent []int, steps []Step, start, end int) Solution {
+	path := make(Solution, 0)
+	for node := end; node != start; node = parent[node] {
+		path = append(path, steps[node])
+	}
+	for left, right := 0, len(path)-1; left < right; left, right = left+1, right-1 {
+		path[left], path[right] = path[right], path[left]
+	}
+	return path
+}
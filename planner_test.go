@@ -0,0 +1,64 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/planner_test.go
+
+package hexoban
+
+import "testing"
+
+func TestState_PlanCratePath(t *testing.T) {
+	at := NewHexCoord
+	tests := []struct {
+		name    string
+		puzzle  Puzzle
+		from    HexCoord
+		to      HexCoord
+		expect  string
+		wantErr bool
+	}{
+		{"single push", treasureRoom(), at(2, 3), at(2, 4), "R", false},
+		{"already there", treasureRoom(), at(2, 3), at(2, 3), "", false},
+		{"no crate", treasureRoom(), at(2, 2), at(2, 4), "", true},
+		{"off terrain", treasureRoom(), at(2, 3), at(0, 0), "", true},
+		{"against the wall", treasureRoom(), at(2, 3), at(1, 3), "", true},
+		{"occupied target", dws001(), at(6, 5), at(6, 6), "", true},
+		{"walk around", dws001(), at(7, 7), at(5, 6), "UrB", false},
+		{"locked in", dws001(), at(6, 6), at(5, 5), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := NewState(tt.puzzle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path, err := state.PlanCratePath(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PlanCratePath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if path.String() != tt.expect {
+				t.Errorf("PlanCratePath() = %s, expected %s", path, tt.expect)
+			}
+			if err := state.Apply(path); err != nil {
+				t.Fatalf("planned path is not legal: %v", err)
+			}
+			if !state.IsCrate(tt.to) {
+				t.Errorf("crate not at %v after applying %s", tt.to, path)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/progress.go
+
+package hexoban
+
+// Reporting on a search while it runs, for watching a long search (and
+// stopping it through its context if it is not going anywhere).
+
+// How many positions are expanded between reports, if the solver's
+// ProgressInterval is not set.
+const DEFAULT_PROGRESS_INTERVAL = 1024
+
+// A snapshot of a search in progress, see Solver.Progress.
+type SolverProgress struct {
+	SolverStats
+	// The distinct feature-space cells among the positions expanded, a cell
+	// being the number of crates on goals and the number of regions the floor
+	// is divided into by crates (the packing and connectivity features of FESS,
+	// see /fess/README.md).  The search is exploring while this grows.
+	FeatureCells int
+	// The least lower bound (pushes still needed) among the positions
+	// expanded, and the steps from the initial state to the first position
+	// expanded with that bound.  The bound is zero once a solution is found.
+	LowerBound int
+	Best       Solution
+	Done       bool // true for the report made as the search ends.
+}
+
+// A position in the feature space, see SolverProgress.FeatureCells.
+type featureCell struct {
+	packed       int
+	connectivity int
+}
+
+type progressTracker struct {
+	report   func(SolverProgress)
+	interval int
+	state    *State // the position searched from.
+	cells    map[featureCell]bool
+	best     int // the node with the least lower bound so far.
+}
+
+func newProgressTracker(solver Solver, state *State) *progressTracker {
+	if solver.Progress == nil {
+		return nil
+	}
+	interval := solver.ProgressInterval
+	if interval <= 0 {
+		interval = DEFAULT_PROGRESS_INTERVAL
+	}
+	return &progressTracker{report: solver.Progress, interval: interval,
+		state: state, cells: make(map[featureCell]bool), best: -1}
+}
+
+// Notes the node's features as it is expanded and reports on the search every
+// interval expansions.
+func (tracker *progressTracker) expanded(search *search, node int, stats SolverStats) {
+	crates := search.decode(search.nodes[node].key)
+	tracker.cells[search.board.features(crates)] = true
+	if tracker.best < 0 || search.nodes[node].lowerBound < search.nodes[tracker.best].lowerBound {
+		tracker.best = node
+	}
+	if stats.Nodes%tracker.interval == 0 {
+		tracker.send(search, stats, false)
+	}
+}
+
+func (tracker *progressTracker) send(search *search, stats SolverStats, done bool) {
+	progress := SolverProgress{SolverStats: stats, FeatureCells: len(tracker.cells), Done: done}
+	if tracker.best >= 0 {
+		progress.LowerBound = search.nodes[tracker.best].lowerBound
+		progress.Best = search.solution(tracker.state, tracker.best)
+	}
+	tracker.report(progress)
+}
+
+// Returns the feature-space cell of the crate positions.
+func (board *board) features(crates []bool) featureCell {
+	cell := featureCell{}
+	visited := make([]bool, len(board.cells))
+	queue := make([]int, 0, len(board.cells))
+	for start, present := range crates {
+		if present {
+			if board.goals[start] {
+				cell.packed++
+			}
+			continue
+		}
+		if visited[start] {
+			continue
+		}
+		cell.connectivity++
+		visited[start] = true
+		queue = append(queue[:0], start)
+		for len(queue) > 0 {
+			current := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			for _, next := range board.neighbors[current] {
+				if next >= 0 && !visited[next] && !crates[next] {
+					visited[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	return cell
+}
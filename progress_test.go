@@ -0,0 +1,96 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/progress_test.go
+
+package hexoban
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSolver_Progress(t *testing.T) {
+	state, err := NewState(loadLevel(t, "Heroban/Heroban 1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := []SolverProgress{}
+	solver := Solver{ProgressInterval: 16, Progress: func(progress SolverProgress) {
+		reports = append(reports, progress)
+	}}
+	solution, stats, err := solver.Solve(context.Background(), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != stats.Nodes/16+1 {
+		t.Fatalf("%d reports for %d nodes, expected %d", len(reports), stats.Nodes, stats.Nodes/16+1)
+	}
+	for index, report := range reports[1:] {
+		previous := reports[index]
+		if report.Nodes < previous.Nodes || report.FeatureCells < previous.FeatureCells ||
+			report.LowerBound > previous.LowerBound {
+			t.Errorf("report %d went backwards: %+v after %+v", index+1, report, previous)
+		}
+		if report.Done != (index+1 == len(reports)-1) {
+			t.Errorf("report %d: Done = %t", index+1, report.Done)
+		}
+	}
+	final := reports[len(reports)-1]
+	if final.SolverStats != stats || final.LowerBound != 0 || final.Best.String() != solution.String() {
+		t.Errorf("final report %+v, expected the stats %+v and solution %s", final, stats, solution)
+	}
+	if final.FeatureCells < 2 {
+		t.Errorf("visited %d feature-space cells, expected several", final.FeatureCells)
+	}
+
+	// Reports are made even when the search gives up.
+	reports = reports[:0]
+	solver.MaxNodes = 5
+	if _, _, err := solver.Solve(context.Background(), state); err == nil {
+		t.Fatal("expected the budget to be exhausted")
+	}
+	if len(reports) != 1 || !reports[0].Done || reports[0].Nodes != 5 {
+		t.Errorf("reports %+v, expected one final report after 5 nodes", reports)
+	}
+}
+
+func TestBoard_Features(t *testing.T) {
+	at := NewHexCoord
+	state, err := NewState(dws001())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		crates []HexCoord
+		expect featureCell
+	}{
+		{"none", nil, featureCell{0, 1}},
+		{"one packed", []HexCoord{at(7, 7)}, featureCell{1, 1}},
+		{"dividing", []HexCoord{at(6, 6), at(6, 7)}, featureCell{0, 2}},
+		{"packed apart", []HexCoord{at(4, 5), at(5, 5)}, featureCell{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crates := make([]bool, len(state.board.cells))
+			for _, crate := range tt.crates {
+				crates[state.board.lookup(crate)] = true
+			}
+			if got := state.board.features(crates); got != tt.expect {
+				t.Errorf("features() = %+v, expected %+v", got, tt.expect)
+			}
+		})
+	}
+}
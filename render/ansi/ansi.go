@@ -0,0 +1,331 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/ansi/ansi.go
+
+// Package ansi renders puzzles for terminals, in color with ANSI escape codes
+// and optionally drawing each hex's outline with Unicode box-drawing and block
+// characters.  Plain ASCII output is the same as textmap.MapString, it is used
+// when writing to files and pipes (see ForOutput).
+package ansi
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/internal/terminal"
+	"github.com/SymbolNotFound/hexoban/render"
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+type Mode int
+
+const (
+	MODE_AUTO    Mode = iota // MODE_COLOR for terminals, MODE_ASCII otherwise.
+	MODE_ASCII               // the text map format, without any escape codes.
+	MODE_COLOR               // the text map layout with colored glyphs.
+	MODE_UNICODE             // colored hexagons with outlines, at any scale.
+)
+
+var MODE_NAMES = []string{"auto", "ascii", "color", "unicode"}
+
+// The largest scale of MODE_UNICODE, the width of each hexagon grows by four
+// columns and its height by two lines with each step of scale.
+const MAX_SCALE = 4
+
+// Escape codes (Select Graphic Rendition) for each kind of hex.
+const (
+	STYLE_OUTLINE    = "\x1b[90m"
+	STYLE_WALL       = "\x1b[90m"
+	STYLE_GOAL       = "\x1b[32m"
+	STYLE_CRATE      = "\x1b[33m"
+	STYLE_CRATE_GOAL = "\x1b[1;32m"
+	STYLE_ICHIBAN    = "\x1b[1;36m"
+	STYLE_DEAD       = "\x1b[31m"
+	STYLE_RESET      = "\x1b[0m"
+)
+
+// The symbols of MODE_UNICODE, for each glyph of the text map format.
+var UNICODE_GLYPHS = map[textmap.TokenType]rune{
+	textmap.TOKEN_WALL:       '░',
+	textmap.TOKEN_FLOOR:      ' ',
+	textmap.TOKEN_GOAL:       '○',
+	textmap.TOKEN_CRATE:      '■',
+	textmap.TOKEN_CRATE_GOAL: '▣',
+	textmap.TOKEN_AT:         '●',
+	textmap.TOKEN_AT_GOAL:    '◉',
+}
+
+// How dead cells without a crate are shown, when they are being marked.
+const DEAD_GLYPH = '·'
+
+type Options struct {
+	Mode  Mode
+	Scale int  // the size of each hex in MODE_UNICODE, 1 (the default) to MAX_SCALE.
+	Dead  bool // marks the dead cells and the crates on them (except in MODE_ASCII).
+}
+
+// Returns the mode named (one of MODE_NAMES), for use with command-line flags.
+func ParseMode(name string) (Mode, error) {
+	for index, known := range MODE_NAMES {
+		if strings.EqualFold(name, known) {
+			return Mode(index), nil
+		}
+	}
+	return MODE_AUTO, fmt.Errorf("unknown render mode %q, expected one of %s",
+		name, strings.Join(MODE_NAMES, ", "))
+}
+
+func (mode Mode) String() string {
+	if mode < 0 || int(mode) >= len(MODE_NAMES) {
+		return fmt.Sprintf("Mode(%d)", int(mode))
+	}
+	return MODE_NAMES[mode]
+}
+
+// Resolves MODE_AUTO for writing to the output, which is colored only if the
+// output is a terminal.  Other modes are returned as they are.
+func ForOutput(mode Mode, output *os.File) Mode {
+	if mode != MODE_AUTO {
+		return mode
+	}
+	if terminal.IsTerminal(int(output.Fd())) {
+		return MODE_COLOR
+	}
+	return MODE_ASCII
+}
+
+// Renders the puzzle's map (with its crates and ichiban at their positions in
+// puzzle.Init) as lines of text, separated by "\n".  MODE_AUTO is rendered as
+// MODE_ASCII, resolve it with ForOutput beforehand.
+func Render(puzzle hexoban.Puzzle, options Options) (string, error) {
+	text, err := textmap.MapString(puzzle)
+	if err != nil || options.Mode == MODE_ASCII || options.Mode == MODE_AUTO {
+		return text, err
+	}
+
+	cells := make(map[hexoban.HexCoord]textmap.TokenType, len(puzzle.Terrain))
+	for _, coord := range puzzle.Terrain {
+		cells[coord] = textmap.TOKEN_FLOOR
+	}
+	for _, coord := range puzzle.Terrain {
+		for _, dir := range hexoban.Directions {
+			if _, exists := cells[coord.Neighbor(dir)]; !exists {
+				cells[coord.Neighbor(dir)] = textmap.TOKEN_WALL
+			}
+		}
+	}
+	for _, goal := range puzzle.Init.Goals {
+		cells[goal] = textmap.TOKEN_GOAL
+	}
+	for _, crate := range puzzle.Init.Crates {
+		if cells[crate] == textmap.TOKEN_GOAL {
+			cells[crate] = textmap.TOKEN_CRATE_GOAL
+		} else {
+			cells[crate] = textmap.TOKEN_CRATE
+		}
+	}
+	if cells[puzzle.Init.Ichiban] == textmap.TOKEN_GOAL {
+		cells[puzzle.Init.Ichiban] = textmap.TOKEN_AT_GOAL
+	} else {
+		cells[puzzle.Init.Ichiban] = textmap.TOKEN_AT
+	}
+
+	dead := make(map[hexoban.HexCoord]bool)
+	if options.Dead {
+		// Dead cells are only known for playable puzzles.
+		if state, err := hexoban.NewState(puzzle); err == nil {
+			for _, coord := range puzzle.Terrain {
+				dead[coord] = state.IsDeadCell(coord)
+			}
+		}
+	}
+
+	if options.Mode == MODE_UNICODE {
+		return renderHexes(cells, dead, max(1, min(options.Scale, MAX_SCALE))), nil
+	}
+	return renderGlyphs(cells, dead), nil
+}
+
+// Returns the glyph colored for its kind of hex, as a symbol of MODE_UNICODE
+// or the glyph itself otherwise.  Dead cells are shown as DEAD_GLYPH and crates
+// on them in the dead style.
+func Glyph(glyph textmap.TokenType, dead bool, mode Mode) string {
+	if mode == MODE_ASCII || mode == MODE_AUTO {
+		return string(rune(glyph))
+	}
+	symbol, style := symbolOf(glyph, dead, mode), styleOf(glyph, dead)
+	if style == "" {
+		return string(symbol)
+	}
+	return style + string(symbol) + STYLE_RESET
+}
+
+func symbolOf(glyph textmap.TokenType, dead bool, mode Mode) rune {
+	switch {
+	case glyph == textmap.TOKEN_FLOOR && dead:
+		return DEAD_GLYPH
+	case mode == MODE_UNICODE:
+		return UNICODE_GLYPHS[glyph]
+	}
+	return rune(glyph)
+}
+
+func styleOf(glyph textmap.TokenType, dead bool) string {
+	switch glyph {
+	case textmap.TOKEN_WALL:
+		return STYLE_WALL
+	case textmap.TOKEN_FLOOR:
+		if dead {
+			return STYLE_DEAD
+		}
+	case textmap.TOKEN_GOAL:
+		return STYLE_GOAL
+	case textmap.TOKEN_CRATE:
+		if dead {
+			return STYLE_DEAD
+		}
+		return STYLE_CRATE
+	case textmap.TOKEN_CRATE_GOAL:
+		return STYLE_CRATE_GOAL
+	case textmap.TOKEN_AT, textmap.TOKEN_AT_GOAL:
+		return STYLE_ICHIBAN
+	}
+	return ""
+}
+
+// The bounds of the cells in the text map layout, rows are i and columns are
+// render.Column().
+func bounds(cells map[hexoban.HexCoord]textmap.TokenType) (minRow, maxRow, minCol, maxCol int) {
+	first := true
+	for coord := range cells {
+		row, col := coord.I(), render.Column(coord)
+		if first {
+			minRow, maxRow, minCol, maxCol = row, row, col, col
+			first = false
+		}
+		minRow, maxRow = min(minRow, row), max(maxRow, row)
+		minCol, maxCol = min(minCol, col), max(maxCol, col)
+	}
+	return
+}
+
+// Renders in the text map layout, one (colored) glyph for each hex.
+func renderGlyphs(cells map[hexoban.HexCoord]textmap.TokenType, dead map[hexoban.HexCoord]bool) string {
+	minRow, maxRow, minCol, maxCol := bounds(cells)
+	lines := make([]string, 0, maxRow-minRow+1)
+	for row := minRow; row <= maxRow; row++ {
+		var line strings.Builder
+		pending := 0 // spaces, written only if a glyph follows them.
+		for col := minCol; col <= maxCol; col++ {
+			coord := hexoban.NewHexCoord(row, (row+col)/2)
+			glyph, exists := cells[coord]
+			if (col-row)&1 != 0 || !exists || (glyph == textmap.TOKEN_FLOOR && !dead[coord]) {
+				pending++
+				continue
+			}
+			line.WriteString(strings.Repeat(" ", pending))
+			pending = 0
+			line.WriteString(Glyph(glyph, dead[coord], MODE_COLOR))
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// A character of the canvas and the style it is drawn with.
+type styled struct {
+	symbol rune
+	style  string
+}
+
+// Renders each hex as a hexagon (pointy side up) with its symbol in the middle.
+// At scale s, the hexagon's sides are s lines tall and its diagonals are s lines
+// of '/' and '\' each, shared with the neighboring hexagons:
+//
+//	 / \ / \        scale 1,    /   \         scale 2
+//	| # | @ |                  /     \
+//	 \ / \ /                  |       |
+//	                          |   ●   |
+//	                           \     /
+//	                            \   /
+func renderHexes(cells map[hexoban.HexCoord]textmap.TokenType, dead map[hexoban.HexCoord]bool, s int) string {
+	minRow, maxRow, minCol, maxCol := bounds(cells)
+	width, height := 2*s*(maxCol-minCol)+4*s+1, 2*s*(maxRow-minRow)+3*s
+	canvas := make([][]styled, height)
+	for y := range canvas {
+		canvas[y] = make([]styled, width)
+	}
+	put := func(x, y int, symbol rune, style string) {
+		canvas[y][x] = styled{symbol, style}
+	}
+
+	for coord, glyph := range cells {
+		x := 2*s*(render.Column(coord)-minCol) + 2*s
+		y0 := 2*s*(coord.I()-minRow) + s
+		fill := func(y, left, right int) {
+			if glyph == textmap.TOKEN_WALL {
+				for fillX := left + 1; fillX < right; fillX++ {
+					put(fillX, y, UNICODE_GLYPHS[textmap.TOKEN_WALL], STYLE_WALL)
+				}
+			}
+		}
+		for k := 1; k <= s; k++ {
+			left, right := x-2*s+2*k-1, x+2*s-2*k+1
+			put(left, y0-k, '/', STYLE_OUTLINE)
+			put(right, y0-k, '\\', STYLE_OUTLINE)
+			fill(y0-k, left, right)
+			put(left, y0+s-1+k, '\\', STYLE_OUTLINE)
+			put(right, y0+s-1+k, '/', STYLE_OUTLINE)
+			fill(y0+s-1+k, left, right)
+		}
+		for m := 0; m < s; m++ {
+			put(x-2*s, y0+m, '|', STYLE_OUTLINE)
+			put(x+2*s, y0+m, '|', STYLE_OUTLINE)
+			fill(y0+m, x-2*s, x+2*s)
+		}
+		if glyph != textmap.TOKEN_WALL {
+			put(x, y0+(s-1)/2, symbolOf(glyph, dead[coord], MODE_UNICODE), styleOf(glyph, dead[coord]))
+		}
+	}
+
+	lines := make([]string, height)
+	for y, row := range canvas {
+		var line strings.Builder
+		style, pending := "", 0
+		for _, char := range row {
+			if char.symbol == 0 || char.symbol == ' ' {
+				pending++
+				continue
+			}
+			line.WriteString(strings.Repeat(" ", pending))
+			pending = 0
+			if char.style != style {
+				if style != "" {
+					line.WriteString(STYLE_RESET)
+				}
+				line.WriteString(char.style)
+				style = char.style
+			}
+			line.WriteRune(char.symbol)
+		}
+		if style != "" {
+			line.WriteString(STYLE_RESET)
+		}
+		lines[y] = line.String()
+	}
+	return strings.Join(lines, "\n")
+}
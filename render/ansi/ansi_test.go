@@ -0,0 +1,187 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/ansi/ansi_test.go
+
+package ansi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/textmap"
+)
+
+var escapes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func stripEscapes(text string) string {
+	return escapes.ReplaceAllString(text, "")
+}
+
+func loadLevels(t *testing.T) map[string]hexoban.Puzzle {
+	paths, err := filepath.Glob("../../levels/*/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no levels found: %v", err)
+	}
+	puzzles := make(map[string]hexoban.Puzzle, len(paths))
+	for _, path := range paths {
+		filedata, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var puzzle hexoban.Puzzle
+		if err := json.Unmarshal(filedata, &puzzle); err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		puzzles[path] = puzzle
+	}
+	return puzzles
+}
+
+func TestRender_SameLayout(t *testing.T) {
+	for path, puzzle := range loadLevels(t) {
+		want, err := textmap.MapString(puzzle)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		for _, mode := range []Mode{MODE_AUTO, MODE_ASCII, MODE_COLOR} {
+			got, err := Render(puzzle, Options{Mode: mode})
+			if err != nil || stripEscapes(got) != want {
+				t.Errorf("%s: Render(%s) =\n%s\nwant\n%s", path, mode, got, want)
+			}
+		}
+	}
+}
+
+func dws001(t *testing.T) hexoban.Puzzle {
+	return loadLevels(t)["../../levels/DWS/001.json"]
+}
+
+func TestRender_Dead(t *testing.T) {
+	got, err := Render(dws001(t), Options{Mode: MODE_COLOR, Dead: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `   # # #
+  # · · #
+   #   · #
+  #   . #
+ #   .   #
+# · $ $   #
+ # # # * · #
+    # @ · #
+     # # #`
+	if stripEscapes(got) != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", stripEscapes(got), want)
+	}
+	if !strings.Contains(got, STYLE_DEAD+"·"+STYLE_RESET) ||
+		!strings.Contains(got, STYLE_CRATE_GOAL+"*"+STYLE_RESET) ||
+		!strings.Contains(got, STYLE_ICHIBAN+"@"+STYLE_RESET) {
+		t.Errorf("Render() is missing styles: %q", got)
+	}
+
+	// A crate pushed into a dead corner is in the dead style.
+	puzzle := dws001(t)
+	puzzle.Init.Crates = append([]hexoban.HexCoord{}, puzzle.Init.Crates...)
+	puzzle.Init.Crates[0] = hexoban.NewHexCoord(-6, -4)
+	got, _ = Render(puzzle, Options{Mode: MODE_COLOR, Dead: true})
+	if !strings.Contains(got, STYLE_DEAD+"$"+STYLE_RESET) {
+		t.Errorf("Render() of a dead crate: %q", got)
+	}
+}
+
+func TestRender_Unicode(t *testing.T) {
+	puzzle, err := textmap.Decode(strings.NewReader(" # # # #\n# @ $ . #\n # # # #\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		scale int
+		want  string
+	}{
+		{0, `   /░\ /░\ /░\ /░\
+  |░░░|░░░|░░░|░░░|
+ /░\░/ \░/ \░/ \░/░\
+|░░░| ● | ■ | ○ |░░░|
+ \░/░\ /░\ /░\ /░\░/
+  |░░░|░░░|░░░|░░░|
+   \░/ \░/ \░/ \░/`},
+		{9, ""}, // clamped to MAX_SCALE
+	}
+	for _, tt := range tests {
+		got, err := Render(puzzle, Options{Mode: MODE_UNICODE, Scale: tt.scale})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = stripEscapes(got)
+		if tt.want != "" && got != tt.want {
+			t.Errorf("scale %d =\n%s\nwant\n%s", tt.scale, got, tt.want)
+		}
+		lines := strings.Split(got, "\n")
+		if scale := max(1, min(tt.scale, MAX_SCALE)); len(lines) != 2*scale*2+3*scale {
+			t.Errorf("scale %d has %d lines", tt.scale, len(lines))
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for index, name := range MODE_NAMES {
+		if mode, err := ParseMode(strings.ToUpper(name)); err != nil || mode != Mode(index) {
+			t.Errorf("ParseMode(%q) = %v, %v", name, mode, err)
+		}
+	}
+	if _, err := ParseMode("sixel"); err == nil {
+		t.Errorf("ParseMode(sixel) expected an error")
+	}
+}
+
+func TestForOutput(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "output.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if mode := ForOutput(MODE_AUTO, file); mode != MODE_ASCII {
+		t.Errorf("ForOutput(auto, file) = %s", mode)
+	}
+	if mode := ForOutput(MODE_UNICODE, file); mode != MODE_UNICODE {
+		t.Errorf("ForOutput(unicode, file) = %s", mode)
+	}
+}
+
+func TestGlyph(t *testing.T) {
+	tests := []struct {
+		glyph textmap.TokenType
+		dead  bool
+		mode  Mode
+		want  string
+	}{
+		{'$', true, MODE_ASCII, "$"},
+		{'$', false, MODE_COLOR, STYLE_CRATE + "$" + STYLE_RESET},
+		{'$', true, MODE_COLOR, STYLE_DEAD + "$" + STYLE_RESET},
+		{' ', false, MODE_COLOR, " "},
+		{' ', true, MODE_UNICODE, STYLE_DEAD + "·" + STYLE_RESET},
+		{'+', false, MODE_UNICODE, STYLE_ICHIBAN + "◉" + STYLE_RESET},
+	}
+	for _, tt := range tests {
+		if got := Glyph(tt.glyph, tt.dead, tt.mode); got != tt.want {
+			t.Errorf("Glyph(%q, %v, %s) = %q, want %q", tt.glyph, tt.dead, tt.mode, got, tt.want)
+		}
+	}
+}
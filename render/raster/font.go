@@ -0,0 +1,110 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/raster/font.go
+
+package raster
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+)
+
+// A tiny bitmap font for labels and counters, each glyph is three pixels wide
+// and five tall (before scaling) with one pixel between glyphs.  Lowercase
+// letters are drawn as uppercase, characters not in the font as spaces.
+var FONT = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'A': {".#.", "#.#", "###", "#.#", "#.#"},
+	'B': {"##.", "#.#", "##.", "#.#", "##."},
+	'C': {".##", "#..", "#..", "#..", ".##"},
+	'D': {"##.", "#.#", "#.#", "#.#", "##."},
+	'E': {"###", "#..", "##.", "#..", "###"},
+	'F': {"###", "#..", "##.", "#..", "#.."},
+	'G': {".##", "#..", "#.#", "#.#", ".##"},
+	'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I': {"###", ".#.", ".#.", ".#.", "###"},
+	'J': {"..#", "..#", "..#", "#.#", ".#."},
+	'K': {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L': {"#..", "#..", "#..", "#..", "###"},
+	'M': {"#.#", "###", "###", "#.#", "#.#"},
+	'N': {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O': {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P': {"##.", "#.#", "##.", "#..", "#.."},
+	'Q': {".#.", "#.#", "#.#", "###", ".##"},
+	'R': {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S': {".##", "#..", ".#.", "..#", "##."},
+	'T': {"###", ".#.", ".#.", ".#.", ".#."},
+	'U': {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V': {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W': {"#.#", "#.#", "###", "###", "#.#"},
+	'X': {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y': {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z': {"###", "..#", ".#.", "#..", "###"},
+	'-': {"...", "...", "###", "...", "..."},
+	',': {"...", "...", "...", ".#.", "#.."},
+	'.': {"...", "...", "...", "...", ".#."},
+	'/': {"..#", "..#", ".#.", "#..", "#.."},
+	':': {"...", ".#.", "...", ".#.", "..."},
+	'(': {".#.", "#..", "#..", "#..", ".#."},
+	')': {".#.", "..#", "..#", "..#", ".#."},
+}
+
+// The width and height of the text in pixels when drawn at this scale.
+func TextWidth(text string, scale int) int {
+	if text == "" {
+		return 0
+	}
+	return (4*len([]rune(text)) - 1) * scale
+}
+
+func TextHeight(scale int) int {
+	return 5 * scale
+}
+
+// Draws the text with its top-left corner at (x, y), each of the font's pixels
+// as a square of scale pixels.
+func DrawText(img *image.RGBA, x, y int, text string, scale int, rgba color.NRGBA) {
+	for _, char := range strings.ToUpper(text) {
+		glyph, exists := FONT[unicode.ToUpper(char)]
+		if exists {
+			for row, line := range glyph {
+				for col := range line {
+					if line[col] != '#' {
+						continue
+					}
+					dot := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+					dot = dot.Intersect(img.Rect)
+					for py := dot.Min.Y; py < dot.Max.Y; py++ {
+						for px := dot.Min.X; px < dot.Max.X; px++ {
+							blend(img, px, py, rgba, 1)
+						}
+					}
+				}
+			}
+		}
+		x += 4 * scale
+	}
+}
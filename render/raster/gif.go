@@ -0,0 +1,156 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/raster/gif.go
+
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"slices"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render"
+)
+
+// Frame delays are in hundredths of a second, as in the GIF format.
+const (
+	DEFAULT_FRAME_DELAY = 15
+	DEFAULT_HOLD_DELAY  = 100
+)
+
+type AnimationOptions struct {
+	Options
+	Delay     int  // for each step, see DEFAULT_FRAME_DELAY.
+	Hold      int  // for the first and last frames, see DEFAULT_HOLD_DELAY.
+	SkipWalks bool // only shows the position after each push.
+	Counter   bool // writes the moves and pushes made beneath the puzzle.
+}
+
+// Replays the solution from the puzzle's initial position, writing an animated
+// GIF with a frame for each step.  The solution does not need to solve the
+// puzzle, but each of its steps must be possible and written as a push only
+// where it pushes (as State.Apply() requires), otherwise the error is the
+// *hexoban.StepError for the first step that is not.  The GIF format has no
+// translucent colors so a transparent background is drawn as white.
+func EncodeGIF(writer io.Writer, puzzle hexoban.Puzzle, solution hexoban.Solution, options AnimationOptions) error {
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return err
+	}
+	if err := state.Clone().Apply(solution); err != nil {
+		return err
+	}
+	if options.Delay <= 0 {
+		options.Delay = DEFAULT_FRAME_DELAY
+	}
+	if options.Hold <= 0 {
+		options.Hold = DEFAULT_HOLD_DELAY
+	}
+	if options.Theme.Background.A < 0xFF {
+		if options.Theme == (render.Theme{}) {
+			options.Theme = render.THEME_LIGHT
+		}
+		options.Theme.Background = color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	}
+
+	frames, delays := []*image.RGBA{}, []int{}
+	addFrame := func(delay int) error {
+		img, err := Draw(puzzle, options.Options)
+		if err != nil {
+			return err
+		}
+		if options.Counter {
+			img = withCounter(img, state.History(), options)
+		}
+		frames = append(frames, img)
+		delays = append(delays, delay)
+		return nil
+	}
+	if err := addFrame(options.Hold); err != nil {
+		return err
+	}
+	for index, step := range solution {
+		state.Move(step.Direction()) // each step was verified above.
+		if options.SkipWalks && !step.IsPush() && index < len(solution)-1 {
+			continue
+		}
+		puzzle.Init.Crates, puzzle.Init.Ichiban = state.Crates(), state.Ichiban()
+		if err := addFrame(options.Delay); err != nil {
+			return err
+		}
+	}
+	delays[len(delays)-1] = options.Hold
+
+	palette := paletteOf(frames)
+	animation := &gif.GIF{Delay: delays}
+	for _, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), palette)
+		draw.Draw(paletted, frame.Bounds(), frame, image.Point{}, draw.Src)
+		animation.Image = append(animation.Image, paletted)
+	}
+	return gif.EncodeAll(writer, animation)
+}
+
+// Returns a taller image with the counts of moves and pushes written beneath.
+func withCounter(board *image.RGBA, history hexoban.Solution, options AnimationOptions) *image.RGBA {
+	scale := max(1, options.TileSize/16)
+	if options.TileSize == 0 {
+		scale = DEFAULT_TILE_SIZE / 16
+	}
+	margin := 2 * scale
+	bounds := board.Bounds()
+	bounds.Max.Y += TextHeight(scale) + 2*margin
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, &image.Uniform{options.Theme.Background}, image.Point{}, draw.Src)
+	draw.Draw(img, board.Bounds(), board, image.Point{}, draw.Over)
+	text := fmt.Sprintf("moves %d  pushes %d", history.Moves(), history.Pushes())
+	DrawText(img, margin, board.Bounds().Max.Y+margin, text, scale, options.Theme.Label)
+	return img
+}
+
+// The most common colors of the frames, up to the 256 colors a GIF may have.
+// Those left out (from the anti-aliased edges) will be drawn with the nearest
+// color in the palette.
+func paletteOf(frames []*image.RGBA) color.Palette {
+	counts := make(map[color.RGBA]int)
+	for _, frame := range frames {
+		for offset := 0; offset < len(frame.Pix); offset += 4 {
+			pixel := frame.Pix[offset : offset+4 : offset+4]
+			counts[color.RGBA{pixel[0], pixel[1], pixel[2], pixel[3]}]++
+		}
+	}
+	colors := make([]color.RGBA, 0, len(counts))
+	for rgba := range counts {
+		colors = append(colors, rgba)
+	}
+	slices.SortFunc(colors, func(a, b color.RGBA) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		// Ties are broken by the color itself so that the palette is the same
+		// each time the same frames are drawn.
+		return int(a.R)<<16 + int(a.G)<<8 + int(a.B) - (int(b.R)<<16 + int(b.G)<<8 + int(b.B))
+	})
+	palette := make(color.Palette, 0, 256)
+	for _, rgba := range colors[:min(len(colors), 256)] {
+		palette = append(palette, rgba)
+	}
+	return palette
+}
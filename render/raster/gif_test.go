@@ -0,0 +1,102 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/raster/gif_test.go
+
+package raster
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"reflect"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+func TestEncodeGIF(t *testing.T) {
+	board, _ := Draw(treasureRoom(), Options{})
+	tests := []struct {
+		name    string
+		lurd    string
+		options AnimationOptions
+		delays  []int
+		height  int
+	}{
+		{"each step", "udR", AnimationOptions{}, []int{100, 15, 15, 100}, board.Bounds().Dy()},
+		{"pushes only", "udR", AnimationOptions{SkipWalks: true}, []int{100, 100}, board.Bounds().Dy()},
+		{"ending with a walk", "Rl", AnimationOptions{SkipWalks: true, Delay: 5, Hold: 50},
+			[]int{50, 5, 50}, board.Bounds().Dy()},
+		{"no steps", "", AnimationOptions{}, []int{100}, board.Bounds().Dy()},
+		{"counter", "R", AnimationOptions{Counter: true}, []int{100, 100},
+			board.Bounds().Dy() + TextHeight(2) + 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solution, err := hexoban.ParseSolution(tt.lurd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buffer bytes.Buffer
+			if err := EncodeGIF(&buffer, treasureRoom(), solution, tt.options); err != nil {
+				t.Fatalf("EncodeGIF() error = %v", err)
+			}
+			animation, err := gif.DecodeAll(&buffer)
+			if err != nil {
+				t.Fatalf("gif.DecodeAll() error = %v", err)
+			}
+			if !reflect.DeepEqual(animation.Delay, tt.delays) {
+				t.Errorf("delays = %v, expected %v", animation.Delay, tt.delays)
+			}
+			bounds := animation.Image[0].Bounds()
+			if bounds.Dx() != board.Bounds().Dx() || bounds.Dy() != tt.height {
+				t.Errorf("frame bounds = %v, expected %d by %d", bounds, board.Bounds().Dx(), tt.height)
+			}
+			// The background is opaque white, rather than transparent.
+			if r, g, b, a := animation.Image[0].At(0, 0).RGBA(); r != 0xFFFF || g != 0xFFFF ||
+				b != 0xFFFF || a != 0xFFFF {
+				t.Errorf("background = %v", animation.Image[0].At(0, 0))
+			}
+		})
+	}
+
+	// Illegal steps, and steps which push but are not written as pushes (or
+	// the reverse), are not drawn.
+	for lurd, index := range map[string]int{"uL": 2, "udr": 3, "uD": 2} {
+		solution, _ := hexoban.ParseSolution(lurd)
+		err := EncodeGIF(&bytes.Buffer{}, treasureRoom(), solution, AnimationOptions{})
+		var stepErr *hexoban.StepError
+		if !errors.As(err, &stepErr) || stepErr.Index != index {
+			t.Errorf("EncodeGIF(%s) error = %v, expected one for step %d", lurd, err, index)
+		}
+	}
+}
+
+func TestPaletteOf(t *testing.T) {
+	frame := image.NewRGBA(image.Rect(0, 0, 300, 2))
+	for x := 0; x < 300; x++ {
+		frame.SetRGBA(x, 0, color.RGBA{uint8(x), uint8(x >> 8), 0, 0xFF})
+		frame.SetRGBA(x, 1, color.RGBA{0, 0, 0xFF, 0xFF})
+	}
+	palette := paletteOf([]*image.RGBA{frame})
+	if len(palette) != 256 {
+		t.Fatalf("paletteOf() has %d colors, expected 256", len(palette))
+	}
+	if palette[0] != (color.RGBA{0, 0, 0xFF, 0xFF}) || palette[1] != (color.RGBA{0, 0, 0, 0xFF}) {
+		t.Errorf("paletteOf() begins with %v, %v", palette[0], palette[1])
+	}
+}
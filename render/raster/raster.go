@@ -0,0 +1,262 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/raster/raster.go
+
+// Package raster draws puzzles into images with only the standard library, for
+// the places that cannot show SVG.  Shapes are described by their signed
+// distance from each pixel, which makes anti-aliasing a matter of how far the
+// edge is from the pixel's center.
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render"
+)
+
+// The width of each hex when no TileSize is given, and the least it can be.
+const (
+	DEFAULT_TILE_SIZE = 32
+	MIN_TILE_SIZE     = 8
+)
+
+type Options struct {
+	render.Overlays
+	Orientation render.Orientation
+	Theme       render.Theme // render.THEME_LIGHT if it is the zero Theme.
+	TileSize    int          // the width of each hex in pixels, see DEFAULT_TILE_SIZE.
+}
+
+// Draws the puzzle's initial position.  Every position of the same puzzle (with
+// the same options) is drawn at the same size and place within the image.
+func Draw(puzzle hexoban.Puzzle, options Options) (*image.RGBA, error) {
+	scene, err := render.NewScene(puzzle, options.Orientation, options.Overlays)
+	if err != nil {
+		return nil, err
+	}
+	theme := options.Theme
+	if theme == (render.Theme{}) {
+		theme = render.THEME_LIGHT
+	}
+	size := float64(options.TileSize)
+	if options.TileSize == 0 {
+		size = DEFAULT_TILE_SIZE
+	}
+	if size < MIN_TILE_SIZE {
+		return nil, fmt.Errorf("tile size %d is smaller than %d", options.TileSize, MIN_TILE_SIZE)
+	}
+
+	layout := newLayout(scene, size)
+	canvas := canvas{image.NewRGBA(layout.bounds)}
+	if theme.Background.A > 0 {
+		draw.Draw(canvas.img, layout.bounds, &image.Uniform{theme.Background}, image.Point{}, draw.Src)
+	}
+
+	apothem, line := size/2, max(1, size/32)
+	for _, coord := range scene.Walls {
+		x, y := layout.center(coord)
+		canvas.fill(hexagon(x, y, apothem+0.5), theme.Wall)
+		canvas.stroke(hexagon(x, y, apothem), line, theme.WallOutline)
+	}
+	for _, coord := range scene.Floors {
+		x, y := layout.center(coord)
+		canvas.fill(hexagon(x, y, apothem+0.5), theme.Floor)
+		canvas.stroke(hexagon(x, y, apothem), line, theme.Outline)
+	}
+	for _, coord := range scene.Dead {
+		x, y := layout.center(coord)
+		canvas.fill(hexagon(x, y, apothem-line), theme.Dead)
+	}
+	for _, coord := range scene.Goals {
+		x, y := layout.center(coord)
+		canvas.stroke(circle(x, y, 0.42*apothem), size/12, theme.Goal)
+	}
+	for _, match := range scene.Matches {
+		x1, y1 := layout.center(match[0])
+		x2, y2 := layout.center(match[1])
+		canvas.fill(segment(x1, y1, x2, y2, size/32), theme.Matching)
+	}
+	for _, coord := range scene.Crates {
+		x, y := layout.center(coord)
+		fill := theme.Crate
+		if scene.IsGoal(coord) {
+			fill = theme.CrateGoal
+		}
+		canvas.fill(roundedBox(x, y, 0.55*apothem, 0.15*apothem), fill)
+		canvas.stroke(roundedBox(x, y, 0.55*apothem, 0.15*apothem), size/20, theme.CrateOutline)
+		canvas.stroke(roundedBox(x, y, 0.28*apothem, 0.05*apothem), size/24, theme.CrateOutline)
+	}
+	x, y := layout.center(scene.Ichiban)
+	canvas.fill(circle(x, y, 0.5*apothem), theme.Ichiban)
+	canvas.stroke(circle(x, y, 0.5*apothem), line, theme.Outline)
+
+	if options.Coordinates {
+		scale := max(1, int(size)/32)
+		for index, coord := range scene.Floors {
+			x, y := layout.center(coord)
+			label := fmt.Sprintf("%d,%d", scene.Labels[index].I(), scene.Labels[index].J())
+			DrawText(canvas.img, int(x)-TextWidth(label, scale)/2, int(y+0.5*apothem), label, scale, theme.Label)
+		}
+	}
+	return canvas.img, nil
+}
+
+// Draws the state's current position.
+func DrawState(state *hexoban.State, options Options) (*image.RGBA, error) {
+	return Draw(state.Puzzle(), options)
+}
+
+// Writes the puzzle's initial position as a PNG image.
+func EncodePNG(writer io.Writer, puzzle hexoban.Puzzle, options Options) error {
+	img, err := Draw(puzzle, options)
+	if err != nil {
+		return err
+	}
+	return png.Encode(writer, img)
+}
+
+// The placement of hexes within the image, pointy-topped with neighbors on the
+// same row one tile size apart.
+type layout struct {
+	bounds         image.Rectangle
+	minCol, minRow int
+	apothem        float64 // half of the tile size,
+	radius         float64 // and the distance from the center to each corner.
+}
+
+func newLayout(scene render.Scene, size float64) layout {
+	minCol, minRow, maxCol, maxRow := scene.Bounds()
+	radius := size / math.Sqrt(3)
+	width := float64(maxCol-minCol)*size/2 + size + 2
+	height := float64(maxRow-minRow)*1.5*radius + 2*radius + 2
+	return layout{
+		bounds:  image.Rect(0, 0, int(math.Ceil(width)), int(math.Ceil(height))),
+		minCol:  minCol,
+		minRow:  minRow,
+		apothem: size / 2,
+		radius:  radius,
+	}
+}
+
+// The position of the hex's center in the image, with a pixel of margin.
+func (layout layout) center(coord hexoban.HexCoord) (float64, float64) {
+	return 1 + layout.apothem*float64(render.Column(coord)-layout.minCol+1),
+		1 + layout.radius*(1.5*float64(coord.I()-layout.minRow)+1)
+}
+
+// A shape's signed distance (in pixels) from the point to its edge, negative
+// inside of the shape, and the bounds outside of which the shape has no part.
+type shape struct {
+	distance func(x, y float64) float64
+	bounds   image.Rectangle
+}
+
+func around(x, y, extent float64) image.Rectangle {
+	return image.Rect(
+		int(math.Floor(x-extent-1)), int(math.Floor(y-extent-1)),
+		int(math.Ceil(x+extent+1)), int(math.Ceil(y+extent+1)))
+}
+
+// A pointy-topped hexagon, its flat left and right sides are apothem from the
+// center.
+func hexagon(cx, cy, apothem float64) shape {
+	return shape{func(x, y float64) float64 {
+		x, y = math.Abs(x-cx), math.Abs(y-cy)
+		// The distance from the nearest of the sides, by symmetry either the
+		// vertical one or the one whose normal is 60 degrees from it.
+		return max(x, 0.5*x+math.Sqrt(3)/2*y) - apothem
+	}, around(cx, cy, 2*apothem/math.Sqrt(3))}
+}
+
+func circle(cx, cy, radius float64) shape {
+	return shape{func(x, y float64) float64 {
+		return math.Hypot(x-cx, y-cy) - radius
+	}, around(cx, cy, radius)}
+}
+
+// A square with rounded corners, its sides are half from the center.
+func roundedBox(cx, cy, half, corner float64) shape {
+	return shape{func(x, y float64) float64 {
+		qx, qy := math.Abs(x-cx)-half+corner, math.Abs(y-cy)-half+corner
+		return math.Hypot(max(qx, 0), max(qy, 0)) + min(max(qx, qy), 0) - corner
+	}, around(cx, cy, half)}
+}
+
+// A line from (x1, y1) to (x2, y2) with rounded ends, halfWidth on each side.
+func segment(x1, y1, x2, y2, halfWidth float64) shape {
+	dx, dy := x2-x1, y2-y1
+	length := dx*dx + dy*dy
+	return shape{func(x, y float64) float64 {
+		t := 0.0
+		if length > 0 {
+			t = min(1, max(0, ((x-x1)*dx+(y-y1)*dy)/length))
+		}
+		return math.Hypot(x-x1-t*dx, y-y1-t*dy) - halfWidth
+	}, image.Rect(int(min(x1, x2)), int(min(y1, y2)), int(max(x1, x2)), int(max(y1, y2))).
+		Inset(-int(halfWidth) - 2)}
+}
+
+type canvas struct {
+	img *image.RGBA
+}
+
+// Paints the inside of the shape.
+func (canvas canvas) fill(shape shape, rgba color.NRGBA) {
+	canvas.paint(shape.bounds, rgba, func(x, y float64) float64 {
+		return 0.5 - shape.distance(x, y)
+	})
+}
+
+// Paints along the edge of the shape, half of the width on each side.
+func (canvas canvas) stroke(shape shape, width float64, rgba color.NRGBA) {
+	canvas.paint(shape.bounds.Inset(-int(width)), rgba, func(x, y float64) float64 {
+		return width/2 + 0.5 - math.Abs(shape.distance(x, y))
+	})
+}
+
+// Blends the color over each pixel within the bounds, in proportion to how much
+// of the pixel is covered (estimated at the pixel's center).
+func (canvas canvas) paint(bounds image.Rectangle, rgba color.NRGBA, coverage func(x, y float64) float64) {
+	if rgba.A == 0 {
+		return
+	}
+	bounds = bounds.Intersect(canvas.img.Rect)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			covered := min(1, coverage(float64(x)+0.5, float64(y)+0.5))
+			if covered > 0 {
+				blend(canvas.img, x, y, rgba, covered)
+			}
+		}
+	}
+}
+
+// Composites the color, with its alpha reduced by the coverage, over the pixel.
+func blend(img *image.RGBA, x, y int, rgba color.NRGBA, coverage float64) {
+	alpha := float64(rgba.A) / 0xFF * coverage
+	offset := img.PixOffset(x, y)
+	pixel := img.Pix[offset : offset+4 : offset+4]
+	for channel, value := range [3]uint8{rgba.R, rgba.G, rgba.B} {
+		pixel[channel] = uint8(float64(value)*alpha + float64(pixel[channel])*(1-alpha) + 0.5)
+	}
+	pixel[3] = uint8(0xFF*alpha + float64(pixel[3])*(1-alpha) + 0.5)
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/raster/raster_test.go
+
+package raster
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render"
+)
+
+// .      # # #
+// .     #     #
+// .    # @ $ . #
+// .     # # # #
+func treasureRoom() hexoban.Puzzle {
+	at := hexoban.NewHexCoord
+	return hexoban.Puzzle{
+		Terrain: []hexoban.HexCoord{at(1, 2), at(1, 3), at(2, 2), at(2, 3), at(2, 4)},
+		Init: hexoban.Init{
+			Goals:   []hexoban.HexCoord{at(2, 4)},
+			Crates:  []hexoban.HexCoord{at(2, 3)},
+			Ichiban: at(2, 2),
+		},
+	}
+}
+
+// Returns the color of the pixel at the center of the hex.
+func colorAt(img *image.RGBA, scene render.Scene, size float64, coord hexoban.HexCoord) color.NRGBA {
+	x, y := newLayout(scene, size).center(coord)
+	return color.NRGBAModel.Convert(img.At(int(x), int(y))).(color.NRGBA)
+}
+
+func TestDraw(t *testing.T) {
+	at := hexoban.NewHexCoord
+	puzzle := treasureRoom()
+	img, err := Draw(puzzle, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 162, 123) {
+		t.Errorf("Draw() bounds = %v", img.Bounds())
+	}
+	if img.RGBAAt(0, 0).A != 0 {
+		t.Errorf("Draw() should leave the corners transparent, got %v", img.RGBAAt(0, 0))
+	}
+
+	scene, _ := render.NewScene(puzzle, render.Orientation{}, render.Overlays{})
+	theme := render.THEME_LIGHT
+	for _, tt := range []struct {
+		coord  hexoban.HexCoord
+		expect color.NRGBA
+	}{
+		{at(2, 2), theme.Ichiban},
+		{at(2, 3), theme.Crate},
+		{at(2, 4), theme.Floor}, // inside the goal's ring.
+		{at(1, 2), theme.Floor},
+		{at(1, 1), theme.Wall},
+	} {
+		if got := colorAt(img, scene, DEFAULT_TILE_SIZE, tt.coord); got != tt.expect {
+			t.Errorf("Draw() at %v = %v, expected %v", tt.coord, got, tt.expect)
+		}
+	}
+
+	state, _ := hexoban.NewState(puzzle)
+	state.Move(hexoban.DIR_RIGHT)
+	moved, err := DrawState(state, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Bounds() != img.Bounds() {
+		t.Errorf("DrawState() bounds = %v, expected %v", moved.Bounds(), img.Bounds())
+	}
+	if got := colorAt(moved, scene, DEFAULT_TILE_SIZE, at(2, 4)); got != theme.CrateGoal {
+		t.Errorf("DrawState() crate on its goal = %v, expected %v", got, theme.CrateGoal)
+	}
+}
+
+func TestDraw_Options(t *testing.T) {
+	at := hexoban.NewHexCoord
+	options := Options{
+		Overlays:    render.Overlays{Dead: true, Matching: true, Coordinates: true},
+		Orientation: render.Orientation{Rotation: 1},
+		Theme:       render.THEME_DARK,
+		TileSize:    48,
+	}
+	img, err := Draw(treasureRoom(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Turned a sixth, the rows of the room become diagonals.
+	scene, _ := render.NewScene(treasureRoom(), options.Orientation, options.Overlays)
+	minCol, minRow, maxCol, maxRow := scene.Bounds()
+	layout := newLayout(scene, 48)
+	if img.Bounds() != layout.bounds || maxCol-minCol != 7 || maxRow-minRow != 4 {
+		t.Errorf("Draw() bounds = %v, scene is %d by %d", img.Bounds(), maxCol-minCol, maxRow-minRow)
+	}
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{0x1E, 0x1E, 0x1E, 0xFF}) {
+		t.Errorf("Draw() background = %v", got)
+	}
+	floor := render.THEME_DARK.Floor
+	if got := colorAt(img, scene, 48, options.Orientation.Apply(at(1, 2))); got == floor {
+		t.Errorf("Draw() should mark the dead cell at (1, 2)")
+	}
+
+	if _, err := Draw(treasureRoom(), Options{TileSize: 4}); err == nil {
+		t.Errorf("Draw() expected an error for a tile size of 4")
+	}
+	if _, err := Draw(hexoban.Puzzle{}, Options{}); err == nil {
+		t.Errorf("Draw() expected an error without terrain")
+	}
+}
+
+func TestEncodePNG(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := EncodePNG(&buffer, treasureRoom(), Options{TileSize: 20}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buffer)
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	expect, _ := Draw(treasureRoom(), Options{TileSize: 20})
+	if img.Bounds() != expect.Bounds() {
+		t.Errorf("decoded bounds = %v, expected %v", img.Bounds(), expect.Bounds())
+	}
+}
+
+func TestBlend(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, color.RGBA{0, 0, 0xFF, 0xFF})
+	blend(img, 0, 0, color.NRGBA{0xFF, 0, 0, 0xFF}, 0.5)
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{0x80, 0, 0x80, 0xFF}) {
+		t.Errorf("blend() = %v", got)
+	}
+	blend(img, 0, 0, color.NRGBA{0, 0xFF, 0, 0}, 1)
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{0x80, 0, 0x80, 0xFF}) {
+		t.Errorf("blend() of a transparent color = %v", got)
+	}
+}
+
+func TestDrawText(t *testing.T) {
+	if got := TextWidth("moves 12", 2); got != 62 {
+		t.Errorf("TextWidth() = %d, expected 62", got)
+	}
+	if got := TextWidth("", 3); got != 0 {
+		t.Errorf("TextWidth(\"\") = %d", got)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 12))
+	DrawText(img, 1, 1, "1?", 2, color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF})
+	lit := 0
+	for y := 0; y < 12; y++ {
+		for x := 0; x < 20; x++ {
+			if img.RGBAAt(x, y).A > 0 {
+				lit++
+			}
+		}
+	}
+	// The '1' has eight dots of four pixels each, '?' is not in the font.
+	if lit != 32 {
+		t.Errorf("DrawText() lit %d pixels, expected 32", lit)
+	}
+	if img.RGBAAt(3, 1).A == 0 || img.RGBAAt(1, 1).A != 0 {
+		t.Errorf("DrawText() misplaced the '1'")
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/render.go
+
+// Package render holds what the renderers of puzzles have in common: the
+// orientation of the hex grid, color themes and the Scene, a puzzle's position
+// prepared for drawing.  Each output format has its own package, see ansi (for
+// terminals), svg and raster.
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// One of the twelve symmetries of the hex grid, a rotation optionally preceded
+// by mirroring left-to-right.  The zero value is the puzzle's own orientation.
+type Orientation struct {
+	Rotation int  // sixths of a turn, counter-clockwise.
+	Mirror   bool // reflects across the vertical axis, before rotating.
+}
+
+// Parses an orientation in degrees, a multiple of 60, followed by "m" if it is
+// also mirrored (e.g. "0", "120" or "60m").
+func ParseOrientation(text string) (Orientation, error) {
+	orientation := Orientation{}
+	degrees, mirror := strings.CutSuffix(strings.TrimSpace(text), "m")
+	angle, err := strconv.Atoi(degrees)
+	if err != nil || angle%60 != 0 {
+		return orientation, fmt.Errorf(
+			"invalid orientation %q, expected a multiple of 60 degrees (and m to mirror)", text)
+	}
+	orientation.Rotation = ((angle/60)%6 + 6) % 6
+	orientation.Mirror = mirror
+	return orientation, nil
+}
+
+func (orientation Orientation) String() string {
+	text := strconv.Itoa(60 * (((orientation.Rotation % 6) + 6) % 6))
+	if orientation.Mirror {
+		text += "m"
+	}
+	return text
+}
+
+// Returns the coordinate's position in this orientation.
+func (orientation Orientation) Apply(coord hexoban.HexCoord) hexoban.HexCoord {
+	i, j := coord.I(), coord.J()
+	if orientation.Mirror {
+		j = i - j
+	}
+	for turns := ((orientation.Rotation % 6) + 6) % 6; turns > 0; turns-- {
+		i, j = i-j, i
+	}
+	return hexoban.NewHexCoord(i, j)
+}
+
+// The colors for each part of a puzzle.  Colors which are fully transparent are
+// not drawn (e.g. the background of THEME_LIGHT).
+type Theme struct {
+	Background   color.NRGBA
+	Floor        color.NRGBA
+	Outline      color.NRGBA // the edges of floor hexes.
+	Wall         color.NRGBA
+	WallOutline  color.NRGBA
+	Goal         color.NRGBA
+	Crate        color.NRGBA
+	CrateOutline color.NRGBA
+	CrateGoal    color.NRGBA // a crate resting on a goal.
+	Ichiban      color.NRGBA
+	Dead         color.NRGBA // overlaid on dead cells.
+	Label        color.NRGBA // coordinates.
+	Matching     color.NRGBA // the lines joining crates to their matched goals.
+}
+
+// Based on the colors of the web app's puzzle view, on a transparent background.
+var THEME_LIGHT = Theme{
+	Floor:        color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF},
+	Outline:      color.NRGBA{0x00, 0x00, 0x00, 0xFF},
+	Wall:         color.NRGBA{0x22, 0x22, 0x22, 0xFF},
+	WallOutline:  color.NRGBA{0x55, 0x55, 0x55, 0xFF},
+	Goal:         color.NRGBA{0xB8, 0xB8, 0xB8, 0xFF},
+	Crate:        color.NRGBA{0xD4, 0xAA, 0x00, 0xFF},
+	CrateOutline: color.NRGBA{0xFF, 0xD7, 0x00, 0xFF},
+	CrateGoal:    color.NRGBA{0x5C, 0xA8, 0x3C, 0xFF},
+	Ichiban:      color.NRGBA{0xA8, 0x6C, 0xD0, 0xFF},
+	Dead:         color.NRGBA{0xE5, 0x39, 0x35, 0x40},
+	Label:        color.NRGBA{0x88, 0x88, 0x88, 0xFF},
+	Matching:     color.NRGBA{0x3F, 0x7F, 0xD0, 0xC0},
+}
+
+var THEME_DARK = Theme{
+	Background:   color.NRGBA{0x1E, 0x1E, 0x1E, 0xFF},
+	Floor:        color.NRGBA{0x33, 0x33, 0x33, 0xFF},
+	Outline:      color.NRGBA{0x88, 0x88, 0x88, 0xFF},
+	Wall:         color.NRGBA{0x11, 0x11, 0x11, 0xFF},
+	WallOutline:  color.NRGBA{0x44, 0x44, 0x44, 0xFF},
+	Goal:         color.NRGBA{0xB8, 0xB8, 0xB8, 0xFF},
+	Crate:        color.NRGBA{0xD4, 0xAA, 0x00, 0xFF},
+	CrateOutline: color.NRGBA{0xFF, 0xD7, 0x00, 0xFF},
+	CrateGoal:    color.NRGBA{0x7C, 0xC8, 0x5C, 0xFF},
+	Ichiban:      color.NRGBA{0xD5, 0xAD, 0xEE, 0xFF},
+	Dead:         color.NRGBA{0xE5, 0x39, 0x35, 0x50},
+	Label:        color.NRGBA{0xAA, 0xAA, 0xAA, 0xFF},
+	Matching:     color.NRGBA{0x64, 0xB5, 0xF6, 0xC0},
+}
+
+var THEMES = map[string]Theme{
+	"light": THEME_LIGHT,
+	"dark":  THEME_DARK,
+}
+
+// Returns the theme with this name (one of the keys of THEMES).
+func ParseTheme(name string) (Theme, error) {
+	if theme, exists := THEMES[strings.ToLower(name)]; exists {
+		return theme, nil
+	}
+	names := make([]string, 0, len(THEMES))
+	for known := range THEMES {
+		names = append(names, known)
+	}
+	slices.Sort(names)
+	return Theme{}, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// Optional annotations drawn over the puzzle.
+type Overlays struct {
+	Dead        bool // marks the dead cells, see hexoban.State.IsDeadCell().
+	Coordinates bool // labels each floor hex with its (i, j) coordinate.
+	Matching    bool // joins each crate to its goal in State.Matching().
+}
+
+// The parts of a puzzle in the position to be drawn, with all coordinates in
+// the chosen orientation.
+type Scene struct {
+	Floors  []hexoban.HexCoord // in the order of the puzzle's terrain,
+	Labels  []hexoban.HexCoord // with the puzzle's own coordinate for each.
+	Walls   []hexoban.HexCoord // the neighbors of floors which are not floors.
+	Goals   []hexoban.HexCoord
+	Crates  []hexoban.HexCoord
+	Ichiban hexoban.HexCoord
+	Dead    []hexoban.HexCoord    // with Overlays.Dead,
+	Matches [][2]hexoban.HexCoord // and each crate and goal, with Overlays.Matching.
+}
+
+// Prepares the puzzle's initial position for drawing.  The puzzle only needs to
+// be playable (see hexoban.NewState) for the dead cell and matching overlays.
+func NewScene(puzzle hexoban.Puzzle, orientation Orientation, overlays Overlays) (Scene, error) {
+	if len(puzzle.Terrain) == 0 {
+		return Scene{}, fmt.Errorf("no terrain coordinates are defined")
+	}
+	scene := Scene{Ichiban: orientation.Apply(puzzle.Init.Ichiban)}
+	floors := make(map[hexoban.HexCoord]bool, len(puzzle.Terrain))
+	for _, coord := range puzzle.Terrain {
+		if !floors[coord] {
+			floors[coord] = true
+			scene.Floors = append(scene.Floors, orientation.Apply(coord))
+			scene.Labels = append(scene.Labels, coord)
+		}
+	}
+	walls := make(map[hexoban.HexCoord]bool)
+	for _, coord := range scene.Labels {
+		for _, dir := range hexoban.Directions {
+			neighbor := coord.Neighbor(dir)
+			if !floors[neighbor] && !walls[neighbor] {
+				walls[neighbor] = true
+				scene.Walls = append(scene.Walls, orientation.Apply(neighbor))
+			}
+		}
+	}
+	for _, goal := range puzzle.Init.Goals {
+		scene.Goals = append(scene.Goals, orientation.Apply(goal))
+	}
+	for _, crate := range puzzle.Init.Crates {
+		scene.Crates = append(scene.Crates, orientation.Apply(crate))
+	}
+
+	if !overlays.Dead && !overlays.Matching {
+		return scene, nil
+	}
+	state, err := hexoban.NewState(puzzle)
+	if err != nil {
+		return scene, err
+	}
+	if overlays.Dead {
+		for _, coord := range scene.Labels {
+			if state.IsDeadCell(coord) {
+				scene.Dead = append(scene.Dead, orientation.Apply(coord))
+			}
+		}
+	}
+	if overlays.Matching {
+		matching := state.Matching()
+		for _, crate := range state.Crates() {
+			if goal, matched := matching[crate]; matched {
+				scene.Matches = append(scene.Matches,
+					[2]hexoban.HexCoord{orientation.Apply(crate), orientation.Apply(goal)})
+			}
+		}
+	}
+	return scene, nil
+}
+
+// The column of the coordinate in the text map layout, where neighbors on the
+// same row are two columns apart (see textmap.HexToRect).  The row is coord.I().
+func Column(coord hexoban.HexCoord) int {
+	return 2*coord.J() - coord.I()
+}
+
+// Returns the least and greatest column and row of all the scene's hexes,
+// including its walls.
+func (scene Scene) Bounds() (minCol, minRow, maxCol, maxRow int) {
+	first := scene.Floors[0]
+	minCol, minRow, maxCol, maxRow = Column(first), first.I(), Column(first), first.I()
+	for _, coords := range [][]hexoban.HexCoord{scene.Floors, scene.Walls} {
+		for _, coord := range coords {
+			minCol, maxCol = min(minCol, Column(coord)), max(maxCol, Column(coord))
+			minRow, maxRow = min(minRow, coord.I()), max(maxRow, coord.I())
+		}
+	}
+	return minCol, minRow, maxCol, maxRow
+}
+
+// Returns true if the coordinate (in the scene's orientation) is a goal.
+func (scene Scene) IsGoal(coord hexoban.HexCoord) bool {
+	return slices.Contains(scene.Goals, coord)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/render_test.go
+
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+func TestParseOrientation(t *testing.T) {
+	tests := []struct {
+		text   string
+		expect Orientation
+		name   string
+	}{
+		{"0", Orientation{}, "0"},
+		{"60", Orientation{Rotation: 1}, "60"},
+		{" 300m", Orientation{Rotation: 5, Mirror: true}, "300m"},
+		{"360", Orientation{}, "0"},
+		{"-60", Orientation{Rotation: 5}, "300"},
+		{"0m", Orientation{Mirror: true}, "0m"},
+	}
+	for _, tt := range tests {
+		got, err := ParseOrientation(tt.text)
+		if err != nil || got != tt.expect || got.String() != tt.name {
+			t.Errorf("ParseOrientation(%q) = %v (%s), %v", tt.text, got, got, err)
+		}
+	}
+	for _, text := range []string{"", "45", "m", "90m", "left"} {
+		if _, err := ParseOrientation(text); err == nil {
+			t.Errorf("ParseOrientation(%q) expected an error", text)
+		}
+	}
+}
+
+func TestOrientation_Apply(t *testing.T) {
+	at := hexoban.NewHexCoord
+	right := at(0, 1)
+	tests := []struct {
+		orientation Orientation
+		expect      hexoban.HexCoord
+	}{
+		{Orientation{}, right},
+		{Orientation{Rotation: 1}, at(-1, 0)},  // up
+		{Orientation{Rotation: 2}, at(-1, -1)}, // backward
+		{Orientation{Rotation: 3}, at(0, -1)},  // left
+		{Orientation{Rotation: 6}, right},
+		{Orientation{Mirror: true}, at(0, -1)},
+		{Orientation{Rotation: 1, Mirror: true}, at(1, 0)}, // left, then down
+	}
+	for _, tt := range tests {
+		if got := tt.orientation.Apply(right); got != tt.expect {
+			t.Errorf("%v.Apply(%v) = %v, expected %v", tt.orientation, right, got, tt.expect)
+		}
+	}
+
+	// Every orientation keeps neighbors as neighbors.
+	for rotation := 0; rotation < 6; rotation++ {
+		for _, mirror := range []bool{false, true} {
+			orientation := Orientation{rotation, mirror}
+			origin := orientation.Apply(at(3, 5))
+			for _, dir := range hexoban.Directions {
+				moved := orientation.Apply(at(3, 5).Neighbor(dir))
+				adjacent := false
+				for _, other := range hexoban.Directions {
+					adjacent = adjacent || origin.Neighbor(other) == moved
+				}
+				if !adjacent {
+					t.Errorf("%v moves %v to %v, not adjacent to %v", orientation, dir, moved, origin)
+				}
+			}
+		}
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	if theme, err := ParseTheme("Dark"); err != nil || theme != THEME_DARK {
+		t.Errorf("ParseTheme(Dark) = %v, %v", theme, err)
+	}
+	if _, err := ParseTheme("neon"); err == nil {
+		t.Errorf("ParseTheme(neon) expected an error")
+	}
+}
+
+// .      # # #
+// .     #     #
+// .    # @ $ . #
+// .     # # # #
+func treasureRoom() hexoban.Puzzle {
+	at := hexoban.NewHexCoord
+	return hexoban.Puzzle{
+		Terrain: []hexoban.HexCoord{at(1, 2), at(1, 3), at(2, 2), at(2, 3), at(2, 4)},
+		Init: hexoban.Init{
+			Goals:   []hexoban.HexCoord{at(2, 4)},
+			Crates:  []hexoban.HexCoord{at(2, 3)},
+			Ichiban: at(2, 2),
+		},
+	}
+}
+
+func TestNewScene(t *testing.T) {
+	at := hexoban.NewHexCoord
+	scene, err := NewScene(treasureRoom(), Orientation{}, Overlays{Dead: true, Matching: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scene.Floors) != 5 || len(scene.Walls) != 11 {
+		t.Errorf("NewScene() has %d floors and %d walls", len(scene.Floors), len(scene.Walls))
+	}
+	minCol, minRow, maxCol, maxRow := scene.Bounds()
+	if minCol != 0 || minRow != 0 || maxCol != 8 || maxRow != 3 {
+		t.Errorf("Bounds() = %d, %d, %d, %d", minCol, minRow, maxCol, maxRow)
+	}
+	expectDead := []hexoban.HexCoord{at(1, 2), at(1, 3), at(2, 2)}
+	if !reflect.DeepEqual(scene.Dead, expectDead) {
+		t.Errorf("Dead = %v, expected %v", scene.Dead, expectDead)
+	}
+	if len(scene.Matches) != 1 || scene.Matches[0] != [2]hexoban.HexCoord{at(2, 3), at(2, 4)} {
+		t.Errorf("Matches = %v", scene.Matches)
+	}
+
+	turned, err := NewScene(treasureRoom(), Orientation{Rotation: 3}, Overlays{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turned.Ichiban != at(-2, -2) || turned.Labels[0] != at(1, 2) ||
+		turned.Floors[0] != at(-1, -2) || !turned.IsGoal(at(-2, -4)) {
+		t.Errorf("rotated half a turn = %+v", turned)
+	}
+
+	unplayable := treasureRoom()
+	unplayable.Init.Crates = nil
+	if _, err := NewScene(unplayable, Orientation{}, Overlays{}); err != nil {
+		t.Errorf("NewScene() of an unplayable puzzle: %v", err)
+	}
+	if _, err := NewScene(unplayable, Orientation{}, Overlays{Dead: true}); err == nil {
+		t.Errorf("NewScene() expected an error for dead cells of an unplayable puzzle")
+	}
+	if _, err := NewScene(hexoban.Puzzle{}, Orientation{}, Overlays{}); err == nil {
+		t.Errorf("NewScene() expected an error without terrain")
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/svg/svg.go
+
+// Package svg renders puzzles as SVG documents, in the style of the web app's
+// puzzle view: each kind of tile is a symbol in the document's <defs> which is
+// placed on the hex grid with a <use> element.
+package svg
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render"
+)
+
+// The half-width of a hex and the distance between rows, in SVG user units.  A
+// hex's center is at (HALF_WIDTH * column, ROW_HEIGHT * row), see render.Column.
+const (
+	HALF_WIDTH = 9
+	ROW_HEIGHT = 15
+)
+
+// The corners of a hex around its center, a pointy-topped hexagon.
+const HEX_POINTS = "-9,-5 -9,5 0,10 9,5 9,-5 0,-10"
+
+type Options struct {
+	render.Overlays
+	Orientation render.Orientation
+	Theme       render.Theme // render.THEME_LIGHT if it is the zero Theme.
+	Width       int          // the width in pixels, or zero to leave it to the viewer.
+}
+
+// Writes the puzzle's initial position as an SVG document.
+func Encode(writer io.Writer, puzzle hexoban.Puzzle, options Options) error {
+	scene, err := render.NewScene(puzzle, options.Orientation, options.Overlays)
+	if err != nil {
+		return err
+	}
+	theme := options.Theme
+	if theme == (render.Theme{}) {
+		theme = render.THEME_LIGHT
+	}
+
+	output := bufio.NewWriter(writer)
+	minCol, minRow, maxCol, maxRow := scene.Bounds()
+	view := [4]int{
+		HALF_WIDTH*minCol - 10, ROW_HEIGHT*minRow - 11,
+		HALF_WIDTH*(maxCol-minCol) + 20, ROW_HEIGHT*(maxRow-minRow) + 22}
+	fmt.Fprintf(output, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"`+
+		` viewBox="%d %d %d %d"`, view[0], view[1], view[2], view[3])
+	if options.Width > 0 {
+		fmt.Fprintf(output, ` width="%d" height="%d"`, options.Width, (options.Width*view[3]+view[2]/2)/view[2])
+	}
+	fmt.Fprintln(output, ` font-family="sans-serif" font-size="16px">`)
+	if puzzle.Title != "" {
+		title := puzzle.Title
+		if puzzle.Author != "" {
+			title += " by " + puzzle.Author
+		}
+		fmt.Fprintf(output, "  <title>%s</title>\n", html.EscapeString(title))
+	}
+	writeDefs(output, theme)
+	if theme.Background.A > 0 {
+		fmt.Fprintf(output, `  <rect x="%d" y="%d" width="%d" height="%d"%s />`+"\n",
+			view[0], view[1], view[2], view[3], paint("fill", theme.Background))
+	}
+
+	fmt.Fprintln(output, `  <g class="hexgrid">`)
+	place := func(class, symbol string, coord hexoban.HexCoord) {
+		x, y := center(coord)
+		fmt.Fprintf(output, `    <use class="%s" xlink:href="#%s" transform="translate(%d, %d)" />`+"\n",
+			class, symbol, x, y)
+	}
+	for _, coord := range scene.Floors {
+		place("floor", "floor", coord)
+	}
+	for _, coord := range scene.Walls {
+		place("wall", "wall", coord)
+	}
+	for _, coord := range scene.Dead {
+		place("dead", "dead", coord)
+	}
+	for _, coord := range scene.Goals {
+		place("goal", "goal", coord)
+	}
+	for _, match := range scene.Matches {
+		x1, y1 := center(match[0])
+		x2, y2 := center(match[1])
+		fmt.Fprintf(output, `    <line class="match" x1="%d" y1="%d" x2="%d" y2="%d"%s`+
+			` stroke-width="1.5" stroke-dasharray="3,2" />`+"\n",
+			x1, y1, x2, y2, paint("stroke", theme.Matching))
+	}
+	for _, coord := range scene.Crates {
+		if scene.IsGoal(coord) {
+			place("crate", "crate-goal", coord)
+		} else {
+			place("crate", "crate", coord)
+		}
+	}
+	place("ichiban", "at", scene.Ichiban)
+	if options.Coordinates {
+		for index, coord := range scene.Floors {
+			x, y := center(coord)
+			label := scene.Labels[index]
+			fmt.Fprintf(output, `    <text class="coord" x="%d" y="%d" font-size="4" text-anchor="middle"%s>%d,%d</text>`+"\n",
+				x, y+8, paint("fill", theme.Label), label.I(), label.J())
+		}
+	}
+	fmt.Fprintln(output, "  </g>")
+	fmt.Fprintln(output, "</svg>")
+	return output.Flush()
+}
+
+// Writes the state's current position as an SVG document.
+func EncodeState(writer io.Writer, state *hexoban.State, options Options) error {
+	return Encode(writer, state.Puzzle(), options)
+}
+
+// The position of the hex's center in SVG user units.
+func center(coord hexoban.HexCoord) (int, int) {
+	return HALF_WIDTH * render.Column(coord), ROW_HEIGHT * coord.I()
+}
+
+// The symbol definitions, with the same shapes as the web app's HexView.
+func writeDefs(output io.Writer, theme render.Theme) {
+	fmt.Fprintf(output, `  <defs>
+    <!-- floor tiles -->
+    <g id="floor">
+      <polygon%s%s stroke-width="0.5" points="%s" />
+    </g>
+    <g id="wall">
+      <polygon%s%s stroke-width="0.7" points="%s" />
+    </g>
+    <g id="dead">
+      <polygon%s points="%s" />
+    </g>
+    <!-- goal tiles, immovable -->
+    <g id="goal">
+      <circle cx="0" cy="0" r="5.0" fill="none"%s stroke-width="1.2" />
+    </g>
+`, paint("fill", theme.Floor), paint("stroke", theme.Outline), HEX_POINTS,
+		paint("fill", theme.Wall), paint("stroke", theme.WallOutline), HEX_POINTS,
+		paint("fill", theme.Dead), HEX_POINTS,
+		paint("stroke", theme.Goal))
+	// Crates are two dollar signs, one turned a quarter, in a different color
+	// when they are resting on a goal.
+	fmt.Fprintln(output, "    <!-- crates, the movable objects -->")
+	for _, crate := range []struct {
+		id   string
+		fill color.NRGBA
+	}{{"crate", theme.Crate}, {"crate-goal", theme.CrateGoal}} {
+		fmt.Fprintf(output, `    <g id="%s">
+      <text x="-3.4" y="1.2" font-size=".85em" dominant-baseline="middle" stroke-width="0.2"%s%s>$</text>
+      <text x="-3.4" y="1.2" transform="rotate(90)" font-size=".85em" dominant-baseline="middle" stroke-width="0.2"%s%s>$</text>
+    </g>
+`, crate.id, paint("stroke", theme.CrateOutline), paint("fill", crate.fill),
+			paint("stroke", theme.CrateOutline), paint("fill", crate.fill))
+	}
+	fmt.Fprintf(output, `    <!-- the player character -->
+    <g id="at">
+      <text x="-5.2" y="0" font-size=".85em" dominant-baseline="middle" stroke-width="0.2"%s%s>@</text>
+    </g>
+  </defs>
+`, paint("stroke", theme.Ichiban), paint("fill", theme.Ichiban))
+}
+
+// Returns the attribute (fill or stroke) for the color, and its opacity if it
+// is translucent.
+func paint(attribute string, rgba color.NRGBA) string {
+	switch rgba.A {
+	case 0:
+		return fmt.Sprintf(` %s="none"`, attribute)
+	case 0xFF:
+		return fmt.Sprintf(` %s="#%02x%02x%02x"`, attribute, rgba.R, rgba.G, rgba.B)
+	}
+	return fmt.Sprintf(` %s="#%02x%02x%02x" %s-opacity="%.2f"`,
+		attribute, rgba.R, rgba.G, rgba.B, attribute, float64(rgba.A)/0xFF)
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/render/svg/svg_test.go
+
+package svg
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+	"github.com/SymbolNotFound/hexoban/render"
+)
+
+// .      # # #
+// .     #     #
+// .    # @ $ . #
+// .     # # # #
+func treasureRoom() hexoban.Puzzle {
+	at := hexoban.NewHexCoord
+	return hexoban.Puzzle{
+		Title:   "Treasure <Room>",
+		Terrain: []hexoban.HexCoord{at(1, 2), at(1, 3), at(2, 2), at(2, 3), at(2, 4)},
+		Init: hexoban.Init{
+			Goals:   []hexoban.HexCoord{at(2, 4)},
+			Crates:  []hexoban.HexCoord{at(2, 3)},
+			Ichiban: at(2, 2),
+		},
+	}
+}
+
+// Returns the document, failing the test if it is not well-formed XML.
+func encode(t *testing.T, puzzle hexoban.Puzzle, options Options) string {
+	t.Helper()
+	var text strings.Builder
+	if err := Encode(&text, puzzle, options); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	decoder := xml.NewDecoder(strings.NewReader(text.String()))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Encode() is not well-formed: %v\n%s", err, text.String())
+		}
+	}
+	return text.String()
+}
+
+func TestEncode(t *testing.T) {
+	document := encode(t, treasureRoom(), Options{Width: 184})
+	for _, expect := range []string{
+		`viewBox="-10 -11 92 67" width="184" height="134"`,
+		`<title>Treasure &lt;Room&gt;</title>`,
+		`<use class="ichiban" xlink:href="#at" transform="translate(18, 30)" />`,
+		`<use class="crate" xlink:href="#crate" transform="translate(36, 30)" />`,
+		`<use class="goal" xlink:href="#goal" transform="translate(54, 30)" />`,
+	} {
+		if !strings.Contains(document, expect) {
+			t.Errorf("Encode() is missing %s\n%s", expect, document)
+		}
+	}
+	for class, count := range map[string]int{
+		"floor": 5, "wall": 11, "goal": 1, "crate": 1, "ichiban": 1, "dead": 0} {
+		if got := strings.Count(document, `<use class="`+class+`"`); got != count {
+			t.Errorf("Encode() has %d %s, expected %d", got, class, count)
+		}
+	}
+	if strings.Contains(document, "<rect") || strings.Contains(document, "<line") ||
+		strings.Contains(document, `class="coord"`) {
+		t.Errorf("Encode() has a background or overlays\n%s", document)
+	}
+}
+
+func TestEncode_Options(t *testing.T) {
+	puzzle := treasureRoom()
+	puzzle.Init.Crates = puzzle.Init.Goals
+	document := encode(t, puzzle, Options{
+		Overlays:    render.Overlays{Dead: true, Coordinates: true, Matching: true},
+		Orientation: render.Orientation{Rotation: 3},
+		Theme:       render.THEME_DARK,
+	})
+	for _, expect := range []string{
+		`<rect x="-82" y="-56" width="92" height="67" fill="#1e1e1e" />`,
+		`<use class="ichiban" xlink:href="#at" transform="translate(-18, -30)" />`,
+		`<use class="crate" xlink:href="#crate-goal" transform="translate(-54, -30)" />`,
+		`<line class="match" x1="-54" y1="-30" x2="-54" y2="-30"`,
+		`>1,2</text>`,
+	} {
+		if !strings.Contains(document, expect) {
+			t.Errorf("Encode() is missing %s\n%s", expect, document)
+		}
+	}
+	if got := strings.Count(document, `<use class="dead"`); got != 3 {
+		t.Errorf("Encode() has %d dead cells, expected 3", got)
+	}
+	if got := strings.Count(document, `class="coord"`); got != 5 {
+		t.Errorf("Encode() has %d coordinates, expected 5", got)
+	}
+}
+
+func TestEncode_Levels(t *testing.T) {
+	paths, err := filepath.Glob("../../levels/*/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no levels found: %v", err)
+	}
+	for _, path := range paths {
+		filedata, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var puzzle hexoban.Puzzle
+		if err := json.Unmarshal(filedata, &puzzle); err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		document := encode(t, puzzle, Options{Overlays: render.Overlays{Dead: true, Matching: true}})
+		if got := strings.Count(document, `<use class="crate"`); got != len(puzzle.Init.Crates) {
+			t.Errorf("%s has %d crates, expected %d", path, got, len(puzzle.Init.Crates))
+		}
+	}
+}
+
+func TestEncodeState(t *testing.T) {
+	state, err := hexoban.NewState(treasureRoom())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := state.Move(hexoban.DIR_RIGHT); err != nil {
+		t.Fatal(err)
+	}
+	var text strings.Builder
+	if err := EncodeState(&text, state, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), `<use class="crate" xlink:href="#crate-goal" transform="translate(54, 30)" />`) {
+		t.Errorf("EncodeState() after pushing the crate onto its goal\n%s", text.String())
+	}
+}
+
+func TestPaint(t *testing.T) {
+	tests := []struct {
+		rgba   color.NRGBA
+		expect string
+	}{
+		{color.NRGBA{}, ` fill="none"`},
+		{color.NRGBA{0x12, 0xAB, 0x00, 0xFF}, ` fill="#12ab00"`},
+		{color.NRGBA{0xFF, 0x00, 0x00, 0x80}, ` fill="#ff0000" fill-opacity="0.50"`},
+	}
+	for _, tt := range tests {
+		if got := paint("fill", tt.rgba); got != tt.expect {
+			t.Errorf("paint(%v) = %q, expected %q", tt.rgba, got, tt.expect)
+		}
+	}
+}
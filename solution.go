@@ -0,0 +1,95 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/solution.go
+
+package hexoban
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A single step of the worker in hex-LURD notation, the letter of its direction
+// in lowercase for moves and in uppercase for pushes (moving a crate with it).
+type Step byte
+
+// Constructor function for a step, a move if push is false.
+func NewStep(dir Direction, push bool) Step {
+	if push {
+		return Step(dir - 'a' + 'A')
+	}
+	return Step(dir)
+}
+
+// The direction that the worker moves in for this step.
+func (step Step) Direction() Direction {
+	if step.IsPush() {
+		return Direction(step - 'A' + 'a')
+	}
+	return Direction(step)
+}
+
+// Returns true if this step also pushes a crate.
+func (step Step) IsPush() bool {
+	return 'A' <= step && step <= 'Z'
+}
+
+// A sequence of steps, from a puzzle's initial state or any intermediate state.
+// Serialized as its hex-LURD string, e.g. "bRRdfU".
+type Solution []Step
+
+// Parses a hex-LURD string into a Solution.  Whitespace is ignored so that long
+// solutions may be wrapped over several lines.
+func ParseSolution(lurd string) (Solution, error) {
+	solution := make(Solution, 0, len(lurd))
+	for index := 0; index < len(lurd); index++ {
+		letter := lurd[index]
+		switch letter {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		dir, err := ParseDirection(letter)
+		if err != nil {
+			return nil, fmt.Errorf("at position %d: %s", index, err)
+		}
+		solution = append(solution, NewStep(dir, 'A' <= letter && letter <= 'Z'))
+	}
+	return solution, nil
+}
+
+// Satisfies the fmt.Stringer interface with the hex-LURD representation.
+func (solution Solution) String() string {
+	var builder strings.Builder
+	for _, step := range solution {
+		builder.WriteByte(byte(step))
+	}
+	return builder.String()
+}
+
+// The number of steps taken, including pushes.
+func (solution Solution) Moves() int {
+	return len(solution)
+}
+
+// The number of steps which pushed a crate.
+func (solution Solution) Pushes() int {
+	pushes := 0
+	for _, step := range solution {
+		if step.IsPush() {
+			pushes++
+		}
+	}
+	return pushes
+}
@@ -0,0 +1,344 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/solver.go
+
+package hexoban
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// A best-first search over push positions, where the worker's position is
+// normalized to the region it can reach without pushing.  Which position is
+// expanded next is decided by the solver's Advisor (see /fess/README.md), so
+// that the same search can behave as an optimal A* search, a greedy search or
+// a deliberately weak baseline.
+
+// Returned when the search has visited every reachable position without
+// finding one where all crates are on goals.
+var ErrNoSolution = errors.New("no solution exists from this position")
+
+// Returned (wrapped) when the solver's context is done or its node limit is
+// reached before a solution was found.
+var ErrBudgetExhausted = errors.New("search budget exhausted before a solution was found")
+
+// Advisors assign a priority to each position in the search, the position with
+// the lowest priority is expanded next.  They are given the number of pushes
+// made to reach the position and a lower bound on the pushes still needed.
+type Advisor interface {
+	Priority(pushes, lowerBound int) int
+}
+
+type advisorFunc func(pushes, lowerBound int) int
+
+func (advise advisorFunc) Priority(pushes, lowerBound int) int {
+	return advise(pushes, lowerBound)
+}
+
+var (
+	// Finds solutions with the fewest pushes, possibly slowly.
+	AStarAdvisor Advisor = advisorFunc(func(pushes, lowerBound int) int {
+		return pushes + lowerBound
+	})
+	// Favors positions closer to the goal, trading optimality for speed.
+	WeightedAdvisor Advisor = advisorFunc(func(pushes, lowerBound int) int {
+		return pushes + 3*lowerBound
+	})
+	// Ignores the lower bound entirely, a breadth-first search over pushes.
+	BaselineAdvisor Advisor = advisorFunc(func(pushes, lowerBound int) int {
+		return pushes
+	})
+)
+
+// Configuration for a search.  The zero value uses the WeightedAdvisor and
+// searches until a solution is found or the context is done.
+type Solver struct {
+	Advisor  Advisor
+	MaxNodes int // the number of positions to expand before giving up, 0 for no limit.
+
+	// If set, called (from the searching goroutine) every ProgressInterval
+	// expansions and once more when the search ends, however it ends.
+	Progress         func(SolverProgress)
+	ProgressInterval int // DEFAULT_PROGRESS_INTERVAL if zero.
+}
+
+// Counters describing the effort spent by a search.
+type SolverStats struct {
+	Nodes     int // positions expanded.
+	Generated int // positions added to the open list, including duplicates.
+}
+
+// Searches for a solution from the state's current position.  The steps
+// returned (moves and pushes) lead from the current position to the solved
+// position, they do not include the state's history.  Returns ErrNoSolution
+// if the position cannot be solved and a wrapped ErrBudgetExhausted if the
+// context is done or MaxNodes is reached first.
+func (solver Solver) Solve(ctx context.Context, state *State) (Solution, SolverStats, error) {
+	advisor := solver.Advisor
+	if advisor == nil {
+		advisor = WeightedAdvisor
+	}
+	search := newSearch(state.board)
+	stats := SolverStats{}
+	tracker := newProgressTracker(solver, state)
+	if tracker != nil {
+		defer func() { tracker.send(search, stats, true) }()
+	}
+
+	root := search.add(-1, state.crates, state.ichiban, 0, 0, 0)
+	if root < 0 {
+		return nil, stats, ErrNoSolution
+	}
+	heap.Push(&search.open, openEntry{root, advisor.Priority(0, search.nodes[root].lowerBound)})
+	stats.Generated++
+
+	for search.open.Len() > 0 {
+		if stats.Nodes%1024 == 0 && ctx.Err() != nil {
+			return nil, stats, fmt.Errorf("%w: %w", ErrBudgetExhausted, ctx.Err())
+		}
+		if solver.MaxNodes > 0 && stats.Nodes >= solver.MaxNodes {
+			return nil, stats, fmt.Errorf("%w: expanded %d positions", ErrBudgetExhausted, stats.Nodes)
+		}
+
+		entry := heap.Pop(&search.open).(openEntry)
+		node := &search.nodes[entry.node]
+		if node.closed || search.seen[node.key] != entry.node {
+			continue // already expanded, or superseded by a path with fewer pushes.
+		}
+		node.closed = true
+		stats.Nodes++
+		if tracker != nil {
+			tracker.expanded(search, entry.node, stats)
+		}
+		if node.lowerBound == 0 {
+			return search.solution(state, entry.node), stats, nil
+		}
+
+		crates := search.decode(node.key)
+		reach := search.board.reachable(crates, node.worker)
+		pushes := node.pushes
+		for cell, present := range crates {
+			if !present {
+				continue
+			}
+			for d := range Directions {
+				behind := search.board.neighbors[cell][(d+3)%6]
+				ahead := search.board.neighbors[cell][d]
+				if behind < 0 || !reach[behind] || ahead < 0 || crates[ahead] {
+					continue
+				}
+				if search.board.isDead(ahead) {
+					continue
+				}
+				crates[cell], crates[ahead] = false, true
+				child := search.add(entry.node, crates, cell, ahead, d, pushes+1)
+				crates[cell], crates[ahead] = true, false
+				if child >= 0 {
+					priority := advisor.Priority(pushes+1, search.nodes[child].lowerBound)
+					heap.Push(&search.open, openEntry{child, priority})
+					stats.Generated++
+				}
+			}
+		}
+	}
+	return nil, stats, ErrNoSolution
+}
+
+// The solver's working memory, a table of positions and the open list.
+type search struct {
+	board *board
+	nodes []searchNode
+	seen  map[string]int // from position key to its index in nodes.
+	open  openList
+}
+
+// A position in the search, the crates are encoded into the key along with
+// the normalized worker position (the least cell index it can reach).
+type searchNode struct {
+	key        string
+	worker     int // the worker's actual position after the push.
+	parent     int
+	crate      int // the cell the crate was pushed from, -1 for the root.
+	dir        int // index of the push direction in Directions.
+	pushes     int
+	lowerBound int
+	closed     bool
+}
+
+func newSearch(board *board) *search {
+	return &search{board: board, seen: make(map[string]int)}
+}
+
+// Adds the position to the search if it was not already seen with fewer
+// pushes and it is not (simply) deadlocked.  The pushed crate's new cell is
+// given in `pushed` so that only its neighborhood is checked for freezing.
+// Returns the index of the new node, or -1 if the position was not added.
+func (search *search) add(parent int, crates []bool, worker, pushed, dir, pushes int) int {
+	reach := search.board.reachable(crates, worker)
+	normal := worker
+	for cell, reached := range reach {
+		if reached {
+			normal = cell
+			break
+		}
+	}
+
+	key := search.encode(crates, normal)
+	if previous, exists := search.seen[key]; exists {
+		if search.nodes[previous].closed || search.nodes[previous].pushes <= pushes {
+			return -1
+		}
+	}
+	if parent >= 0 && search.board.frozenDeadlock(crates, pushed) != nil {
+		return -1
+	}
+
+	lowerBound := 0
+	for cell, present := range crates {
+		if present {
+			if search.board.isDead(cell) {
+				return -1
+			}
+			lowerBound += search.board.distance[cell]
+		}
+	}
+
+	from := -1
+	if parent >= 0 {
+		from = worker
+	}
+	search.nodes = append(search.nodes, searchNode{
+		key, worker, parent, from, dir, pushes, lowerBound, false})
+	search.seen[key] = len(search.nodes) - 1
+	return len(search.nodes) - 1
+}
+
+// Encodes the crate positions and worker region as a compact string key.
+func (search *search) encode(crates []bool, worker int) string {
+	key := make([]byte, 0, 16)
+	key = append(key, byte(worker>>8), byte(worker))
+	for cell, present := range crates {
+		if present {
+			key = append(key, byte(cell>>8), byte(cell))
+		}
+	}
+	return string(key)
+}
+
+// Reconstructs the crate positions from a position key.
+func (search *search) decode(key string) []bool {
+	crates := make([]bool, len(search.board.cells))
+	for index := 2; index+1 < len(key); index += 2 {
+		crates[int(key[index])<<8|int(key[index+1])] = true
+	}
+	return crates
+}
+
+// Expands the pushes leading to the node into a full sequence of steps from
+// the state, routing the worker between pushes.
+func (search *search) solution(state *State, node int) Solution {
+	pushes := make([]searchNode, 0)
+	for ; search.nodes[node].parent >= 0; node = search.nodes[node].parent {
+		pushes = append(pushes, search.nodes[node])
+	}
+
+	replay := state.Clone()
+	steps := make(Solution, 0)
+	for index := len(pushes) - 1; index >= 0; index-- {
+		push := pushes[index]
+		behind := search.board.neighbors[push.crate][(push.dir+3)%6]
+		for _, step := range replay.walkTo(behind) {
+			replay.Move(step.Direction())
+			steps = append(steps, step)
+		}
+		step, _ := replay.Move(Directions[push.dir])
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// Returns the cells that the worker can reach from `from` without pushing.
+func (board *board) reachable(crates []bool, from int) []bool {
+	reach := make([]bool, len(board.cells))
+	reach[from] = true
+	queue := []int{from}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		for _, next := range board.neighbors[cell] {
+			if next >= 0 && !reach[next] && !crates[next] {
+				reach[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return reach
+}
+
+// Returns the shortest sequence of moves (without pushing) that takes the
+// worker to the target cell, or nil if it cannot be reached.
+func (state *State) walkTo(target int) Solution {
+	board := state.board
+	parent := make([]int, len(board.cells))
+	steps := make([]Step, len(board.cells))
+	for cell := range parent {
+		parent[cell] = -1
+	}
+	parent[state.ichiban] = state.ichiban
+	queue := []int{state.ichiban}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		if cell == target {
+			return tracePath(parent, steps, state.ichiban, target)
+		}
+		for d, next := range board.neighbors[cell] {
+			if next >= 0 && parent[next] < 0 && !state.crates[next] {
+				parent[next] = cell
+				steps[next] = NewStep(Directions[d], false)
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// The open list is a priority queue (min-heap) of node indices.
+type openEntry struct {
+	node     int
+	priority int
+}
+
+type openList []openEntry
+
+func (list openList) Len() int { return len(list) }
+func (list openList) Less(a, b int) bool {
+	if list[a].priority == list[b].priority {
+		// Prefer the more recently generated node, favoring depth on ties.
+		return list[a].node > list[b].node
+	}
+	return list[a].priority < list[b].priority
+}
+func (list openList) Swap(a, b int) { list[a], list[b] = list[b], list[a] }
+func (list *openList) Push(x any)   { *list = append(*list, x.(openEntry)) }
+func (list *openList) Pop() any {
+	old := *list
+	entry := old[len(old)-1]
+	*list = old[:len(old)-1]
+	return entry
+}
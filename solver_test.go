@@ -0,0 +1,205 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/solver_test.go
+
+package hexoban
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+// Reads a puzzle from the levels directory, failing the test if it can't.
+func loadLevel(t *testing.T, path string) Puzzle {
+	t.Helper()
+	filedata, err := os.ReadFile("levels/" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var puzzle Puzzle
+	if err := json.Unmarshal(filedata, &puzzle); err != nil {
+		t.Fatal(err)
+	}
+	return puzzle
+}
+
+func TestSolver_Solve(t *testing.T) {
+	tests := []struct {
+		name    string
+		puzzle  Puzzle
+		advisor Advisor
+		pushes  int // expected pushes when optimal, or zero to skip checking.
+	}{
+		{"treasure room", treasureRoom(), nil, 1},
+		{"dws001 optimal", dws001(), AStarAdvisor, 0},
+		{"dws001 baseline", dws001(), BaselineAdvisor, 0},
+		{"LukaszM 05", loadLevel(t, "LukaszM/05.json"), AStarAdvisor, 4},
+		{"Heroban 1", loadLevel(t, "Heroban/Heroban 1.json"), nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := NewState(tt.puzzle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			solution, stats, err := Solver{Advisor: tt.advisor}.Solve(context.Background(), state)
+			if err != nil {
+				t.Fatalf("Solve() error = %v after %d nodes", err, stats.Nodes)
+			}
+			if tt.pushes > 0 && solution.Pushes() != tt.pushes {
+				t.Errorf("Solve() = %s, %d pushes, expected %d", solution, solution.Pushes(), tt.pushes)
+			}
+			if err := state.Apply(solution); err != nil || !state.IsSolved() {
+				t.Errorf("Solve() = %s does not solve the puzzle: %v", solution, err)
+			}
+		})
+	}
+}
+
+func TestSolver_Budget(t *testing.T) {
+	state, _ := NewState(loadLevel(t, "Heroban/Heroban 1.json"))
+	_, stats, err := Solver{MaxNodes: 10}.Solve(context.Background(), state)
+	if !errors.Is(err, ErrBudgetExhausted) || stats.Nodes != 10 {
+		t.Errorf("Solve() error = %v after %d nodes, expected budget exhausted", err, stats.Nodes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = Solver{Advisor: BaselineAdvisor}.Solve(ctx, state)
+	if !errors.Is(err, ErrBudgetExhausted) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Solve() error = %v, expected cancellation", err)
+	}
+}
+
+// Two crates side by side against a wall, neither can be pushed along the wall
+// because the other is in the way.
+//
+// .      # # # # #
+// .     # . $ $ . #
+// .    #     @     #
+// .     # # # # # #
+func frozenPair() Puzzle {
+	at := NewHexCoord
+	return Puzzle{
+		Terrain: []HexCoord{
+			at(2, 1), at(2, 2), at(2, 3), at(2, 4),
+			at(3, 1), at(3, 2), at(3, 3), at(3, 4), at(3, 5),
+		},
+		Init: Init{
+			Goals:   []HexCoord{at(2, 1), at(2, 4)},
+			Crates:  []HexCoord{at(2, 2), at(2, 3)},
+			Ichiban: at(3, 3),
+		},
+	}
+}
+
+func TestState_Deadlocks(t *testing.T) {
+	at := NewHexCoord
+	tests := []struct {
+		name   string
+		puzzle Puzzle
+		crates []HexCoord
+		expect []HexCoord
+	}{
+		{"initial", dws001(), nil, []HexCoord{}},
+		{"crate on goal against the wall", dws001(),
+			[]HexCoord{at(6, 5), at(6, 6), at(4, 5)}, []HexCoord{}},
+		{"dead corner", dws001(),
+			[]HexCoord{at(2, 3), at(6, 6), at(7, 7)}, []HexCoord{at(2, 3)}},
+		{"frozen pair", frozenPair(), nil, []HexCoord{at(2, 2), at(2, 3)}},
+		{"movable pair", frozenPair(),
+			[]HexCoord{at(2, 1), at(2, 3)}, []HexCoord{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.crates != nil {
+				tt.puzzle.Init.Crates = tt.crates
+			}
+			state, err := NewState(tt.puzzle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := state.Deadlocks()
+			if len(got) != len(tt.expect) {
+				t.Fatalf("Deadlocks() = %v, expected %v", got, tt.expect)
+			}
+			for index := range got {
+				if got[index] != tt.expect[index] {
+					t.Errorf("Deadlocks() = %v, expected %v", got, tt.expect)
+				}
+			}
+		})
+	}
+}
+
+func TestState_IsDeadCell(t *testing.T) {
+	at := NewHexCoord
+	state, err := NewState(dws001())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		coord HexCoord
+		dead  bool
+	}{
+		{at(2, 3), true},   // a corner
+		{at(4, 5), false},  // a goal
+		{at(6, 6), false},  // a crate's initial position
+		{at(0, 0), false},  // outside of the terrain
+		{at(-9, 9), false}, // also outside
+	}
+	for _, tt := range tests {
+		if got := state.IsDeadCell(tt.coord); got != tt.dead {
+			t.Errorf("IsDeadCell(%v) = %v, expected %v", tt.coord, got, tt.dead)
+		}
+	}
+}
+
+func TestHint(t *testing.T) {
+	ctx := context.Background()
+	state, _ := NewState(treasureRoom())
+	suggestion, err := Hint(ctx, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suggestion.Steps.String() != "R" || suggestion.Crate != NewHexCoord(2, 3) ||
+		suggestion.Direction != DIR_RIGHT || suggestion.Remaining != 1 {
+		t.Errorf("Hint() = %+v", suggestion)
+	}
+
+	state.Apply(suggestion.Steps)
+	if suggestion, _ = Hint(ctx, state); !suggestion.Solved {
+		t.Errorf("Hint() = %+v, expected solved", suggestion)
+	}
+
+	state, _ = NewState(loadLevel(t, "Heroban/Heroban 2.json"))
+	suggestion, err = Hint(ctx, state)
+	if err != nil || suggestion.Deadlocked || !suggestion.Steps[len(suggestion.Steps)-1].IsPush() {
+		t.Errorf("Hint() = %+v, %v", suggestion, err)
+	}
+	if err := state.Apply(suggestion.Steps); err != nil || state.IsCrate(suggestion.Crate) {
+		t.Errorf("Hint() steps %s did not push the crate at %v: %v",
+			suggestion.Steps, suggestion.Crate, err)
+	}
+
+	state, _ = NewState(frozenPair())
+	suggestion, err = Hint(ctx, state)
+	if err != nil || !suggestion.Deadlocked || len(suggestion.DeadCrates) != 2 {
+		t.Errorf("Hint() = %+v, %v, expected deadlocked", suggestion, err)
+	}
+}
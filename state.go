@@ -0,0 +1,285 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/state.go
+
+package hexoban
+
+import "fmt"
+
+// The rules engine's representation of a puzzle in progress.  The terrain and
+// goals are shared (and immutable) between a State and any of its clones, only
+// the crates, worker position and history of steps are unique to each State.
+type State struct {
+	board   *board
+	crates  []bool // indexed by cell, true where a crate is present.
+	ichiban int    // the cell index of the worker.
+	history Solution
+}
+
+// The fixed layout of a puzzle with terrain coordinates indexed densely, so
+// that the state of crates can be a simple slice and neighbors can be found
+// without hashing.
+type board struct {
+	cells     []HexCoord
+	index     map[HexCoord]int
+	neighbors [][6]int // by position in Directions, -1 where there is a wall.
+	goals     []bool
+	distance  []int // pushes to the nearest goal, see board.analyze().
+}
+
+// Constructs the initial state for the puzzle.  Returns an error if the puzzle
+// is not playable (crates, goals or the worker outside the terrain, crates
+// stacked on each other or an unequal number of crates and goals).
+func NewState(puzzle Puzzle) (*State, error) {
+	if len(puzzle.Terrain) == 0 {
+		return nil, fmt.Errorf("no terrain coordinates are defined")
+	}
+
+	board := &board{index: make(map[HexCoord]int, len(puzzle.Terrain))}
+	for _, coord := range puzzle.Terrain {
+		if _, exists := board.index[coord]; !exists {
+			board.index[coord] = len(board.cells)
+			board.cells = append(board.cells, coord)
+		}
+	}
+	board.neighbors = make([][6]int, len(board.cells))
+	for cell, coord := range board.cells {
+		for d, dir := range Directions {
+			board.neighbors[cell][d] = board.lookup(coord.Neighbor(dir))
+		}
+	}
+
+	board.goals = make([]bool, len(board.cells))
+	for _, goal := range puzzle.Init.Goals {
+		cell := board.lookup(goal)
+		if cell < 0 {
+			return nil, fmt.Errorf("goal at %v is not on the terrain", goal)
+		}
+		if board.goals[cell] {
+			return nil, fmt.Errorf("goal at %v is defined more than once", goal)
+		}
+		board.goals[cell] = true
+	}
+	board.analyze()
+
+	state := &State{board: board, crates: make([]bool, len(board.cells))}
+	for _, crate := range puzzle.Init.Crates {
+		cell := board.lookup(crate)
+		if cell < 0 {
+			return nil, fmt.Errorf("crate at %v is not on the terrain", crate)
+		}
+		if state.crates[cell] {
+			return nil, fmt.Errorf("crate at %v is defined more than once", crate)
+		}
+		state.crates[cell] = true
+	}
+	if len(puzzle.Init.Crates) != len(puzzle.Init.Goals) {
+		return nil, fmt.Errorf("# goals (%d) different from # crates (%d)",
+			len(puzzle.Init.Goals), len(puzzle.Init.Crates))
+	}
+
+	state.ichiban = board.lookup(puzzle.Init.Ichiban)
+	if state.ichiban < 0 {
+		return nil, fmt.Errorf("ichiban at %v is not on the terrain", puzzle.Init.Ichiban)
+	}
+	if state.crates[state.ichiban] {
+		return nil, fmt.Errorf("ichiban at %v is on top of a crate", puzzle.Init.Ichiban)
+	}
+	return state, nil
+}
+
+// Returns the cell index of the coordinate, or -1 if it is not in the terrain.
+func (board *board) lookup(coord HexCoord) int {
+	if cell, exists := board.index[coord]; exists {
+		return cell
+	}
+	return -1
+}
+
+// Returns an independent copy of this state, sharing only the immutable board.
+func (state *State) Clone() *State {
+	return &State{
+		board:   state.board,
+		crates:  append([]bool(nil), state.crates...),
+		ichiban: state.ichiban,
+		history: append(Solution(nil), state.history...),
+	}
+}
+
+// The worker's current position.
+func (state *State) Ichiban() HexCoord {
+	return state.board.cells[state.ichiban]
+}
+
+// The current crate positions, in the order of the puzzle's terrain.
+func (state *State) Crates() []HexCoord {
+	crates := make([]HexCoord, 0, len(state.board.cells))
+	for cell, present := range state.crates {
+		if present {
+			crates = append(crates, state.board.cells[cell])
+		}
+	}
+	return crates
+}
+
+// Returns a puzzle which begins at this state's position, with the terrain and
+// goals of the state's puzzle (in the same order) but without its metadata.
+func (state *State) Puzzle() Puzzle {
+	puzzle := Puzzle{Terrain: append([]HexCoord(nil), state.board.cells...)}
+	for cell, coord := range state.board.cells {
+		if state.board.goals[cell] {
+			puzzle.Init.Goals = append(puzzle.Init.Goals, coord)
+		}
+	}
+	puzzle.Init.Crates = state.Crates()
+	puzzle.Init.Ichiban = state.Ichiban()
+	return puzzle
+}
+
+// The steps that have been taken from the initial state to reach this one.
+func (state *State) History() Solution {
+	return append(Solution(nil), state.history...)
+}
+
+// Returns true if the coordinate is traversable terrain.
+func (state *State) IsFloor(coord HexCoord) bool {
+	return state.board.lookup(coord) >= 0
+}
+
+// Returns true if the coordinate is one of the puzzle's goals.
+func (state *State) IsGoal(coord HexCoord) bool {
+	cell := state.board.lookup(coord)
+	return cell >= 0 && state.board.goals[cell]
+}
+
+// Returns true if there is currently a crate at the coordinate.
+func (state *State) IsCrate(coord HexCoord) bool {
+	cell := state.board.lookup(coord)
+	return cell >= 0 && state.crates[cell]
+}
+
+// Returns true when every crate is resting on a goal.
+func (state *State) IsSolved() bool {
+	for cell, present := range state.crates {
+		if present && !state.board.goals[cell] {
+			return false
+		}
+	}
+	return true
+}
+
+// Moves the worker one step in the indicated direction, pushing the crate in
+// front of it if there is one.  Returns the step taken, or an error describing
+// why the worker could not move (the state is unchanged in that case).
+func (state *State) Move(dir Direction) (Step, error) {
+	d := dir.Index()
+	if d < 0 {
+		return 0, fmt.Errorf("unknown direction '%c'", byte(dir))
+	}
+	next := state.board.neighbors[state.ichiban][d]
+	if next < 0 {
+		return 0, fmt.Errorf("cannot move %s from %v, a wall is in the way",
+			dir, state.Ichiban())
+	}
+	if !state.crates[next] {
+		state.ichiban = next
+		state.history = append(state.history, NewStep(dir, false))
+		return NewStep(dir, false), nil
+	}
+
+	beyond := state.board.neighbors[next][d]
+	if beyond < 0 || state.crates[beyond] {
+		return 0, fmt.Errorf("cannot push the crate at %v %s, it is blocked",
+			state.board.cells[next], dir)
+	}
+	state.crates[next], state.crates[beyond] = false, true
+	state.ichiban = next
+	state.history = append(state.history, NewStep(dir, true))
+	return NewStep(dir, true), nil
+}
+
+// Reverts the most recent step, returning it and true, or returning false if
+// there is no history to undo.
+func (state *State) Undo() (Step, bool) {
+	if len(state.history) == 0 {
+		return 0, false
+	}
+	step := state.history[len(state.history)-1]
+	state.history = state.history[:len(state.history)-1]
+
+	d := step.Direction().Index()
+	back := (d + 3) % 6
+	if step.IsPush() {
+		crate := state.board.neighbors[state.ichiban][d]
+		state.crates[crate], state.crates[state.ichiban] = false, true
+	}
+	state.ichiban = state.board.neighbors[state.ichiban][back]
+	return step, true
+}
+
+// A step of a sequence that could not be taken, and why.
+type StepError struct {
+	Index int // the position of the step in its sequence, counting from 1.
+	Step  Step
+	Err   error
+}
+
+func (err *StepError) Error() string {
+	return fmt.Sprintf("step %d (%c): %s", err.Index, byte(err.Step), err.Err)
+}
+
+func (err *StepError) Unwrap() error {
+	return err.Err
+}
+
+// Applies all steps of the solution, verifying that each is legal and that its
+// push or move casing agrees with the rules.  If any step fails, the state is
+// left unchanged and the error is a *StepError for the failing step.
+func (state *State) Apply(steps Solution) error {
+	next := state.Clone()
+	for index, step := range steps {
+		taken, err := next.Move(step.Direction())
+		if err == nil && taken != step {
+			if step.IsPush() {
+				err = fmt.Errorf("there is no crate to push")
+			} else {
+				err = fmt.Errorf("the step pushes a crate")
+			}
+		}
+		if err != nil {
+			return &StepError{Index: index + 1, Step: step, Err: err}
+		}
+	}
+	*state = *next
+	return nil
+}
+
+// Moves in the direction of each step in turn, ignoring its casing: whether a
+// step pushes is decided by the rules.  Returns the steps taken, cased as the
+// rules decided.  If any step is illegal, the state is left unchanged and the
+// error is a *StepError for the failing step.
+func (state *State) Walk(directions Solution) (Solution, error) {
+	next := state.Clone()
+	taken := make(Solution, 0, len(directions))
+	for index, step := range directions {
+		step, err := next.Move(step.Direction())
+		if err != nil {
+			return nil, &StepError{Index: index + 1, Step: directions[index], Err: err}
+		}
+		taken = append(taken, step)
+	}
+	*state = *next
+	return taken, nil
+}
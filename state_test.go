@@ -0,0 +1,247 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/state_test.go
+
+package hexoban
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// A small room with one crate, one goal and a solution of "R".
+//
+// .      # # #
+// .     #     #
+// .    # @ $ . #
+// .     # # # #
+func treasureRoom() Puzzle {
+	at := NewHexCoord
+	return Puzzle{
+		Identity: "testdata/treasure",
+		Title:    "Treasure Room",
+		Terrain:  []HexCoord{at(1, 2), at(1, 3), at(2, 2), at(2, 3), at(2, 4)},
+		Init: Init{
+			Goals:   []HexCoord{at(2, 4)},
+			Crates:  []HexCoord{at(2, 3)},
+			Ichiban: at(2, 2),
+		},
+	}
+}
+
+// The first of David W. Skinner's hexoban levels.
+//
+// .       # # #
+// .      #     #
+// .       #     #
+// .      #   . #
+// .     #   .   #
+// .    #   $ $   #
+// .     # # # *   #
+// .        # @   #
+// .         # # #
+func dws001() Puzzle {
+	at := NewHexCoord
+	return Puzzle{
+		Identity: "testdata/dws001",
+		Title:    "001",
+		Author:   "David W. Skinner",
+		Terrain: []HexCoord{
+			at(2, 3), at(2, 4), at(3, 4), at(3, 5), at(4, 4), at(4, 5),
+			at(5, 4), at(5, 5), at(5, 6), at(6, 4), at(6, 5), at(6, 6), at(6, 7),
+			at(7, 7), at(7, 8), at(8, 7), at(8, 8),
+		},
+		Init: Init{
+			Goals:   []HexCoord{at(4, 5), at(5, 5), at(7, 7)},
+			Crates:  []HexCoord{at(6, 5), at(6, 6), at(7, 7)},
+			Ichiban: at(8, 7),
+		},
+	}
+}
+
+func TestNewState(t *testing.T) {
+	at := NewHexCoord
+	tests := []struct {
+		name    string
+		modify  func(*Puzzle)
+		wantErr bool
+	}{
+		{"valid", func(p *Puzzle) {}, false},
+		{"no terrain", func(p *Puzzle) { p.Terrain = nil }, true},
+		{"crate off terrain", func(p *Puzzle) { p.Init.Crates = []HexCoord{at(0, 0)} }, true},
+		{"goal off terrain", func(p *Puzzle) { p.Init.Goals = []HexCoord{at(3, 3)} }, true},
+		{"ichiban off terrain", func(p *Puzzle) { p.Init.Ichiban = at(0, 0) }, true},
+		{"ichiban on crate", func(p *Puzzle) { p.Init.Ichiban = at(2, 3) }, true},
+		{"more crates than goals", func(p *Puzzle) {
+			p.Init.Crates = append(p.Init.Crates, at(1, 3))
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzle := treasureRoom()
+			tt.modify(&puzzle)
+			if _, err := NewState(puzzle); (err != nil) != tt.wantErr {
+				t.Errorf("NewState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestState_MoveAndUndo(t *testing.T) {
+	state, err := NewState(treasureRoom())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := state.Move(DIR_LEFT); err == nil {
+		t.Error("expected moving into a wall to fail")
+	}
+	if step, err := state.Move(DIR_UP); err != nil || step != 'u' {
+		t.Errorf("Move(up) = %c, %v", step, err)
+	}
+	if step, err := state.Move(DIR_DOWN); err != nil || step != 'd' {
+		t.Errorf("Move(down) = %c, %v", step, err)
+	}
+	if state.IsSolved() {
+		t.Error("state reported solved before pushing the crate")
+	}
+	if step, err := state.Move(DIR_RIGHT); err != nil || step != 'R' {
+		t.Errorf("Move(right) = %c, %v", step, err)
+	}
+	if !state.IsSolved() {
+		t.Error("state not solved after pushing the crate onto the goal")
+	}
+	if _, err := state.Move(DIR_RIGHT); err == nil {
+		t.Error("expected pushing a crate into a wall to fail")
+	}
+	if got := state.History().String(); got != "udR" {
+		t.Errorf("History() = %s, expected udR", got)
+	}
+
+	if step, ok := state.Undo(); !ok || step != 'R' {
+		t.Errorf("Undo() = %c, %v", step, ok)
+	}
+	if !state.IsCrate(NewHexCoord(2, 3)) || state.Ichiban() != NewHexCoord(2, 2) {
+		t.Errorf("Undo() did not restore crate and worker, crates %v ichiban %v",
+			state.Crates(), state.Ichiban())
+	}
+}
+
+func TestState_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		lurd    string
+		wantErr bool
+		solved  bool
+		step    int // the index of the failing step.
+	}{
+		{"solution", "R", false, true, 0},
+		{"wander then solve", "udR", false, true, 0},
+		{"push without casing", "r", true, false, 1},
+		{"move cased as push", "U", true, false, 1},
+		{"into the wall", "udl", true, false, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, _ := NewState(treasureRoom())
+			steps, err := ParseSolution(tt.lurd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = state.Apply(steps)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply(%s) error = %v, wantErr %v", tt.lurd, err, tt.wantErr)
+			}
+			if state.IsSolved() != tt.solved {
+				t.Errorf("Apply(%s) solved = %v, expected %v", tt.lurd, state.IsSolved(), tt.solved)
+			}
+			var stepErr *StepError
+			if err != nil && (!errors.As(err, &stepErr) || stepErr.Index != tt.step) {
+				t.Errorf("Apply(%s) error = %#v, expected a StepError at step %d", tt.lurd, err, tt.step)
+			}
+			if err != nil && len(state.History()) != 0 {
+				t.Errorf("failed Apply(%s) modified the state", tt.lurd)
+			}
+		})
+	}
+}
+
+func TestState_Walk(t *testing.T) {
+	tests := []struct {
+		name  string
+		lurd  string
+		taken string
+		step  int // the index of the failing step, 0 if none fail.
+	}{
+		{"casing from the rules", "udr", "udR", 0},
+		{"move cased as push", "UDR", "udR", 0},
+		{"into the wall", "udl", "", 3},
+		{"nothing", "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, _ := NewState(treasureRoom())
+			steps, err := ParseSolution(tt.lurd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			taken, err := state.Walk(steps)
+			var stepErr *StepError
+			if tt.step != 0 {
+				if !errors.As(err, &stepErr) || stepErr.Index != tt.step {
+					t.Errorf("Walk(%s) error = %#v, expected a StepError at step %d", tt.lurd, err, tt.step)
+				}
+				if len(state.History()) != 0 {
+					t.Errorf("failed Walk(%s) modified the state", tt.lurd)
+				}
+				return
+			}
+			if err != nil || taken.String() != tt.taken || state.History().String() != tt.taken {
+				t.Errorf("Walk(%s) = %s, %v (history %s), expected %s",
+					tt.lurd, taken, err, state.History(), tt.taken)
+			}
+		})
+	}
+}
+
+func TestParseSolution(t *testing.T) {
+	steps, err := ParseSolution("bRR\nduFl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps.String() != "bRRduFl" || steps.Moves() != 7 || steps.Pushes() != 3 {
+		t.Errorf("ParseSolution() = %s (%d moves, %d pushes)", steps, steps.Moves(), steps.Pushes())
+	}
+	if _, err := ParseSolution("bRx"); err == nil {
+		t.Error("expected an error for an unknown direction")
+	}
+}
+
+func TestState_Puzzle(t *testing.T) {
+	state, _ := NewState(dws001())
+	if _, err := state.Move(DIR_RIGHT); err != nil {
+		t.Fatal(err)
+	}
+	puzzle := state.Puzzle()
+	original := dws001()
+	if !reflect.DeepEqual(puzzle.Terrain, original.Terrain) ||
+		!reflect.DeepEqual(puzzle.Init.Goals, original.Init.Goals) ||
+		!reflect.DeepEqual(puzzle.Init.Crates, original.Init.Crates) {
+		t.Errorf("Puzzle() = %v, expected the same map as %v", puzzle, original)
+	}
+	if puzzle.Init.Ichiban != NewHexCoord(8, 8) || puzzle.Identity != "" {
+		t.Errorf("Puzzle() = %+v", puzzle)
+	}
+}
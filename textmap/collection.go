@@ -0,0 +1,310 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/textmap/collection.go
+
+package textmap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// Collections hold several puzzles in one text file, in the format used by
+// Sokoban tools for classic levels.  The file begins with headers and comments
+// describing the whole collection, then each map is followed by the headers
+// and comments for its puzzle:
+//
+// .  Title: Example Collection
+// .  Author: Anonymous
+// .  Any line which is not a header or a map is a comment.
+// .
+// .     # # #
+// .    #     #
+// .   # @ $ . #
+// .    # # # #
+// .  Title: Treasure Room
+// .  Difficulty: 1
+// .
+// .    # # # ...
+//
+// Headers are a case-insensitive name followed by a colon and the value, see
+// COLLECTION_HEADERS and LEVEL_HEADERS.  A comment may be written after the
+// Comment: header, a multi-line comment is ended with Comment-End: and lines
+// beginning with ';' are also comments (the ';' is removed).  Blank lines are
+// only needed to separate a level's comments from the next map's first line,
+// they are not retained.
+
+// Header names recognized at the beginning of a collection.
+var COLLECTION_HEADERS = []string{"Title", "Author", "Source"}
+
+// Header names recognized after each map in the collection.
+var LEVEL_HEADERS = []string{"Title", "ID", "Author", "Source", "Difficulty"}
+
+// A set of puzzles along with its own descriptive properties.
+type Collection struct {
+	Title    string
+	Author   string
+	Source   string
+	Comments []string
+	Levels   []Level
+}
+
+// A puzzle within a collection and the comments that accompany it.
+type Level struct {
+	hexoban.Puzzle
+	Comments []string
+}
+
+// Reads all the levels of a collection and its headers.  Returns an error if
+// the collection has no maps, a map cannot be parsed or a header's value is
+// not valid.  Errors indicate the line number of the problem.
+func DecodeCollection(reader io.Reader) (Collection, error) {
+	collection := Collection{}
+	scanner := bufio.NewScanner(reader)
+	lineno := 0
+	var mapLines []string
+	mapStart := 0
+	inComment := false
+
+	// The headers and comments are collected into the last level, or into the
+	// collection itself if there is no level yet.
+	addHeader := func(name, value string) error {
+		if len(collection.Levels) == 0 {
+			switch name {
+			case "title":
+				collection.Title = value
+			case "author":
+				collection.Author = value
+			case "source":
+				collection.Source = value
+			}
+			return nil
+		}
+		puzzle := &collection.Levels[len(collection.Levels)-1].Puzzle
+		if err := setHeader(puzzle, name, value); err != nil {
+			return fmt.Errorf("line %d: %w", lineno, err)
+		}
+		return nil
+	}
+	addComment := func(comment string) {
+		if len(collection.Levels) == 0 {
+			collection.Comments = append(collection.Comments, comment)
+		} else {
+			level := &collection.Levels[len(collection.Levels)-1]
+			level.Comments = append(level.Comments, comment)
+		}
+	}
+	endMap := func() error {
+		if len(mapLines) == 0 {
+			return nil
+		}
+		puzzle, err := Decode(strings.NewReader(strings.Join(mapLines, "\n")))
+		if err != nil {
+			return fmt.Errorf("map at line %d: %w", mapStart, err)
+		}
+		collection.Levels = append(collection.Levels, Level{Puzzle: puzzle})
+		mapLines = nil
+		return nil
+	}
+
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if inComment {
+			if name, _, ok := parseHeader(line); ok && name == "comment-end" {
+				inComment = false
+			} else {
+				addComment(line)
+			}
+			continue
+		}
+		if isMapLine(line) {
+			if len(mapLines) == 0 {
+				mapStart = lineno
+			}
+			mapLines = append(mapLines, line)
+			continue
+		}
+		if err := endMap(); err != nil {
+			return collection, err
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, ";") {
+			addComment(strings.TrimSpace(trimmed[1:]))
+			continue
+		}
+		name, value, ok := parseHeader(trimmed)
+		switch {
+		case ok && name == "comment" && value == "":
+			inComment = true
+		case ok && name == "comment":
+			addComment(value)
+		case ok && isHeader(name, len(collection.Levels) == 0):
+			if err := addHeader(name, value); err != nil {
+				return collection, err
+			}
+		default:
+			addComment(trimmed)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return collection, err
+	}
+	if err := endMap(); err != nil {
+		return collection, err
+	}
+	if len(collection.Levels) == 0 {
+		return collection, fmt.Errorf("no maps found in the collection")
+	}
+	return collection, nil
+}
+
+// Reads the level headers from a file which has no map, e.g. the metadata
+// kept alongside a single level's map.  Lines which are not headers (or are
+// headers not in LEVEL_HEADERS) are ignored.  The puzzle returned has only the
+// properties from the headers found.
+func DecodeHeaders(reader io.Reader) (hexoban.Puzzle, error) {
+	puzzle := hexoban.Puzzle{}
+	scanner := bufio.NewScanner(reader)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		name, value, ok := parseHeader(strings.TrimSpace(scanner.Text()))
+		if !ok || !isHeader(name, false) {
+			continue
+		}
+		if err := setHeader(&puzzle, name, value); err != nil {
+			return puzzle, fmt.Errorf("line %d: %w", lineno, err)
+		}
+	}
+	return puzzle, scanner.Err()
+}
+
+// Writes the collection's headers and comments followed by each of its levels.
+// Headers with empty values are omitted, as are level authors and sources that
+// are the same as the collection's.
+func EncodeCollection(writer io.Writer, collection Collection) error {
+	output := bufio.NewWriter(writer)
+	writeHeader := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(output, "%s: %s\n", name, value)
+		}
+	}
+	writeComments := func(comments []string) {
+		for _, comment := range comments {
+			if _, _, ok := parseHeader(comment); ok || isMapLine(comment) ||
+				strings.HasPrefix(strings.TrimSpace(comment), ";") {
+				comment = "Comment: " + comment
+			}
+			fmt.Fprintln(output, comment)
+		}
+	}
+
+	writeHeader("Title", collection.Title)
+	writeHeader("Author", collection.Author)
+	writeHeader("Source", collection.Source)
+	writeComments(collection.Comments)
+
+	for _, level := range collection.Levels {
+		text, err := MapString(level.Puzzle)
+		if err != nil {
+			return fmt.Errorf("level %q: %w", level.Title, err)
+		}
+		fmt.Fprintf(output, "\n%s\n", text)
+		writeHeader("Title", level.Title)
+		writeHeader("ID", level.Identity)
+		if level.Author != collection.Author {
+			writeHeader("Author", level.Author)
+		}
+		if level.Source != collection.Source {
+			writeHeader("Source", level.Source)
+		}
+		if level.Difficulty > 0 {
+			writeHeader("Difficulty", strconv.Itoa(level.Difficulty))
+		}
+		writeComments(level.Comments)
+	}
+	return output.Flush()
+}
+
+// Assigns the value of a level header (by its lowercase name) to the puzzle.
+func setHeader(puzzle *hexoban.Puzzle, name, value string) error {
+	switch name {
+	case "title":
+		puzzle.Title = value
+	case "id":
+		puzzle.Identity = value
+	case "author":
+		puzzle.Author = value
+	case "source":
+		puzzle.Source = value
+	case "difficulty":
+		difficulty, err := strconv.Atoi(value)
+		if err != nil || difficulty < 0 {
+			return fmt.Errorf("invalid difficulty %q", value)
+		}
+		puzzle.Difficulty = difficulty
+	}
+	return nil
+}
+
+// Returns the lowercase header name and its value if the line is a header.
+// Any name is accepted here, isHeader() determines which are recognized.
+func parseHeader(line string) (string, string, bool) {
+	name, value, found := strings.Cut(line, ":")
+	if !found || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", false
+	}
+	return strings.ToLower(name), strings.TrimSpace(value), true
+}
+
+// Returns true if the (lowercase) name is one of the collection's headers, or
+// one of the level headers if not at the collection level.
+func isHeader(name string, collectionLevel bool) bool {
+	headers := LEVEL_HEADERS
+	if collectionLevel {
+		headers = COLLECTION_HEADERS
+	}
+	for _, header := range headers {
+		if strings.ToLower(header) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the line is part of a map, its first glyph being a wall and
+// every other character one of the tile glyphs.
+func isMapLine(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(trimmed, string(TOKEN_WALL)) {
+		return false
+	}
+	for index := 0; index < len(trimmed); index++ {
+		if !TokenType(trimmed[index]).IsTile() && trimmed[index] != '\t' {
+			return false
+		}
+	}
+	return true
+}
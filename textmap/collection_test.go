@@ -0,0 +1,185 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/textmap/collection_test.go
+
+package textmap
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const exampleCollection = `Title: Example Collection
+Author: Anonymous
+A collection for testing.
+Comment: Solutions: not included
+
+  # # #
+ #     #
+# @ $ . #
+ # # # #
+Title: Treasure Room
+ID: example/1
+Difficulty: 1
+; the first one is easy
+
+   # # #
+  #     #
+ # + $   #
+  # # # #
+title: Goal Start
+Author: Someone Else
+Comment:
+Starting on the goal.
+Title: not a header here
+Comment-End:
+`
+
+func TestDecodeCollection(t *testing.T) {
+	collection, err := DecodeCollection(strings.NewReader(exampleCollection))
+	if err != nil {
+		t.Fatalf("DecodeCollection() error = %v", err)
+	}
+	if collection.Title != "Example Collection" || collection.Author != "Anonymous" {
+		t.Errorf("collection headers = %q by %q", collection.Title, collection.Author)
+	}
+	expectComments := []string{"A collection for testing.", "Solutions: not included"}
+	if !reflect.DeepEqual(collection.Comments, expectComments) {
+		t.Errorf("collection comments = %q, expected %q", collection.Comments, expectComments)
+	}
+	if len(collection.Levels) != 2 {
+		t.Fatalf("decoded %d levels, expected 2", len(collection.Levels))
+	}
+
+	first, second := collection.Levels[0], collection.Levels[1]
+	if first.Title != "Treasure Room" || first.Identity != "example/1" ||
+		first.Difficulty != 1 || first.Author != "" {
+		t.Errorf("first level headers = %+v", first.Puzzle)
+	}
+	if !reflect.DeepEqual(first.Comments, []string{"the first one is easy"}) {
+		t.Errorf("first level comments = %q", first.Comments)
+	}
+	if len(first.Terrain) != 5 || len(first.Init.Crates) != 1 {
+		t.Errorf("first level map = %v", first.Puzzle)
+	}
+
+	if second.Title != "Goal Start" || second.Author != "Someone Else" {
+		t.Errorf("second level headers = %+v", second.Puzzle)
+	}
+	expectComments = []string{"Starting on the goal.", "Title: not a header here"}
+	if !reflect.DeepEqual(second.Comments, expectComments) {
+		t.Errorf("second level comments = %q, expected %q", second.Comments, expectComments)
+	}
+	if second.Init.Ichiban != second.Init.Goals[0] {
+		t.Errorf("second level should start with ichiban on the goal")
+	}
+}
+
+func TestDecodeCollection_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"no maps", "Title: Empty\nJust comments.\n"},
+		{"bad difficulty", "  # # #\n #     #\n# @ $ . #\n # # # #\nDifficulty: hard\n"},
+		{"bad map", "Title: Bad\n\n  # # #\n #     #\n# @ $ . #\n  # # #\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCollection(strings.NewReader(tt.text)); err == nil {
+				t.Errorf("DecodeCollection() expected an error")
+			}
+		})
+	}
+}
+
+func TestDecodeHeaders(t *testing.T) {
+	text := "Title: Treasure Room\nid: example/1\nNotes: ignored\nA comment.\n  Difficulty: 2\n"
+	puzzle, err := DecodeHeaders(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("DecodeHeaders() error = %v", err)
+	}
+	if puzzle.Title != "Treasure Room" || puzzle.Identity != "example/1" ||
+		puzzle.Difficulty != 2 || puzzle.Author != "" {
+		t.Errorf("DecodeHeaders() = %+v", puzzle)
+	}
+
+	_, err = DecodeHeaders(strings.NewReader("Title: Bad\nDifficulty: -1\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("DecodeHeaders() error = %v, want one for line 2", err)
+	}
+}
+
+func TestEncodeCollection(t *testing.T) {
+	paths, err := filepath.Glob("../levels/DWS/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no levels found: %v", err)
+	}
+	collection := Collection{
+		Title:    "DWS",
+		Author:   "David W. Skinner",
+		Comments: []string{"Title: (not a header)", "# not a map"},
+	}
+	for _, path := range paths {
+		filedata, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		level := Level{}
+		if err := json.Unmarshal(filedata, &level.Puzzle); err != nil {
+			t.Fatal(err)
+		}
+		level.Comments = []string{"from " + path}
+		collection.Levels = append(collection.Levels, level)
+	}
+
+	var text strings.Builder
+	if err := EncodeCollection(&text, collection); err != nil {
+		t.Fatalf("EncodeCollection() error = %v", err)
+	}
+	if strings.Count(text.String(), "Author: ") != 1 {
+		t.Errorf("the levels' author should only be written for the collection")
+	}
+
+	decoded, err := DecodeCollection(strings.NewReader(text.String()))
+	if err != nil {
+		t.Fatalf("DecodeCollection() error = %v\n%s", err, text.String())
+	}
+	if decoded.Title != collection.Title || decoded.Author != collection.Author ||
+		!reflect.DeepEqual(decoded.Comments, collection.Comments) {
+		t.Errorf("decoded collection = %q by %q %q", decoded.Title, decoded.Author, decoded.Comments)
+	}
+	if len(decoded.Levels) != len(collection.Levels) {
+		t.Fatalf("decoded %d levels, expected %d", len(decoded.Levels), len(collection.Levels))
+	}
+	for index, level := range decoded.Levels {
+		original := collection.Levels[index]
+		if level.Title != original.Title || level.Identity != original.Identity ||
+			level.Source != original.Source || level.Difficulty != original.Difficulty ||
+			!reflect.DeepEqual(level.Comments, original.Comments) {
+			t.Errorf("level %d headers = %+v, expected %+v", index, level.Puzzle, original.Puzzle)
+		}
+		if len(level.Init.Crates) != len(original.Init.Crates) ||
+			len(level.Init.Goals) != len(original.Init.Goals) {
+			t.Errorf("level %d has %d crates and %d goals, expected %d and %d", index,
+				len(level.Init.Crates), len(level.Init.Goals),
+				len(original.Init.Crates), len(original.Init.Goals))
+		}
+	}
+}
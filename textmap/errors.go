@@ -0,0 +1,79 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/textmap/errors.go
+
+package textmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The location of a problem within the source text of a map.  Lines and
+// columns both count from 1, tabs are counted as the spaces they expand to.
+type Span struct {
+	Line  int
+	Col   int
+	Width int // the number of columns spanned, at least 1.
+}
+
+// A problem found while parsing a map, along with where it was found.
+type ParseError struct {
+	Span
+	Message string
+	Source  string // the text of the line containing the problem.
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", err.Line, err.Col, err.Message)
+}
+
+// Shows the error message followed by its source line, with carets under the
+// columns of the span.
+//
+// e.g.
+//
+// .  line 3, col 5: crate is outside of the walls
+// .     3 |  # $ @ #
+// .       |    ^
+func (err *ParseError) Pretty() string {
+	if err.Source == "" {
+		return err.Error()
+	}
+	gutter := fmt.Sprintf("%5d | ", err.Line)
+	margin := strings.Repeat(" ", len(gutter)-2) + "| "
+	return fmt.Sprintf("%s\n%s%s\n%s%s%s", err.Error(), gutter, err.Source,
+		margin, strings.Repeat(" ", err.Col-1), strings.Repeat("^", max(err.Width, 1)))
+}
+
+// All of the problems found while parsing a map, in the order they appear.
+type ErrorList []*ParseError
+
+func (list ErrorList) Error() string {
+	messages := make([]string, len(list))
+	for index, err := range list {
+		messages[index] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Shows each of the errors with its source line, see ParseError.Pretty().
+func (list ErrorList) Pretty() string {
+	messages := make([]string, len(list))
+	for index, err := range list {
+		messages[index] = err.Pretty()
+	}
+	return strings.Join(messages, "\n")
+}
@@ -12,9 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 //
-// github:SymbolNotFound/hexoban/cmd/editor/fmt.go
+// github:SymbolNotFound/hexoban/textmap/fmt.go
 
-package main
+package textmap
 
 import (
 	"errors"
@@ -45,64 +45,40 @@ func MapString(p hexoban.Puzzle) (string, error) {
 	}
 	errors := multierror{make([]string, 0)}
 
-	i, j := p.Terrain[0].I(), p.Terrain[0].J()
-	minRow, minCol := i, (j<<1)-i
-	maxRow, maxCol := i, (j<<1)-i
-	odd := minCol&1 == 0
-	// Compute the min & max (range of) coordinates.
+	// Walls are any neighbor of a floor that is not also a floor, this wraps
+	// the floors' perimeter and replaces internal voids with walls.
+	floors := make(map[hexoban.HexCoord]bool, len(p.Terrain))
 	for _, coord := range p.Terrain {
-		i, j = coord.I(), coord.J()
-		row, col := i, (j<<1)-i
-		if row < minRow {
-			minRow = row
-			odd = col&1 == 0
-		} else if row > maxRow {
-			maxRow = row
-		}
-		if col < minCol {
-			minCol = col
-		} else if col > maxCol {
-			maxCol = col
-		}
+		floors[coord] = true
 	}
-	// After finding range for floors, add padding for surrounding walls.
-	minRow -= 1
-	maxRow += 1
-	minCol -= 2
-	maxCol += 2
-	if odd { // plus extra padding for having an oddly-aligned first row
-		minRow -= 1
-		minCol -= 1
-		maxRow += 1
+	walls := make([]hexoban.HexCoord, 0)
+	for _, coord := range p.Terrain {
+		for _, neighbor := range neighbors(coord) {
+			if _, seen := floors[neighbor]; !seen {
+				floors[neighbor] = false // (so that each wall is only added once)
+				walls = append(walls, neighbor)
+			}
+		}
 	}
 
+	// Compute the min & max (range of) coordinates, including the walls.
+	minCol, minRow, maxCol, maxRow := RectBounds(walls)
+
 	// Build rectangular grid able to hold [minRow..maxRow] lines (inclusive)
 	// and [minCol..maxCol]
 	rectgrid := NewRectGrid(uint(maxRow-minRow)+1, uint(maxCol-minCol)+1)
 	for _, coord := range p.Terrain {
-		floorxy := HexToRect(coord, -minRow, -minCol)
+		floorxy := HexToRect(coord, -minCol, -minRow)
 		rectgrid.Assign(floorxy.row, floorxy.col, TOKEN_FLOOR)
 	}
-
-	// Wrap floors' perimeter with walls,
-	// and replace internal voids with walls
-	// (any neighbor of a floor that is not a floor).
-	// We do this after collecting all the floors above
-	// just in case they weren't in a sorted order.
-	for _, coord := range p.Terrain {
-		for _, neighbor := range neighbors(coord) {
-			rectcoord := HexToRect(neighbor.Coord(), -minRow, -minCol)
-			if rectgrid.Lookup(rectcoord.row, rectcoord.col) == BLANK {
-				rectgrid.Assign(rectcoord.row, rectcoord.col, TOKEN_WALL)
-			} // ignores invalid (out-of-bounds) and floor tiles.
-		}
+	for _, coord := range walls {
+		wallxy := HexToRect(coord, -minCol, -minRow)
+		rectgrid.Assign(wallxy.row, wallxy.col, TOKEN_WALL)
 	}
 
-	// add goals on floors and build presence table of goal locations
-	goals := make(map[RectCoord]bool)
+	// add goals on floors
 	for _, goal := range p.Init.Goals {
-		goalxy := HexToRect(goal, -minRow, -minCol)
-		goals[goalxy] = true
+		goalxy := HexToRect(goal, -minCol, -minRow)
 		if !rectgrid.ValidFloor(goalxy.row, goalxy.col) {
 			errors.add(fmt.Sprintf("goal not in-bounds: line %d col %d",
 				goalxy.row, goalxy.col))
@@ -113,25 +89,28 @@ func MapString(p hexoban.Puzzle) (string, error) {
 
 	// add crates (and crates on goals)
 	for _, crate := range p.Init.Crates {
-		cratexy := HexToRect(crate, -minRow, -minCol)
-		presentTile := rectgrid.glyphs[cratexy.row][cratexy.col]
-		if presentTile == TOKEN_FLOOR {
+		cratexy := HexToRect(crate, -minCol, -minRow)
+		switch rectgrid.assigned(cratexy.row, cratexy.col) {
+		case TOKEN_FLOOR:
 			rectgrid.Assign(cratexy.row, cratexy.col, TOKEN_CRATE)
-		} else if presentTile == TOKEN_GOAL {
+		case TOKEN_GOAL:
 			rectgrid.Assign(cratexy.row, cratexy.col, TOKEN_CRATE_GOAL)
-		} else {
-			errors.add(fmt.Sprintf("crate coordinate invalid: line %d col %d", cratexy.row, cratexy.col))
+		default:
+			errors.add(fmt.Sprintf("crate coordinate invalid: line %d col %d",
+				cratexy.row, cratexy.col))
 		}
 	}
 
 	// add ichiban position (@, or + if on goal)
-	workerxy := HexToRect(p.Init.Ichiban, -minRow, -minCol)
-	if goals[workerxy] {
-		rectgrid.Assign(workerxy.row, workerxy.col, TOKEN_AT_GOAL)
-	} else if rectgrid.ValidFloor(workerxy.row, workerxy.col) {
+	workerxy := HexToRect(p.Init.Ichiban, -minCol, -minRow)
+	switch rectgrid.assigned(workerxy.row, workerxy.col) {
+	case TOKEN_FLOOR:
 		rectgrid.Assign(workerxy.row, workerxy.col, TOKEN_AT)
-	} else {
-		errors.add(fmt.Sprintf("ichiban coordinate invalid: line %d col %d", workerxy.row, workerxy.col))
+	case TOKEN_GOAL:
+		rectgrid.Assign(workerxy.row, workerxy.col, TOKEN_AT_GOAL)
+	default:
+		errors.add(fmt.Sprintf("ichiban coordinate invalid: line %d col %d",
+			workerxy.row, workerxy.col))
 	}
 
 	// finally, render the grid of glyphs to newline-separated strings.
@@ -202,6 +181,16 @@ func (grid *RectGrid) ValidFloor(line uint, col uint) bool {
 		grid.glyphs[line][col] == TOKEN_FLOOR
 }
 
+// Returns the glyph assigned at the given coordinate, or BLANK when the
+// coordinate is outside the grid.  Unlike Lookup, floors and out-of-bounds
+// spaces are not confused.
+func (grid *RectGrid) assigned(line uint, col uint) TokenType {
+	if line >= uint(len(grid.glyphs)) || col >= uint(len(grid.glyphs[line])) {
+		return BLANK
+	}
+	return grid.glyphs[line][col]
+}
+
 func (grid *RectGrid) Stringify() string {
 	skippable := 0
 	for _, tokens := range grid.glyphs {
@@ -240,9 +229,3 @@ func (merr multierror) Error() string {
 func (merr *multierror) add(err string) {
 	merr.errs = append(merr.errs, err)
 }
-
-// Returns a string with basic information about the puzzle.
-func Info(puzzle hexoban.Puzzle) string {
-	// TODO: what to put here?  sizeof Terrain?  #crates|goals?  Complexity?
-	return fmt.Sprintf("%s\nby %s\n\n", puzzle.Title, puzzle.Author)
-}
@@ -12,11 +12,12 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 //
-// github:SymbolNotFound/hexoban/cmd/editor/fmt_test.go
+// github:SymbolNotFound/hexoban/textmap/fmt_test.go
 
-package main
+package textmap
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/SymbolNotFound/hexoban"
@@ -101,3 +102,37 @@ func TestMapString(t *testing.T) {
 		})
 	}
 }
+
+func TestMapString_OffTerrain(t *testing.T) {
+	at := hexoban.NewHexCoord
+	terrain := []hexoban.HexCoord{at(1, 2), at(1, 3), at(2, 2), at(2, 3), at(2, 4)}
+	tests := []struct {
+		name   string
+		init   hexoban.Init
+		expect string
+	}{
+		{"crate past the walls",
+			hexoban.Init{Goals: []hexoban.HexCoord{at(2, 4)}, Crates: []hexoban.HexCoord{at(9, 9)}, Ichiban: at(2, 2)},
+			"crate coordinate invalid"},
+		{"crate before the walls",
+			hexoban.Init{Goals: []hexoban.HexCoord{at(2, 4)}, Crates: []hexoban.HexCoord{at(-5, -5)}, Ichiban: at(2, 2)},
+			"crate coordinate invalid"},
+		{"ichiban on an off-terrain goal",
+			hexoban.Init{Goals: []hexoban.HexCoord{at(9, 9)}, Crates: []hexoban.HexCoord{at(2, 3)}, Ichiban: at(9, 9)},
+			"ichiban coordinate invalid"},
+		{"ichiban on a crate",
+			hexoban.Init{Goals: []hexoban.HexCoord{at(2, 4)}, Crates: []hexoban.HexCoord{at(2, 3)}, Ichiban: at(2, 3)},
+			"ichiban coordinate invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MapString(hexoban.Puzzle{Terrain: terrain, Init: tt.init})
+			if err == nil {
+				t.Fatalf("MapString() = %q, expected an error", got)
+			}
+			if !strings.Contains(err.Error(), tt.expect) {
+				t.Errorf("MapString() error = %v, expected %q", err, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/textmap/parser.go
+
+package textmap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	hexoban "github.com/SymbolNotFound/hexoban"
+)
+
+// The width of a tab stop.  Tabs are expanded to the next multiple of this many
+// columns before the glyphs are aligned to the hexagonal grid.
+const TAB_WIDTH = 8
+
+// Parses a line-oriented sequence of (tile, gap) byte pairs representing the
+// double-height offset coordinates layout of a hexagonal grid for a hexoban
+// puzzle's initial conditions.  See TokenType for the glyph representations.
+//
+// The map may be indented by any amount, the alignment of odd and even columns
+// is inferred from the majority of its tiles.  Floors are the spaces enclosed
+// by walls, spaces outside the walls are ignored.  The map ends at the first
+// blank line following it, or at the end of input.
+//
+// All problems found in the map are returned together as an ErrorList, see its
+// Pretty() method for displaying them alongside the source text.
+func ParsePuzzleDefinition(reader *bufio.Reader, puzzle *hexoban.Puzzle) error {
+	parser := parserState{scanner: reader}
+	tokens := make([]token, 0)
+	for token := parser.NextToken(); token.glyph != TOKEN_EOF; token = parser.NextToken() {
+		if token.glyph != TOKEN_NEWLINE {
+			tokens = append(tokens, token)
+		}
+	}
+	if parser.err != nil {
+		return parser.err
+	}
+
+	tiles, errs := parser.layout(tokens)
+	if len(errs) > 0 {
+		return errs
+	}
+	puzzle.AddTiles(tiles)
+	return nil
+}
+
+// Returns the next puzzle-specific token with its (line, col) position,
+// skipping spaces.  Leading blank lines are skipped and the map ends with
+// TOKEN_EOF at the first blank line after the map or the end of input, every
+// call after that also returns TOKEN_EOF.  Any glyph which is not a tile is
+// returned as TOKEN_ERROR_WHAT and scanning continues after it, so that all of
+// the map's problems can be reported.  A newline token is returned at the end
+// of each line.
+func (parser *parserState) NextToken() token {
+	if !parser.inLine {
+		text, ok := "", false
+		if !parser.done {
+			text, ok = parser.nextLine()
+		}
+		if !ok {
+			parser.done = true
+			return token{TOKEN_EOF, uint(len(parser.lines) - parser.first), 0}
+		}
+		parser.text, parser.col, parser.inLine = text, 0, true
+	}
+
+	line := uint(len(parser.lines) - 1 - parser.first)
+	for parser.col < len(parser.text) {
+		glyph := TokenType(parser.text[parser.col])
+		parser.col++
+		if glyph == ' ' {
+			continue
+		}
+		if !glyph.IsTile() {
+			glyph = TOKEN_ERROR_WHAT
+		}
+		return token{glyph, line, uint(parser.col - 1)}
+	}
+	parser.inLine = false
+	return token{TOKEN_NEWLINE, line, uint(len(parser.text))}
+}
+
+// The parser's private state representation, only used within this package.
+type parserState struct {
+	scanner *bufio.Reader
+	lines   []string // the source lines read, tabs expanded and right-trimmed.
+	first   int      // index into lines of the map's first line.
+	err     error    // an error reading from scanner, other than io.EOF.
+
+	// The line being tokenized and the column of the next glyph to scan.
+	text   string
+	col    int
+	inLine bool
+	done   bool // the map has ended, only TOKEN_EOF remains.
+}
+
+// Reads the next line of the map, skipping any blank lines before the map.
+// Returns false when the map has ended or there is no more input.
+func (parser *parserState) nextLine() (string, bool) {
+	for {
+		text, err := parser.scanner.ReadString('\n')
+		if err != nil && err != io.EOF {
+			parser.err = err
+			return "", false
+		}
+		if err == io.EOF && text == "" {
+			return "", false
+		}
+		text = expandTabs(strings.TrimRight(text, " \t\r\n"))
+		parser.lines = append(parser.lines, text)
+
+		started := len(parser.lines) > parser.first+1 ||
+			(len(parser.lines) == parser.first+1 && text != "")
+		if text == "" {
+			if started {
+				return "", false // a blank line ends the map.
+			}
+			parser.first++ // still before the map.
+			continue
+		}
+		return text, true
+	}
+}
+
+// Replaces each tab with spaces up to the next multiple of TAB_WIDTH.
+func expandTabs(text string) string {
+	if !strings.Contains(text, "\t") {
+		return text
+	}
+	var expanded strings.Builder
+	for _, char := range text {
+		if char == '\t' {
+			expanded.WriteString(strings.Repeat(" ", TAB_WIDTH-expanded.Len()%TAB_WIDTH))
+		} else {
+			expanded.WriteRune(char)
+		}
+	}
+	return expanded.String()
+}
+
+// Interprets the map's tokens as tiles, inferring the alignment of the grid
+// and which spaces are floors.  Returns the tiles in reading order, or the list
+// of errors if there were any problems with the map.
+func (parser *parserState) layout(tokens []token) ([]hexoban.Tile, ErrorList) {
+	errs := ErrorList{}
+	report := func(at token, message string, args ...any) {
+		errs = append(errs, parser.errorAt(at, fmt.Sprintf(message, args...)))
+	}
+
+	// The alignment of odd and even columns is decided by majority, a tile is
+	// aligned when the parity of its column and row are the same.
+	parity := [2]int{}
+	for _, token := range tokens {
+		if token.glyph.IsTile() {
+			parity[(token.col+token.line)&1]++
+		}
+	}
+	offset := uint(0)
+	if parity[1] > parity[0] {
+		offset = 1
+	}
+
+	grid := make(map[RectCoord]TokenType)
+	positions := make(map[RectCoord]token)
+	for _, token := range tokens {
+		if token.glyph == TOKEN_ERROR_WHAT {
+			char := parser.lines[parser.first+int(token.line)][token.col]
+			report(token, "unexpected character %q, expected one of \"#.$*@+\"", char)
+			continue
+		}
+		row := token.line + offset
+		if (token.col+row)&1 != 0 {
+			report(token, "tile %q is between the columns of hexagons on this line", byte(token.glyph))
+			continue
+		}
+		coord := RectCoord{token.col, row}
+		grid[coord] = token.glyph
+		positions[coord] = token
+	}
+	if len(grid) == 0 {
+		if len(errs) == 0 {
+			errs = append(errs, &ParseError{Span{1, 1, 1}, "no map found", ""})
+		}
+		return nil, errs
+	}
+
+	// Fill the outside of the walls, starting from a border around the map.
+	minRow, maxRow := len(parser.lines), 0
+	minCol, maxCol := int(^uint(0)>>1), 0
+	for coord := range grid {
+		minRow, maxRow = min(minRow, int(coord.row)), max(maxRow, int(coord.row))
+		minCol, maxCol = min(minCol, int(coord.col)), max(maxCol, int(coord.col))
+	}
+	type cell struct{ row, col int }
+	outside := make(map[cell]bool)
+	queue := make([]cell, 0)
+	for row := minRow - 1; row <= maxRow+1; row++ {
+		for col := minCol - 2; col <= maxCol+2; col++ {
+			if (row+col)&1 == 0 && (row < minRow || row > maxRow || col < minCol || col > maxCol) {
+				outside[cell{row, col}] = true
+				queue = append(queue, cell{row, col})
+			}
+		}
+	}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, delta := range [6][2]int{{0, -2}, {0, 2}, {-1, -1}, {-1, 1}, {1, -1}, {1, 1}} {
+			neighbor := cell{next.row + delta[0], next.col + delta[1]}
+			if neighbor.row < minRow || neighbor.row > maxRow ||
+				neighbor.col < minCol || neighbor.col > maxCol || outside[neighbor] {
+				continue
+			}
+			if grid[RectCoord{uint(neighbor.col), uint(neighbor.row)}] == TOKEN_WALL {
+				continue
+			}
+			outside[neighbor] = true
+			queue = append(queue, neighbor)
+		}
+	}
+
+	// Everything inside the walls is a tile, spaces become floors.
+	tiles := make([]hexoban.Tile, 0)
+	var ichiban *token
+	for row := minRow; row <= maxRow; row++ {
+		for col := minCol + (row+minCol)&1; col <= maxCol; col += 2 {
+			coord := RectCoord{uint(col), uint(row)}
+			glyph, exists := grid[coord]
+			if outside[cell{row, col}] {
+				if exists && glyph != TOKEN_WALL {
+					report(positions[coord], "%s is outside of the walls", describe(glyph))
+				}
+				continue
+			}
+			if !exists {
+				glyph = TOKEN_FLOOR
+			}
+			if glyph == TOKEN_AT || glyph == TOKEN_AT_GOAL {
+				if ichiban != nil {
+					report(positions[coord], "ichiban is already at line %d, col %d",
+						parser.first+int(ichiban.line)+1, ichiban.col+1)
+				}
+				at := positions[coord]
+				ichiban = &at
+			}
+			tiles = append(tiles, token{glyph, coord.row, coord.col}.parseTile()...)
+		}
+	}
+	return tiles, errs
+}
+
+// Constructs an error for the token's position, with the text of its line.
+func (parser *parserState) errorAt(at token, message string) *ParseError {
+	line := parser.first + int(at.line)
+	return &ParseError{Span{line + 1, int(at.col) + 1, 1}, message, parser.lines[line]}
+}
+
+// A short description of the tile's contents, for error messages.
+func describe(glyph TokenType) string {
+	switch glyph {
+	case TOKEN_GOAL:
+		return "goal"
+	case TOKEN_CRATE, TOKEN_CRATE_GOAL:
+		return "crate"
+	case TOKEN_AT, TOKEN_AT_GOAL:
+		return "ichiban"
+	}
+	return fmt.Sprintf("tile %q", byte(glyph))
+}
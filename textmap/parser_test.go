@@ -0,0 +1,297 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/textmap/parser_test.go
+
+package textmap
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+func TestParsePuzzleDefinition(t *testing.T) {
+	at := hexoban.NewHexCoord
+	tests := []struct {
+		name     string
+		text     string
+		expected hexoban.Puzzle
+	}{
+		{
+			"small room",
+			"  # #\n #   # \n  # #",
+			hexoban.Puzzle{
+				Terrain: []hexoban.HexCoord{at(1, 2)},
+			},
+		},
+		{
+			"odd small room",
+			" # # \n#   #\n # #\n",
+			hexoban.Puzzle{
+				Terrain: []hexoban.HexCoord{at(2, 2)},
+			},
+		},
+		{
+			"nudged one column",
+			"  # # \n #   #\n  # #\n",
+			hexoban.Puzzle{
+				Terrain: []hexoban.HexCoord{at(1, 2)},
+			},
+		},
+		{
+			"nudged two columns",
+			"   # # \n  #   #\n   # #\n",
+			hexoban.Puzzle{
+				Terrain: []hexoban.HexCoord{at(2, 3)},
+			},
+		},
+		{
+			"tabs and trailing whitespace",
+			"\n\t # #  \t\r\n\t#   #\t\r\n\t # #\r\n",
+			hexoban.Puzzle{
+				Terrain: []hexoban.HexCoord{at(2, 6)},
+			},
+		},
+		{
+			"spaces outside the walls",
+			"  # #   # #\n #   # #   #\n  # #   # #\n",
+			hexoban.Puzzle{
+				Terrain: []hexoban.HexCoord{at(1, 2), at(1, 5)},
+			},
+		},
+		{
+			"dws001",
+			`   # # #
+  #     #
+   #     #
+  #   . #
+ #   .   #
+#   $ $   #
+ # # # *   #
+    # @   #
+     # # #
+
+; credit for this puzzle goes to David W. Skinner`,
+			hexoban.Puzzle{
+				Terrain: []hexoban.HexCoord{
+					/*    # # #     */
+					/*   #     #    */ at(2, 3), at(2, 4),
+					/*    #     #   */ at(3, 4), at(3, 5),
+					/*   #   . #    */ at(4, 4), at(4, 5),
+					/*  #   .   #   */ at(5, 4), at(5, 5), at(5, 6),
+					/* #   $ $   #  */ at(6, 4), at(6, 5), at(6, 6), at(6, 7),
+					/*  # # # *   # */ at(7, 7), at(7, 8),
+					/*     # @   #  */ at(8, 7), at(8, 8),
+					/*      # # #   */
+				},
+				Init: hexoban.Init{
+					Goals: []hexoban.HexCoord{
+						at(4, 5), at(5, 5), at(7, 7),
+					},
+					Crates: []hexoban.HexCoord{
+						at(6, 5), at(6, 6), at(7, 7),
+					},
+					Ichiban: at(8, 7),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.text))
+			puzzle := hexoban.Puzzle{}
+			if err := ParsePuzzleDefinition(reader, &puzzle); err != nil {
+				t.Errorf("ParsePuzzleDefinition() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(puzzle, tt.expected) {
+				result, _ := MapString(puzzle)
+				expected, _ := MapString(tt.expected)
+				t.Errorf("ParsePuzzleDefinition()\n%s\n,,,\nexpected:\n%s", result, expected)
+			}
+		})
+	}
+}
+
+func TestParsePuzzleDefinition_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		expect []Span
+	}{
+		{"empty", "\n\n", []Span{{1, 1, 1}}},
+		{
+			"several problems",
+			"  # # #\n # x   #\n# @ $ . #\n # # # ##\n    $\n",
+			[]Span{{2, 4, 1}, {4, 9, 1}, {5, 5, 1}},
+		},
+		{"two ichiban", "\n  # # #\n # @ @ #\n  # # #", []Span{{3, 6, 1}}},
+		{"goal outside", "  # #\n #   #\n  # #   .", []Span{{3, 9, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.text))
+			err := ParsePuzzleDefinition(reader, &hexoban.Puzzle{})
+			list, ok := err.(ErrorList)
+			if !ok {
+				t.Fatalf("ParsePuzzleDefinition() error = %v, expected an ErrorList", err)
+			}
+			spans := make([]Span, len(list))
+			for index, err := range list {
+				spans[index] = err.Span
+			}
+			if !reflect.DeepEqual(spans, tt.expect) {
+				t.Errorf("ParsePuzzleDefinition() errors at %v, expected %v\n%s",
+					spans, tt.expect, list.Pretty())
+			}
+		})
+	}
+}
+
+func TestErrorList_Pretty(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("  # # #\n # x   #\n# @ $ . #\n # # # #"))
+	err := ParsePuzzleDefinition(reader, &hexoban.Puzzle{})
+	list, ok := err.(ErrorList)
+	if !ok {
+		t.Fatalf("ParsePuzzleDefinition() error = %v, expected an ErrorList", err)
+	}
+	expect := `line 2, col 4: unexpected character 'x', expected one of "#.$*@+"
+    2 |  # x   #
+      |    ^`
+	if got := list.Pretty(); got != expect {
+		t.Errorf("ErrorList.Pretty()\n%s\nexpected:\n%s", got, expect)
+	}
+}
+
+func TestParserState_NextToken(t *testing.T) {
+	parser := parserState{scanner: bufio.NewReader(strings.NewReader(" # #\n#   #\n\n# #"))}
+	expect := []token{
+		{TOKEN_WALL, 0, 1}, {TOKEN_WALL, 0, 3}, {TOKEN_NEWLINE, 0, 4},
+		{TOKEN_WALL, 1, 0}, {TOKEN_WALL, 1, 4}, {TOKEN_NEWLINE, 1, 5},
+		{TOKEN_EOF, 3, 0}, {TOKEN_EOF, 3, 0},
+	}
+	for index, want := range expect {
+		if got := parser.NextToken(); got != want {
+			t.Errorf("NextToken() #%d = %v, expected %v", index, got, want)
+		}
+	}
+}
+
+// Parsing stops early for invalid maps and callers may abandon the scanner
+// part-way, neither of which may leave anything running or read ahead.
+func TestParsePuzzleDefinition_NoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for count := 0; count < 100; count++ {
+		reader := bufio.NewReader(strings.NewReader("  # #\n # x #\n  # #\n"))
+		if err := ParsePuzzleDefinition(reader, &hexoban.Puzzle{}); err == nil {
+			t.Fatal("ParsePuzzleDefinition() expected an error")
+		}
+
+		reader = bufio.NewReader(strings.NewReader(" # #\n# @ #\n # #\n"))
+		parser := parserState{scanner: reader}
+		for index := 0; index < 3; index++ {
+			parser.NextToken()
+		}
+		if rest, _ := reader.ReadString(0); rest != "# @ #\n # #\n" {
+			t.Fatalf("abandoned parser left %q unread, expected the last two lines", rest)
+		}
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines before parsing, %d after", before, after)
+	}
+}
+
+// The text maps of every level in the collection.
+func corpusMaps(b *testing.B) []string {
+	paths, err := filepath.Glob("../levels/*/*.json")
+	if err != nil || len(paths) == 0 {
+		b.Fatalf("no levels found: %v", err)
+	}
+	maps := make([]string, 0, len(paths))
+	for _, path := range paths {
+		filedata, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var puzzle hexoban.Puzzle
+		if err := json.Unmarshal(filedata, &puzzle); err != nil {
+			b.Fatal(err)
+		}
+		text, err := MapString(puzzle)
+		if err != nil {
+			b.Fatal(err)
+		}
+		maps = append(maps, text)
+	}
+	return maps
+}
+
+// The tokenizer's earlier design, a goroutine sending each token through an
+// unbuffered channel, kept here for comparison.
+func channelTokens(parser *parserState) <-chan token {
+	tokenchan := make(chan token)
+	go func() {
+		defer close(tokenchan)
+		for {
+			token := parser.NextToken()
+			tokenchan <- token
+			if token.glyph == TOKEN_EOF {
+				return
+			}
+		}
+	}()
+	return tokenchan
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	maps := corpusMaps(b)
+	b.Run("pull", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, text := range maps {
+				parser := parserState{scanner: bufio.NewReader(strings.NewReader(text))}
+				for token := parser.NextToken(); token.glyph != TOKEN_EOF; token = parser.NextToken() {
+				}
+			}
+		}
+	})
+	b.Run("channel", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, text := range maps {
+				parser := parserState{scanner: bufio.NewReader(strings.NewReader(text))}
+				for range channelTokens(&parser) {
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	maps := corpusMaps(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, text := range maps {
+			if _, err := Decode(strings.NewReader(text)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
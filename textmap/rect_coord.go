@@ -12,21 +12,31 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 //
-// github:SymbolNotFound/hexoban/cmd/editor/rect_coord.go
+// github:SymbolNotFound/hexoban/textmap/rect_coord.go
 
-package main
+package textmap
 
 import "github.com/SymbolNotFound/hexoban"
 
-// This RectCoord is only provided within the `main` package of a CLI tool.
-// It should not be used in concert with other clients of the hexoban library.
+// The position of a glyph within a text map, a column and line (row) which are
+// both relative to the top-left of the map.  Only coordinates with the same
+// odd/even parity of column and row represent a hexagon in the puzzle.
 //
-// If using it, convert it into an axial hex coordinate using RectCoord.ToHex().
+// Convert it into an axial hex coordinate using RectCoord.ToHex() for use with
+// the rest of the hexoban library.
 type RectCoord struct {
 	col uint
 	row uint
 }
 
+func NewRectCoord(col, row uint) RectCoord {
+	return RectCoord{col, row}
+}
+
+// Accessors are read-only, like those of the HexCoord.
+func (coord RectCoord) Col() uint { return coord.col }
+func (coord RectCoord) Row() uint { return coord.row }
+
 // Converts a rectangular coordinate into the equivalent hexagonal coordinate.
 // Assumes that the (0, 0) center for both systems exists at top-left position.
 //
@@ -86,3 +96,18 @@ func HexToRect(hex hexoban.HexCoord, fromCol, fromRow int) RectCoord {
 		row: uint(hex.I() + fromRow),
 	}
 }
+
+// Returns the least and greatest column and row that HexToRect(coord, 0, 0)
+// would give for any of the coordinates, which (unlike those of a RectCoord)
+// may be negative.  Their negation is the translation to pass to HexToRect()
+// for placing all of the coordinates within a map.
+func RectBounds(coords []hexoban.HexCoord) (minCol, minRow, maxCol, maxRow int) {
+	minRow, minCol = coords[0].I(), (coords[0].J()<<1)-coords[0].I()
+	maxRow, maxCol = minRow, minCol
+	for _, coord := range coords {
+		row, col := coord.I(), (coord.J()<<1)-coord.I()
+		minRow, maxRow = min(minRow, row), max(maxRow, row)
+		minCol, maxCol = min(minCol, col), max(maxCol, col)
+	}
+	return minCol, minRow, maxCol, maxRow
+}
@@ -12,9 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 //
-// github:SymbolNotFound/hexoban/cmd/editor/rectcoord_test.go
+// github:SymbolNotFound/hexoban/textmap/rectcoord_test.go
 
-package main
+package textmap
 
 import (
 	"reflect"
@@ -72,3 +72,24 @@ func TestHexToRect(t *testing.T) {
 		})
 	}
 }
+
+func TestRectBounds(t *testing.T) {
+	coord := hexoban.NewHexCoord
+	tests := []struct {
+		name   string
+		coords []hexoban.HexCoord
+		expect [4]int // minCol, minRow, maxCol, maxRow
+	}{
+		{"one", []hexoban.HexCoord{coord(1, 1)}, [4]int{1, 1, 1, 1}},
+		{"row", []hexoban.HexCoord{coord(2, 2), coord(2, 3), coord(2, 4)}, [4]int{2, 2, 6, 2}},
+		{"negative", []hexoban.HexCoord{coord(0, 0), coord(-1, -1), coord(1, -2)}, [4]int{-5, -1, 0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minCol, minRow, maxCol, maxRow := RectBounds(tt.coords)
+			if got := [4]int{minCol, minRow, maxCol, maxRow}; got != tt.expect {
+				t.Errorf("RectBounds() = %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
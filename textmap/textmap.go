@@ -0,0 +1,54 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/textmap/textmap.go
+
+// Package textmap reads and writes hexoban puzzles in the text format of the
+// .hsb files found in the wild, a doubled-height offset grid of glyphs where
+// each line of text alternates between odd and even columns of hexagons:
+//
+// .      # # #
+// .     #     #
+// .    # @ $ . #
+// .     # # # #
+//
+// See TokenType for the glyphs of each tile.  The conversion between positions
+// in the text and hexagonal coordinates is described by RectCoord.
+package textmap
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+// Reads a puzzle's terrain and initial conditions from its text map.  Only the
+// Terrain and Init properties of the returned puzzle are defined.
+func Decode(reader io.Reader) (hexoban.Puzzle, error) {
+	puzzle := hexoban.Puzzle{}
+	err := ParsePuzzleDefinition(bufio.NewReader(reader), &puzzle)
+	return puzzle, err
+}
+
+// Writes the puzzle's terrain and initial conditions as a text map, followed by
+// a newline.  The puzzle's other properties are not included.
+func Encode(writer io.Writer, puzzle hexoban.Puzzle) error {
+	text, err := MapString(puzzle)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, text+"\n")
+	return err
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2024 Symbol Not Found L.L.C.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// github:SymbolNotFound/hexoban/textmap/textmap_test.go
+
+package textmap
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SymbolNotFound/hexoban"
+)
+
+func TestDecode(t *testing.T) {
+	puzzle, err := Decode(strings.NewReader("  # # #\n #     #\n# @ $ . #\n # # # #\n"))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	at := hexoban.NewHexCoord
+	expected := hexoban.Puzzle{
+		Terrain: []hexoban.HexCoord{at(1, 2), at(1, 3), at(2, 2), at(2, 3), at(2, 4)},
+		Init: hexoban.Init{
+			Goals:   []hexoban.HexCoord{at(2, 4)},
+			Crates:  []hexoban.HexCoord{at(2, 3)},
+			Ichiban: at(2, 2),
+		},
+	}
+	if !reflect.DeepEqual(puzzle, expected) {
+		t.Errorf("Decode() = %v, expected %v", puzzle, expected)
+	}
+}
+
+// Every level in the collection can be written as a text map and read back,
+// the result differing only by a translation of its coordinates.
+func TestEncode_RoundTrip(t *testing.T) {
+	paths, err := filepath.Glob("../levels/*/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no levels found: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			filedata, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var puzzle hexoban.Puzzle
+			if err := json.Unmarshal(filedata, &puzzle); err != nil {
+				t.Fatal(err)
+			}
+
+			var text strings.Builder
+			if err := Encode(&text, puzzle); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			decoded, err := Decode(strings.NewReader(text.String()))
+			if err != nil {
+				t.Fatalf("Decode() error = %v\n%s", err, text.String())
+			}
+
+			// Translate the decoded puzzle by the offset of the ichiban, which
+			// is always on the terrain.
+			di := puzzle.Init.Ichiban.I() - decoded.Init.Ichiban.I()
+			dj := puzzle.Init.Ichiban.J() - decoded.Init.Ichiban.J()
+			translate := func(coords []hexoban.HexCoord, di, dj int) map[hexoban.HexCoord]bool {
+				moved := make(map[hexoban.HexCoord]bool, len(coords))
+				for _, coord := range coords {
+					moved[hexoban.NewHexCoord(coord.I()+di, coord.J()+dj)] = true
+				}
+				return moved
+			}
+			if !maps.Equal(translate(decoded.Terrain, di, dj), translate(puzzle.Terrain, 0, 0)) {
+				t.Errorf("decoded terrain differs from the original\n%s", text.String())
+			}
+			if !maps.Equal(translate(decoded.Init.Goals, di, dj), translate(puzzle.Init.Goals, 0, 0)) {
+				t.Errorf("decoded goals differ from the original\n%s", text.String())
+			}
+			if !maps.Equal(translate(decoded.Init.Crates, di, dj), translate(puzzle.Init.Crates, 0, 0)) {
+				t.Errorf("decoded crates differ from the original\n%s", text.String())
+			}
+		})
+	}
+}
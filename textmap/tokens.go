@@ -12,26 +12,21 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 //
-// github:SymbolNotFound/hexoban/cmd/editor/tokens.go
+// github:SymbolNotFound/hexoban/textmap/tokens.go
 
-package main
+package textmap
 
 import "github.com/SymbolNotFound/hexoban"
 
 // A token is defined by its glyph (printable) representation and its position.
-// The position is relative to the first non-empty line of the text, so it may
-// differ from the absolute byte position as measured from the beginning.
+// The position is relative to the first non-empty line of the text, and its
+// column is counted after tabs have been expanded (see TAB_WIDTH).
 type token struct {
 	glyph TokenType
 	line  uint
 	col   uint
 }
 
-// Constructor function for a token instance.
-func Token(glyph TokenType, cursor cursor) token {
-	return token{glyph, cursor.lines, uint(cursor.cols)}
-}
-
 type TokenType byte
 
 const (
@@ -60,58 +55,47 @@ func (glyph TokenType) IsTile() bool {
 	return false
 }
 
-type (
-	Tile   = hexoban.Tile
-	Floor  = hexoban.Floor
-	Wall   = hexoban.Wall
-	Goal   = hexoban.Goal
-	Crate  = hexoban.Crate
-	Player = hexoban.Player
-
-	HexCoord = hexoban.HexCoord
-)
-
-func (t token) parseTile() []Tile {
+func (t token) parseTile() []hexoban.Tile {
 	coord := RectCoord{t.col, t.line}.ToHex()
 
 	switch t.glyph {
 	case TOKEN_WALL:
 		// Walls are provided in the results but they can later be ignored.
-		return []Tile{Wall{HexCoord: coord}}
+		return []hexoban.Tile{hexoban.Wall{HexCoord: coord}}
 	case TOKEN_FLOOR:
 		// This assumes parseTile is only called after the first (wall) glyph.
-		return []Tile{Floor{HexCoord: coord}}
+		return []hexoban.Tile{hexoban.Floor{HexCoord: coord}}
 	case TOKEN_GOAL:
 		// A goal without a crate on it.
-		return []Tile{
-			Floor{HexCoord: coord},
-			Goal{HexCoord: coord},
+		return []hexoban.Tile{
+			hexoban.Floor{HexCoord: coord},
+			hexoban.Goal{HexCoord: coord},
 		}
 	case TOKEN_CRATE:
 		// A crate on normal (non-goal) floor.
-		return []Tile{
-			Floor{HexCoord: coord},
-			Crate{HexCoord: coord},
+		return []hexoban.Tile{
+			hexoban.Floor{HexCoord: coord},
+			hexoban.Crate{HexCoord: coord},
 		}
 	case TOKEN_CRATE_GOAL:
 		// A crate on a goal.
-		return []Tile{
-			Floor{HexCoord: coord},
-			Goal{HexCoord: coord},
-			Crate{HexCoord: coord},
+		return []hexoban.Tile{
+			hexoban.Floor{HexCoord: coord},
+			hexoban.Goal{HexCoord: coord},
+			hexoban.Crate{HexCoord: coord},
 		}
 	case TOKEN_AT:
 		// A player on a normal (non-goal) floor.
-		return []Tile{
-			Floor{HexCoord: coord},
-			Player{HexCoord: coord},
+		return []hexoban.Tile{
+			hexoban.Floor{HexCoord: coord},
+			hexoban.Player{HexCoord: coord},
 		}
 	case TOKEN_AT_GOAL:
 		// A player on a goal.
-		return []Tile{
-			Floor{HexCoord: coord},
-			Player{HexCoord: coord},
-			Goal{HexCoord: coord},
+		return []hexoban.Tile{
+			hexoban.Floor{HexCoord: coord},
+			hexoban.Player{HexCoord: coord},
+			hexoban.Goal{HexCoord: coord},
 		}
 	}
 
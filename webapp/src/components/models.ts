@@ -45,7 +45,7 @@ export interface PuzzleInitJSON {
 // along with some metadata about the puzzle.
 export interface PuzzleJSON {
   readonly id: string
-  name: string
+  title: string
   author: string
   source: string
   terrain: HexCoordTuple[]